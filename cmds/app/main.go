@@ -1,41 +1,67 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
-	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	pkgauth "go-clean-grpc/pkg/auth"
 	"go-clean-grpc/pkg/config"
 	"go-clean-grpc/pkg/logger"
 	pkgmongodb "go-clean-grpc/pkg/mongodb"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
 	pkgvalidator "go-clean-grpc/pkg/validator"
 	todogrpcdelivery "go-clean-grpc/todo/delivery/grpc"
 	todoproto "go-clean-grpc/todo/delivery/grpc/proto"
 	todohttpdelivery "go-clean-grpc/todo/delivery/http"
+	"go-clean-grpc/todo/policy"
 	todorepository "go-clean-grpc/todo/repository"
+	memoryrepository "go-clean-grpc/todo/repository/memory"
+	sqlrepository "go-clean-grpc/todo/repository/sql"
 	todoservice "go-clean-grpc/todo/service"
+	actorutil "go-clean-grpc/utils/actor"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+	ratelimitutil "go-clean-grpc/utils/ratelimit"
 	responseutil "go-clean-grpc/utils/response"
+	tenantutil "go-clean-grpc/utils/tenant"
 )
 
-func Routes() *chi.Mux {
+// publicPaths - REST paths served without a bearer token nor a tenant
+var publicPaths = []string{"/"}
+
+// publicMethods - gRPC full methods served without a bearer token nor a tenant
+var publicMethods = []string{"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"}
+
+func Routes(verifier *pkgauth.Verifier, resolver *tenantutil.Resolver, ipLimiter *ratelimitutil.Limiter) *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(
 		render.SetContentType(render.ContentTypeJSON), // Set content-Type headers as application/json
 		middleware.Logger, // Log API request calls
 		// middleware.DefaultCompress, // Compress results, mostly gzipping assets and json
-		middleware.RedirectSlashes, // Redirect slashes to no slash URL versions
-		middleware.Recoverer,       // Recover from panics without crashing server
+		middleware.RedirectSlashes,            // Redirect slashes to no slash URL versions
+		middleware.Recoverer,                  // Recover from panics without crashing server
+		ratelimitutil.IPMiddleware(ipLimiter), // Limit the requests of each IP, before the authentication so the rejected requests are limited too
 	)
+	if verifier != nil {
+		router.Use(pkgauth.Middleware(verifier, publicPaths...)) // Authenticate the request with the bearer token
+	}
+	router.Use(tenantutil.Middleware(resolver, publicPaths...)) // Set the tenant of the request from the token or the X-Tenant-ID header
+	router.Use(actorutil.Middleware)                            // Set the actor of the request to the user, from the X-Actor header without authentication
 
 	return router
 }
@@ -64,36 +90,239 @@ func main() {
 		logger.Error(err)
 	}
 
-	// Init MongoDB
-	_, cancel, client := pkgmongodb.InitMongoDB()
-	defer cancel()
+	// Tenants of TENANTS, admins can pick any of them
+	resolver, err := tenantutil.NewResolverFromEnv(policy.RoleAdmin)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+
+	// Init repositories, shared by both servers
+	repos, err := newRepositories(resolver)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+
+	// Token and API key verifier, shared by both servers
+	verifier, err := newVerifier(repos.keys)
+	if err != nil {
+		logger.Error(err)
+		repos.close(context.Background())
+		os.Exit(1)
+	}
+
+	// Rate limits of RATE_LIMIT and RATE_LIMIT_ROUTES, and of RATE_LIMIT_IP, shared by both servers
+	limitStore := ratelimitutil.NewMemoryStore()
+	limiter, err := ratelimitutil.NewLimiterFromEnv(limitStore)
+	if err != nil {
+		logger.Error(err)
+		repos.close(context.Background())
+		os.Exit(1)
+	}
+	ipLimiter, err := ratelimitutil.NewIPLimiterFromEnv(limitStore)
+	if err != nil {
+		logger.Error(err)
+		repos.close(context.Background())
+		os.Exit(1)
+	}
+
+	// Service
+	todoService := todoservice.New(repos.todo, repos.revisions, repos.events, repos.permissions)
+	keyService := todoservice.NewKeyService(repos.keys)
+
+	// Long lived streams end once serving stops
+	serving, stopServing := context.WithCancel(context.Background())
+
+	restServer := newRESTServer(todoService, keyService, verifier, resolver, ipLimiter, limiter, repos.idempotency, serving)
+	grpcServer := newGRPCServer(todoService, verifier, resolver, ipLimiter, limiter, repos.idempotency, serving)
 
 	go func() {
-		startRESTServer(client)
+		startRESTServer(restServer)
 	}()
 
 	go func() {
-		startGRPCServer(client)
+		startGRPCServer(grpcServer)
 	}()
 
+	// Purge the trash in the background
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	purgeStopped := startTrashPurge(purgeCtx, todoService, resolver)
+
 	// catch shutdown
-	done := make(chan bool, 1)
-	go func() {
-		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-		<-sig
-
-		// graceful shutdown
-		// ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		// defer cancel()
-		// server.GracefulStop(ctx, done)
-	}()
-	// wait for graceful shutdown
-	<-done
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	logger.Info("Shutting down servers")
+
+	// graceful shutdown
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), config.GetDuration("SHUTDOWN_TIMEOUT", 15*time.Second))
+	defer cancelShutdown()
+
+	stopServing()
+	shutdownServers(ctx, restServer, grpcServer)
+
+	stopPurge()
+	<-purgeStopped
+
+	// the shutdown timeout may be spent draining the servers, closing gets its own
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), config.GetDuration("DB_TIMEOUT", 5*time.Second))
+	defer cancelClose()
+
+	err = repos.close(closeCtx)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	logger.Info("Servers stopped")
+}
+
+// newVerifier - verifier of the tokens signed with the AUTH_* keys and of the API keys of keys, nil when AUTH_DISABLED=true
+func newVerifier(keys todorepository.APIKeyRepository) (*pkgauth.Verifier, error) {
+	if config.GetBool("AUTH_DISABLED", false) {
+		logger.Info("Authentication is disabled, every todo can be read and changed without a token")
+		return nil, nil
+	}
+
+	return pkgauth.NewFromEnv(keys)
+}
+
+// repositories - repositories of DB_DRIVER, close releases their connection
+type repositories struct {
+	todo        todorepository.Repository
+	revisions   todorepository.RevisionRepository
+	events      todorepository.EventRepository
+	idempotency todorepository.IdempotencyRepository
+	permissions todorepository.PermissionRepository
+	keys        todorepository.APIKeyRepository
+	close       func(ctx context.Context) error
+}
+
+// newRepositories - make repositories of DB_DRIVER (mongodb, sqlite, postgres or memory), the data of each tenant of resolver is kept apart
+// events come from mongo change streams when available, from an in-process event bus of EVENT_BUFFER_SIZE events otherwise
+func newRepositories(resolver *tenantutil.Resolver) (*repositories, error) {
+	eventBufferSize := config.GetInt("EVENT_BUFFER_SIZE", 1000)
+
+	switch os.Getenv("DB_DRIVER") {
+	case pkgsqldb.DialectSQLite, pkgsqldb.DialectPostgres:
+		dialect := os.Getenv("DB_DRIVER")
+
+		db, err := pkgsqldb.InitSQLDB(dialect)
+		if err != nil {
+			return nil, err
+		}
+
+		// the tables of each tenant are migrated when its connection is opened
+		var migrate func(ctx context.Context, db *sql.DB) error
+		if config.GetBool("DB_AUTO_MIGRATE", true) {
+			migrate = func(ctx context.Context, db *sql.DB) error {
+				return sqlrepository.Migrate(ctx, db, dialect)
+			}
+
+			err = migrate(context.Background(), db)
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+
+		// open the connection of every tenant now, so a broken tenant fails the start
+		tenants := pkgsqldb.NewTenants(db, dialect, migrate)
+		for _, tenant := range resolver.Tenants() {
+			_, err = tenants.DB(tenantutil.NewContext(context.Background(), tenant))
+			if err != nil {
+				tenants.Close()
+				return nil, err
+			}
+		}
+
+		return &repositories{
+			todo:        sqlrepository.New(tenants),
+			revisions:   sqlrepository.NewRevisionRepository(tenants),
+			events:      memoryrepository.NewEventRepository(eventBufferSize),
+			idempotency: sqlrepository.NewIdempotencyRepository(tenants),
+			permissions: sqlrepository.NewPermissionRepository(tenants),
+			keys:        sqlrepository.NewAPIKeyRepository(db, dialect),
+			close: func(ctx context.Context) error {
+				return tenants.Close()
+			},
+		}, nil
+	case "memory":
+		logger.Info("Using in-memory repository, data is lost on shutdown")
+
+		return &repositories{
+			todo:        memoryrepository.New(),
+			revisions:   memoryrepository.NewRevisionRepository(),
+			events:      memoryrepository.NewEventRepository(eventBufferSize),
+			idempotency: memoryrepository.NewIdempotencyRepository(),
+			permissions: memoryrepository.NewPermissionRepository(),
+			keys:        memoryrepository.NewAPIKeyRepository(),
+			close:       func(ctx context.Context) error { return nil },
+		}, nil
+	case "", "mongodb":
+		// Init MongoDB
+		_, cancel, client := pkgmongodb.InitMongoDB()
+		db := client.Database(os.Getenv("DB_NAME"))
+
+		if config.GetBool("DB_AUTO_MIGRATE", true) {
+			ctx, cancelMigrate := context.WithTimeout(context.Background(), config.GetDuration("DB_MIGRATE_TIMEOUT", time.Minute))
+			defer cancelMigrate()
+
+			// the default database keeps the API keys of every tenant
+			for _, id := range tenantIDs(resolver) {
+				err := pkgmongodb.MigrateUp(ctx, client.Database(tenantutil.Name(os.Getenv("DB_NAME"), id)), todorepository.Migrations)
+				if err != nil {
+					client.Disconnect(context.Background())
+					cancel()
+					return nil, err
+				}
+			}
+		}
+
+		events := todorepository.NewEventRepository(client)
+		if !pkgmongodb.SupportsChangeStreams(context.Background(), db) {
+			logger.Info("MongoDB change streams are not available, watching changes made by this server only")
+			events = memoryrepository.NewEventRepository(eventBufferSize)
+		}
+
+		return &repositories{
+			todo:        todorepository.New(client),
+			revisions:   todorepository.NewRevisionRepository(client),
+			events:      events,
+			idempotency: todorepository.NewIdempotencyRepository(client),
+			permissions: todorepository.NewPermissionRepository(client),
+			keys:        todorepository.NewAPIKeyRepository(client),
+			close: func(ctx context.Context) error {
+				defer cancel()
+
+				return client.Disconnect(ctx)
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q", os.Getenv("DB_DRIVER"))
+}
+
+// tenantIDs - ids of the default tenant and of the tenants of resolver
+func tenantIDs(resolver *tenantutil.Resolver) []string {
+	ids := []string{""}
+	if resolver.Enabled() {
+		for _, tenant := range resolver.Tenants() {
+			ids = append(ids, tenant.ID)
+		}
+	}
+
+	return ids
 }
 
-func startRESTServer(client *mongo.Client) {
-	router := Routes()
+func newRESTServer(todoService todoservice.Service, keyService todoservice.KeyService, verifier *pkgauth.Verifier, resolver *tenantutil.Resolver, ipLimiter *ratelimitutil.Limiter, limiter *ratelimitutil.Limiter, idempotency todorepository.IdempotencyRepository, serving context.Context) *http.Server {
+	router := Routes(verifier, resolver, ipLimiter)
+	router.Use(
+		ratelimitutil.Middleware(limiter, router), // Limit the requests of each client by route
+		idempotencyutil.Middleware(idempotency),   // Replay the response of requests sent again with the same Idempotency-Key
+		endEventStreams(serving),
+	)
 
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, responseutil.H{
@@ -103,50 +332,195 @@ func startRESTServer(client *mongo.Client) {
 		})
 	})
 
-	// Repository
-	todoRepo := todorepository.New(client)
-
-	// Service
-	todoService := todoservice.New(todoRepo)
-
 	// Delivery
 	todoHandler := todohttpdelivery.New(todoService)
 	todoHandler.RegisterRoutes(router)
+	keyHandler := todohttpdelivery.NewKeyHandler(keyService)
+	keyHandler.RegisterRoutes(router)
 
 	// Print
 	PrintAllRoutes(router)
 
-	addr := fmt.Sprintf("%s%s", ":", os.Getenv("REST_API_PORT"))
+	return &http.Server{
+		Addr:    fmt.Sprintf("%s%s", ":", os.Getenv("REST_API_PORT")),
+		Handler: router,
+	}
+}
+
+// endEventStreams - middleware canceling the server-sent events requests once serving is done, so they do not hold up the shutdown
+func endEventStreams(serving context.Context) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			go func() {
+				select {
+				case <-serving.Done():
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func startRESTServer(server *http.Server) {
 	logger.Info("REST API server started on port " + os.Getenv("REST_API_PORT"))
-	err := http.ListenAndServe(addr, router)
-	if err != nil {
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Error(err)
 	}
 }
 
-func startGRPCServer(client *mongo.Client) {
-	server := grpc.NewServer()
+func newGRPCServer(todoService todoservice.Service, verifier *pkgauth.Verifier, resolver *tenantutil.Resolver, ipLimiter *ratelimitutil.Limiter, limiter *ratelimitutil.Limiter, idempotency todorepository.IdempotencyRepository, serving context.Context) *grpc.Server {
+	// the calls of each IP are limited before the authentication, so the rejected calls are limited too
+	unary := []grpc.UnaryServerInterceptor{ratelimitutil.IPUnaryServerInterceptor(ipLimiter)}
+	stream := []grpc.StreamServerInterceptor{ratelimitutil.IPStreamServerInterceptor(ipLimiter)}
+	if verifier != nil {
+		unary = append(unary, pkgauth.UnaryServerInterceptor(verifier, publicMethods...))
+		stream = append(stream, pkgauth.StreamServerInterceptor(verifier, publicMethods...))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(append(unary,
+			tenantutil.UnaryServerInterceptor(resolver, publicMethods...),
+			actorutil.UnaryServerInterceptor,
+			ratelimitutil.UnaryServerInterceptor(limiter),
+			idempotencyutil.UnaryServerInterceptor(idempotency),
+		)...),
+		grpc.ChainStreamInterceptor(append(stream,
+			tenantutil.StreamServerInterceptor(resolver, publicMethods...),
+			actorutil.StreamServerInterceptor,
+			ratelimitutil.StreamServerInterceptor(limiter),
+			endStreams(serving),
+		)...),
+	)
 
-	// Repository
-	todoRepo := todorepository.New(client)
-	// Service
-	todoService := todoservice.New(todoRepo)
 	// Delivery
 	todoGrpcDelivery := todogrpcdelivery.New(todoService)
 
 	reflection.Register(server)
 	todoproto.RegisterTodoServer(server, todoGrpcDelivery)
 
+	return server
+}
+
+// endStreams - stream interceptor canceling the streams once serving is done, so they do not hold up the graceful stop
+func endStreams(serving context.Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancel(stream.Context())
+		defer cancel()
+
+		go func() {
+			select {
+			case <-serving.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream - server stream with another context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func startGRPCServer(server *grpc.Server) {
 	addr := fmt.Sprintf("%s%s", ":", os.Getenv("GRPC_PORT"))
 	tl, err := net.Listen("tcp", addr)
 	if err != nil {
 		logger.Error(err)
+		return
 	}
 
-	logger.Info("gRPC server started on port 8765")
+	logger.Info("gRPC server started on port " + os.Getenv("GRPC_PORT"))
 
 	err = server.Serve(tl)
 	if err != nil {
 		logger.Error(err)
 	}
 }
+
+// startTrashPurge - permanently delete todo in the trash for longer than TRASH_RETENTION (default 30 days)
+// of every tenant every TRASH_PURGE_INTERVAL (default 1h), the returned channel is closed once ctx is done and the purge stopped
+func startTrashPurge(ctx context.Context, todoService todoservice.Service, resolver *tenantutil.Resolver) <-chan struct{} {
+	retention := config.GetDuration("TRASH_RETENTION", 30*24*time.Hour)
+	interval := config.GetDuration("TRASH_PURGE_INTERVAL", time.Hour)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for _, tenant := range resolver.Tenants() {
+				total, err := todoService.PurgeExpired(tenantutil.NewContext(ctx, tenant), retention)
+				if err != nil && ctx.Err() == nil {
+					logger.Error(err)
+				}
+				if total > 0 {
+					logger.Printf("Purged %d todo from the trash of tenant %q\n", total, tenant.ID)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return stopped
+}
+
+// shutdownServers - drain in-flight REST and gRPC requests, force stop when ctx expires
+func shutdownServers(ctx context.Context, restServer *http.Server, grpcServer *grpc.Server) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		err := restServer.Shutdown(ctx)
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	todoproto "go-clean-grpc/todo/delivery/grpc/proto"
+	todohttpdelivery "go-clean-grpc/todo/delivery/http"
+	mockservice "go-clean-grpc/todo/mocks/service"
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	memoryrepository "go-clean-grpc/todo/repository/memory"
+	errorsutil "go-clean-grpc/utils/errors"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+	ratelimitutil "go-clean-grpc/utils/ratelimit"
+	tenantutil "go-clean-grpc/utils/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestRoutesExportFailure - testing an export failing after its first row through the middlewares of the routes
+func TestRoutesExportFailure(t *testing.T) {
+	pkgvalidator.New()
+	mockService := new(mockservice.Service)
+	mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, filter *models.TodoFilter, send func(*models.Todo) error) error {
+		for _, id := range []string{"1", "2"} {
+			err := send(&models.Todo{ID: id, Title: "a", Status: models.StatusPending})
+			if err != nil {
+				return err
+			}
+		}
+
+		return errorsutil.ErrDefault
+	})
+
+	resolver, err := tenantutil.NewResolver(nil, 0, nil, "")
+	require.NoError(t, err)
+	router := Routes(nil, resolver, ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{}, nil))
+	todohttpdelivery.New(mockService).RegisterRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/todo/export?format=csv")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the connection is closed before the end of the body, the file is not complete
+	body, err := io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Contains(t, string(body), "1,a")
+}
+
+// TestRoutesIPLimit - testing the requests without a valid token are limited by IP [401, 429]
+func TestRoutesIPLimit(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: []byte("secret")})
+	require.NoError(t, err)
+	resolver, err := tenantutil.NewResolver(nil, 0, nil, "")
+	require.NoError(t, err)
+	ipLimiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{Count: 2, Per: time.Minute}, nil)
+	router := Routes(verifier, resolver, ipLimiter)
+	todohttpdelivery.New(new(mockservice.Service)).RegisterRoutes(router)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		req.Header.Set("Authorization", "Bearer invalid")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/todo").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/todo/1").Code)
+
+	// the IP bucket is shared by every route
+	rr := serve("/todo")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get(ratelimitutil.RetryAfterHeader))
+}
+
+// TestGRPCServerIPLimit - testing the calls without a valid token are limited by IP [Unauthenticated, ResourceExhausted]
+func TestGRPCServerIPLimit(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: []byte("secret")})
+	require.NoError(t, err)
+	resolver, err := tenantutil.NewResolver(nil, 0, nil, "")
+	require.NoError(t, err)
+	ipLimiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{Count: 2, Per: time.Minute}, nil)
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{}, nil)
+	server := newGRPCServer(new(mockservice.Service), verifier, resolver, ipLimiter, limiter, memoryrepository.NewIdempotencyRepository(), context.Background())
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := todoproto.NewTodoClient(conn)
+
+	_, err = client.GetAll(context.Background(), &todoproto.TodoGetAllInput{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetAll(context.Background(), &todoproto.TodoGetAllInput{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetAll(context.Background(), &todoproto.TodoGetAllInput{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// TestRESTServerKeyIdempotency - testing a minted API key is not kept by the idempotency records
+func TestRESTServerKeyIdempotency(t *testing.T) {
+	pkgvalidator.New()
+	mockKeyService := new(mockservice.KeyService)
+	mockKeyService.On("CreateKey", mock.Anything, mock.Anything).
+		Return(&models.CreatedAPIKey{APIKey: &pkgauth.APIKey{ID: "k1", UserID: "robot"}, Key: "tk_secret"}, nil)
+
+	resolver, err := tenantutil.NewResolver(nil, 0, nil, "")
+	require.NoError(t, err)
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{}, nil)
+	idempotency := &recordingIdempotency{IdempotencyRepository: memoryrepository.NewIdempotencyRepository()}
+	server := newRESTServer(new(mockservice.Service), mockKeyService, nil, resolver, limiter, limiter, idempotency, context.Background())
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","user_id":"robot","scopes":["todo:read"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyutil.Header, "key-1")
+		rr := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), "tk_secret")
+	}
+
+	for _, response := range idempotency.completed {
+		assert.NotContains(t, string(response.Body), "tk_secret")
+	}
+	mockKeyService.AssertNumberOfCalls(t, "CreateKey", 2)
+}
+
+// recordingIdempotency - idempotency repository keeping the responses it completed
+type recordingIdempotency struct {
+	todorepository.IdempotencyRepository
+	completed []*idempotencyutil.Response
+}
+
+func (r *recordingIdempotency) Complete(ctx context.Context, key string, response *idempotencyutil.Response, expiresAt time.Time) error {
+	r.completed = append(r.completed, response)
+
+	return r.IdempotencyRepository.Complete(ctx, key, response, expiresAt)
+}
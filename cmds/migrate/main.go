@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-clean-grpc/pkg/config"
+	"go-clean-grpc/pkg/logger"
+	pkgmongodb "go-clean-grpc/pkg/mongodb"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	todorepository "go-clean-grpc/todo/repository"
+	sqlrepository "go-clean-grpc/todo/repository/sql"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up            apply the pending migrations
+  down [steps]  roll back the last steps migrations (default 1), mongodb only
+  status        list migrations and when they were applied, mongodb only
+
+DB_DRIVER, DB_URL, DB_NAME and TENANTS are read like the app does,
+the commands run on the default database then on the database of each tenant`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+	}
+	flag.Parse()
+
+	// Load environment variables
+	err := config.LoadConfig()
+	if err != nil {
+		logger.Error(err)
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	resolver, err := tenantutil.NewResolverFromEnv("")
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetDuration("DB_MIGRATE_TIMEOUT", time.Minute))
+	defer cancel()
+
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", "mongodb":
+		err = migrateMongoDB(ctx, resolver, flag.Args())
+	case pkgsqldb.DialectSQLite, pkgsqldb.DialectPostgres:
+		err = migrateSQL(ctx, driver, resolver, flag.Args())
+	default:
+		err = fmt.Errorf("unsupported DB_DRIVER %q", driver)
+	}
+
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+}
+
+// tenantIDs - ids of the default tenant and of the tenants of resolver
+func tenantIDs(resolver *tenantutil.Resolver) []string {
+	ids := []string{""}
+	if resolver.Enabled() {
+		for _, tenant := range resolver.Tenants() {
+			ids = append(ids, tenant.ID)
+		}
+	}
+
+	return ids
+}
+
+func migrateMongoDB(ctx context.Context, resolver *tenantutil.Resolver, args []string) error {
+	_, cancel, client := pkgmongodb.InitMongoDB()
+	defer cancel()
+	defer client.Disconnect(context.Background())
+
+	for _, id := range tenantIDs(resolver) {
+		db := client.Database(tenantutil.Name(os.Getenv("DB_NAME"), id))
+		if resolver.Enabled() {
+			fmt.Printf("database %s\n", db.Name())
+		}
+
+		err := migrateMongoDatabase(ctx, db, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateMongoDatabase(ctx context.Context, db *mongo.Database, args []string) error {
+	switch args[0] {
+	case "up":
+		return pkgmongodb.MigrateUp(ctx, db, todorepository.Migrations)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			value, err := strconv.Atoi(args[1])
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+			steps = value
+		}
+
+		return pkgmongodb.MigrateDown(ctx, db, todorepository.Migrations, steps)
+	case "status":
+		statuses, err := pkgmongodb.MigrationStatuses(ctx, db, todorepository.Migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%4d  %-25s  %s\n", status.Version, appliedAt, status.Description)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+func migrateSQL(ctx context.Context, dialect string, resolver *tenantutil.Resolver, args []string) error {
+	if args[0] != "up" {
+		return fmt.Errorf("command %q is not supported for %s, sql migrations are only applied", args[0], dialect)
+	}
+
+	db, err := pkgsqldb.InitSQLDB(dialect)
+	if err != nil {
+		return err
+	}
+
+	migrate := func(ctx context.Context, db *sql.DB) error {
+		return sqlrepository.Migrate(ctx, db, dialect)
+	}
+
+	// the connection of each tenant is migrated once opened
+	tenants := pkgsqldb.NewTenants(db, dialect, migrate)
+	defer tenants.Close()
+
+	err = migrate(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range resolver.Tenants() {
+		_, err = tenants.DB(tenantutil.NewContext(ctx, tenant))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-clean-grpc/pkg/logger"
+	errorsutil "go-clean-grpc/utils/errors"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// KeyPrefix - prefix of the API keys, bearer tokens starting with it are API keys instead of JWTs
+const KeyPrefix = "tk_"
+
+// touchInterval - the last use of an API key is written at most once per interval
+const touchInterval = time.Minute
+
+// APIKey - long-lived key acting as a user with the scopes of the key, only the hash of the key is stored
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"-"`      // hex SHA-256 of the key
+	Prefix     string     `json:"prefix"` // first characters of the key, to tell keys apart
+	UserID     string     `json:"user_id"`
+	Tenant     string     `json:"tenant,omitempty"` // the key can only reach this tenant when set
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at"` // never expires when nil
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// KeyStore - store of API keys
+type KeyStore interface {
+	// FindKeyByHash - find API key by the hash of the key, not found when there is none
+	FindKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	// TouchKey - set the last use of the API key by id
+	TouchKey(ctx context.Context, id string, usedAt time.Time) error
+}
+
+var (
+	ErrRevokedKey = errorsutil.New(errorsutil.KindUnauthenticated, "api key is revoked")
+	ErrExpiredKey = errorsutil.New(errorsutil.KindUnauthenticated, "api key is expired")
+)
+
+// GenerateKey - new random API key with its hash and prefix, the key is only known to the caller
+func GenerateKey() (key string, hash string, prefix string, err error) {
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return "", "", "", fmt.Errorf("auth: generate api key: %w", err)
+	}
+
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, HashKey(key), key[:len(KeyPrefix)+8], nil
+}
+
+// HashKey - hex SHA-256 of key, keys are random so they are not salted
+func HashKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(digest[:])
+}
+
+// VerifyKey - claims of the user of API key, errors are unauthenticated unless the key store fails
+func (v *Verifier) VerifyKey(ctx context.Context, key string) (*Claims, error) {
+	if v.options.KeyStore == nil || !strings.HasPrefix(key, KeyPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	result, err := v.options.KeyStore.FindKeyByHash(ctx, HashKey(key))
+	if err != nil {
+		if errorsutil.KindOf(err) == errorsutil.KindNotFound {
+			return nil, ErrInvalidToken
+		}
+
+		return nil, err
+	}
+
+	now := timeutil.GetTimeNow()
+	switch {
+	case result.RevokedAt != nil:
+		return nil, ErrRevokedKey
+	case result.ExpiresAt != nil && !now.Before(*result.ExpiresAt):
+		return nil, ErrExpiredKey
+	}
+
+	if result.LastUsedAt == nil || now.Sub(*result.LastUsedAt) >= touchInterval {
+		err = v.options.KeyStore.TouchKey(ctx, result.ID, now)
+		if err != nil {
+			logger.Error(fmt.Errorf("touch api key %s: %w", result.ID, err))
+		}
+	}
+
+	return &Claims{
+		Subject:   result.UserID,
+		KeyID:     result.ID,
+		Scopes:    append([]string{}, result.Scopes...),
+		ExpiresAt: timeValue(result.ExpiresAt),
+		Tenant:    result.Tenant,
+	}, nil
+}
+
+// HasScope - whether the authenticated user can use scope, only API keys are limited to their scopes
+func (c *Claims) HasScope(scope string) bool {
+	return c.KeyID == "" || contains(c.Scopes, scope)
+}
+
+func timeValue(value *time.Time) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+
+	return *value
+}
@@ -0,0 +1,236 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, hash, prefix, err := pkgauth.GenerateKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, pkgauth.KeyPrefix))
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Len(t, prefix, len(pkgauth.KeyPrefix)+8)
+	assert.Equal(t, pkgauth.HashKey(key), hash)
+	assert.NotContains(t, hash, key)
+
+	other, _, _, err := pkgauth.GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestVerifyKey(t *testing.T) {
+	store := newKeyStore()
+	verifier, err := pkgauth.New(pkgauth.Options{KeyStore: store})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	key := store.add(&pkgauth.APIKey{ID: "k1", UserID: "robot", Tenant: "acme", Scopes: []string{"todo:read"}, ExpiresAt: &expiresAt})
+
+	t.Run("success when valid key", func(t *testing.T) {
+		result, err := verifier.VerifyKey(context.Background(), key)
+
+		require.NoError(t, err)
+		assert.Equal(t, "robot", result.Subject)
+		assert.Equal(t, "k1", result.KeyID)
+		assert.Equal(t, "acme", result.Tenant)
+		assert.Equal(t, []string{"todo:read"}, result.Scopes)
+		assert.True(t, result.HasScope("todo:read"))
+		assert.False(t, result.HasScope("todo:write"))
+		assert.Empty(t, result.Roles)
+	})
+
+	t.Run("last use is written once per minute", func(t *testing.T) {
+		store.touched = 0
+		lastUsedAt := time.Now().Add(-2 * time.Minute)
+		store.keys[pkgauth.HashKey(key)].LastUsedAt = &lastUsedAt
+
+		for i := 0; i < 3; i++ {
+			_, err := verifier.VerifyKey(context.Background(), key)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, store.touched)
+		assert.WithinDuration(t, time.Now(), *store.keys[pkgauth.HashKey(key)].LastUsedAt, 5*time.Second)
+	})
+
+	t.Run("error unauthenticated when unknown key", func(t *testing.T) {
+		_, err := verifier.VerifyKey(context.Background(), pkgauth.KeyPrefix+"unknown")
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+	})
+
+	t.Run("error unauthenticated when revoked key", func(t *testing.T) {
+		revokedAt := time.Now()
+		revoked := store.add(&pkgauth.APIKey{ID: "k2", UserID: "robot", RevokedAt: &revokedAt})
+
+		_, err := verifier.VerifyKey(context.Background(), revoked)
+		assert.Equal(t, pkgauth.ErrRevokedKey, err)
+	})
+
+	t.Run("error unauthenticated when expired key", func(t *testing.T) {
+		expiredAt := time.Now().Add(-time.Second)
+		expired := store.add(&pkgauth.APIKey{ID: "k3", UserID: "robot", ExpiresAt: &expiredAt})
+
+		_, err := verifier.VerifyKey(context.Background(), expired)
+		assert.Equal(t, pkgauth.ErrExpiredKey, err)
+	})
+
+	t.Run("error unauthenticated when verifier has no key store", func(t *testing.T) {
+		verifier, err := pkgauth.New(pkgauth.Options{Secret: secret})
+		require.NoError(t, err)
+
+		_, err = verifier.VerifyKey(context.Background(), key)
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	// JWTs are not limited to scopes
+	assert.True(t, (&pkgauth.Claims{Subject: "alice"}).HasScope("todo:write"))
+	assert.False(t, (&pkgauth.Claims{Subject: "robot", KeyID: "k1"}).HasScope("todo:write"))
+}
+
+func TestMiddlewareAPIKey(t *testing.T) {
+	store := newKeyStore()
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret, KeyStore: store})
+	require.NoError(t, err)
+	key := store.add(&pkgauth.APIKey{ID: "k1", UserID: "robot"})
+
+	keyID := ""
+	handler := pkgauth.Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := pkgauth.FromContext(r.Context())
+		keyID = claims.KeyID
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(pkgauth.Header, "Bearer "+key)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "k1", keyID)
+
+	req = httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(pkgauth.Header, "Bearer "+pkgauth.KeyPrefix+"unknown")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rr.Header().Get("WWW-Authenticate"))
+
+	interceptor := pkgauth.UnaryServerInterceptor(verifier)
+	unary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return pkgauth.UserID(ctx), nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+key))
+	result, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, unary)
+	assert.NoError(t, err)
+	assert.Equal(t, "robot", result)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+pkgauth.KeyPrefix+"unknown"))
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, unary)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestMiddlewareKeyStoreError(t *testing.T) {
+	store := newKeyStore()
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret, KeyStore: store})
+	require.NoError(t, err)
+	key := store.add(&pkgauth.APIKey{ID: "k1", UserID: "robot"})
+
+	handler := pkgauth.Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	interceptor := pkgauth.UnaryServerInterceptor(verifier)
+	unary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   codes.Code
+	}{
+		{name: "unavailable", err: errorsutil.Wrap(errorsutil.KindUnavailable, "database unavailable", errors.New("connection refused")), status: http.StatusServiceUnavailable, code: codes.Unavailable},
+		{name: "timeout", err: errorsutil.Wrap(errorsutil.KindDeadlineExceeded, "database timeout", errors.New("i/o timeout")), status: http.StatusGatewayTimeout, code: codes.DeadlineExceeded},
+		{name: "driver error", err: errors.New("server selection error: 10.0.0.1:27017"), status: http.StatusInternalServerError, code: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.err = tt.err
+
+			req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+			req.Header.Set(pkgauth.Header, "Bearer "+key)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Empty(t, rr.Header().Get("WWW-Authenticate"))
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+key))
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, unary)
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, "There is something error", status.Convert(err).Message())
+		})
+	}
+}
+
+// keyStore - API keys by hash, err fails the lookups
+type keyStore struct {
+	keys    map[string]*pkgauth.APIKey
+	touched int
+	err     error
+}
+
+func newKeyStore() *keyStore {
+	return &keyStore{keys: map[string]*pkgauth.APIKey{}}
+}
+
+// add - store value under a new key, returns the key
+func (s *keyStore) add(value *pkgauth.APIKey) string {
+	key, hash, _, err := pkgauth.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	value.Hash = hash
+	s.keys[hash] = value
+
+	return key
+}
+
+func (s *keyStore) FindKeyByHash(ctx context.Context, hash string) (*pkgauth.APIKey, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	value, ok := s.keys[hash]
+	if !ok {
+		return nil, errorsutil.ErrNotFound
+	}
+	result := *value
+
+	return &result, nil
+}
+
+func (s *keyStore) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	for _, value := range s.keys {
+		if value.ID == id {
+			s.touched++
+			value.LastUsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
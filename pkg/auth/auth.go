@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"go-clean-grpc/pkg/config"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+// Signing algorithms of the verified tokens
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// Claims - verified claims of a token, the subject is the user id
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore *time.Time
+	IssuedAt  *time.Time
+	Roles     []string // roles claim, e.g. admin
+	Groups    []string // groups claim, todo can be shared with a group
+	Scopes    []string // scopes of the API key, e.g. todo:read
+	KeyID     string   // id of the API key, empty for JWTs
+	Tenant    string   // tenant claim, the caller can only reach this tenant when set
+}
+
+// Options - keys and expected claims of the verified tokens
+type Options struct {
+	Secret   []byte                    // HS256 secret, HS256 tokens are rejected when empty
+	Keys     map[string]*rsa.PublicKey // RS256 keys by key id, RS256 tokens are rejected when empty
+	Issuer   string                    // expected iss, not checked when empty
+	Audience string                    // expected aud, not checked when empty
+	Leeway   time.Duration             // allowed clock skew of exp and nbf
+	KeyStore KeyStore                  // store of the API keys, API keys are rejected when nil
+}
+
+// Verifier - verify signed JWTs and API keys
+type Verifier struct {
+	options Options
+}
+
+// header - JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// payload - registered claims of a token, numeric dates may be fractional
+type payload struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	IssuedAt  *float64        `json:"iat"`
+	Roles     json.RawMessage `json:"roles"`
+	Groups    json.RawMessage `json:"groups"`
+	Tenant    string          `json:"tenant"`
+}
+
+var (
+	ErrMissingToken = errorsutil.New(errorsutil.KindUnauthenticated, "missing bearer token")
+	ErrInvalidToken = errorsutil.New(errorsutil.KindUnauthenticated, "invalid token")
+	ErrExpiredToken = errorsutil.New(errorsutil.KindUnauthenticated, "token is expired")
+)
+
+// New - make verifier of tokens signed with the keys of options and of the API keys of its store
+func New(options Options) (*Verifier, error) {
+	if len(options.Secret) == 0 && len(options.Keys) == 0 && options.KeyStore == nil {
+		return nil, errors.New("auth: a HS256 secret, RS256 keys or an API key store are required")
+	}
+
+	return &Verifier{options: options}, nil
+}
+
+// NewFromEnv - make verifier of AUTH_JWT_SECRET (HS256) and the keys of the AUTH_JWKS_FILE (RS256)
+// AUTH_ISSUER and AUTH_AUDIENCE are checked when set, AUTH_LEEWAY (default 1m) is the allowed clock skew
+// API keys are verified against keys, JWTs are still required to be configured
+func NewFromEnv(keys KeyStore) (*Verifier, error) {
+	options := Options{
+		KeyStore: keys,
+		Secret:   []byte(os.Getenv("AUTH_JWT_SECRET")),
+		Issuer:   os.Getenv("AUTH_ISSUER"),
+		Audience: os.Getenv("AUTH_AUDIENCE"),
+		Leeway:   config.GetDuration("AUTH_LEEWAY", time.Minute),
+	}
+
+	if path := os.Getenv("AUTH_JWKS_FILE"); path != "" {
+		keys, err := LoadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		options.Keys = keys
+	}
+
+	if len(options.Secret) == 0 && len(options.Keys) == 0 {
+		return nil, errors.New("auth: AUTH_JWT_SECRET or AUTH_JWKS_FILE is required, set AUTH_DISABLED=true to run without authentication")
+	}
+
+	return New(options)
+}
+
+// Verify - verify the signature and claims of token, errors are unauthenticated
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	head := &header{}
+	err := decodeSegment(parts[0], head)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = v.verifySignature(head, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &payload{}
+	err = decodeSegment(parts[1], claims)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return v.verifyClaims(claims, time.Now())
+}
+
+// verifySignature - check signature of the signed header and payload, the algorithm must match a configured key
+func (v *Verifier) verifySignature(head *header, signed string, signature []byte) error {
+	switch head.Alg {
+	case AlgHS256:
+		if len(v.options.Secret) == 0 {
+			return ErrInvalidToken
+		}
+
+		mac := hmac.New(sha256.New, v.options.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidToken
+		}
+
+		return nil
+	case AlgRS256:
+		key := v.key(head.Kid)
+		if key == nil {
+			return ErrInvalidToken
+		}
+
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidToken
+		}
+
+		return nil
+	}
+
+	// none and the algorithms without a configured key
+	return ErrInvalidToken
+}
+
+// key - RS256 key of kid, the only key when the token has no kid
+func (v *Verifier) key(kid string) *rsa.PublicKey {
+	if kid == "" && len(v.options.Keys) == 1 {
+		for _, key := range v.options.Keys {
+			return key
+		}
+	}
+
+	return v.options.Keys[kid]
+}
+
+// verifyClaims - check the registered claims at now, sub and exp are required
+func (v *Verifier) verifyClaims(claims *payload, now time.Time) (*Claims, error) {
+	if claims.Subject == "" || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	audience, err := decodeStrings(claims.Audience)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	roles, err := decodeStrings(claims.Roles)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	groups, err := decodeStrings(claims.Groups)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	result := &Claims{
+		Subject:   claims.Subject,
+		Issuer:    claims.Issuer,
+		Audience:  audience,
+		ExpiresAt: numericDate(*claims.ExpiresAt),
+		Roles:     roles,
+		Groups:    groups,
+		Tenant:    claims.Tenant,
+	}
+	if claims.NotBefore != nil {
+		notBefore := numericDate(*claims.NotBefore)
+		result.NotBefore = &notBefore
+	}
+	if claims.IssuedAt != nil {
+		issuedAt := numericDate(*claims.IssuedAt)
+		result.IssuedAt = &issuedAt
+	}
+
+	if !now.Before(result.ExpiresAt.Add(v.options.Leeway)) {
+		return nil, ErrExpiredToken
+	}
+
+	if result.NotBefore != nil && now.Add(v.options.Leeway).Before(*result.NotBefore) {
+		return nil, errorsutil.New(errorsutil.KindUnauthenticated, "token is not valid yet")
+	}
+
+	if v.options.Issuer != "" && result.Issuer != v.options.Issuer {
+		return nil, errorsutil.New(errorsutil.KindUnauthenticated, "token issuer is not accepted")
+	}
+
+	if v.options.Audience != "" && !contains(result.Audience, v.options.Audience) {
+		return nil, errorsutil.New(errorsutil.KindUnauthenticated, "token audience is not accepted")
+	}
+
+	return result, nil
+}
+
+// jwks - JSON Web Key Set
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// LoadJWKS - read the RSA signing keys of a JWKS file by key id, other keys are skipped
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read JWKS file: %w", err)
+	}
+
+	set := &jwks{}
+	err = json.Unmarshal(data, set)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decode JWKS file: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, item := range set.Keys {
+		if item.Kty != "RSA" || (item.Use != "" && item.Use != "sig") || (item.Alg != "" && item.Alg != AlgRS256) {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(item.N)
+		if err != nil {
+			return nil, fmt.Errorf("auth: decode modulus of key %q: %w", item.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(item.E)
+		if err != nil {
+			return nil, fmt.Errorf("auth: decode exponent of key %q: %w", item.Kid, err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("auth: invalid exponent of key %q", item.Kid)
+		}
+
+		keys[item.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("auth: JWKS file has no RS256 signing key")
+	}
+
+	return keys, nil
+}
+
+type contextKey struct{}
+
+// NewContext - context carrying the claims of the authenticated user
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext - claims of the authenticated user, false when the request was not authenticated
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+
+	return claims, ok
+}
+
+// HasRole - whether the authenticated user has role
+func (c *Claims) HasRole(role string) bool {
+	return contains(c.Roles, role)
+}
+
+// UserID - subject of the authenticated user, empty when the request was not authenticated
+func UserID(ctx context.Context) string {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return claims.Subject
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+// decodeStrings - claim of a string or a list of strings, like aud
+func decodeStrings(data json.RawMessage) ([]string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return []string{}, nil
+	}
+
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		return []string{single}, nil
+	}
+
+	results := []string{}
+	err := json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func numericDate(value float64) time.Time {
+	seconds := int64(value)
+
+	return time.Unix(seconds, int64((value-float64(seconds))*1e9)).UTC()
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
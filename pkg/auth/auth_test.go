@@ -0,0 +1,240 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+var secret = []byte("secret")
+
+func TestVerify(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret, Issuer: "todo", Audience: "api", Leeway: time.Minute})
+	require.NoError(t, err)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"sub": "alice", "iss": "todo", "aud": "api", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+
+	t.Run("success when valid HS256 token", func(t *testing.T) {
+		claims := valid()
+		claims["aud"] = []string{"web", "api"}
+		claims["iat"] = 1600000000
+
+		result, err := verifier.Verify(signHS256(t, secret, claims))
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", result.Subject)
+		assert.Equal(t, "todo", result.Issuer)
+		assert.Equal(t, []string{"web", "api"}, result.Audience)
+		require.NotNil(t, result.IssuedAt)
+		assert.Equal(t, time.Unix(1600000000, 0).UTC(), *result.IssuedAt)
+	})
+
+	t.Run("success when roles and groups claims", func(t *testing.T) {
+		claims := valid()
+		claims["roles"] = []string{"admin"}
+		claims["groups"] = "team"
+
+		result, err := verifier.Verify(signHS256(t, secret, claims))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, result.Roles)
+		assert.Equal(t, []string{"team"}, result.Groups)
+		assert.True(t, result.HasRole("admin"))
+		assert.False(t, result.HasRole("editor"))
+	})
+
+	t.Run("success when tenant claim", func(t *testing.T) {
+		claims := valid()
+		claims["tenant"] = "acme"
+
+		result, err := verifier.Verify(signHS256(t, secret, claims))
+
+		require.NoError(t, err)
+		assert.Equal(t, "acme", result.Tenant)
+	})
+
+	t.Run("error when invalid signature", func(t *testing.T) {
+		_, err := verifier.Verify(signHS256(t, []byte("other"), valid()))
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+
+		token := signHS256(t, secret, valid())
+		_, err = verifier.Verify(token[:len(token)-2])
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+
+		_, err = verifier.Verify("not a token")
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+	})
+
+	t.Run("error when unsigned or unknown algorithm", func(t *testing.T) {
+		token := sign(t, map[string]interface{}{"alg": "none"}, valid(), func(string) []byte { return nil })
+		_, err := verifier.Verify(token)
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+
+		// RS256 tokens are rejected without RS256 keys
+		token = sign(t, map[string]interface{}{"alg": pkgauth.AlgRS256}, valid(), hs256(secret))
+		_, err = verifier.Verify(token)
+		assert.Equal(t, pkgauth.ErrInvalidToken, err)
+	})
+
+	t.Run("error when expired", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+		_, err := verifier.Verify(signHS256(t, secret, claims))
+		assert.Equal(t, pkgauth.ErrExpiredToken, err)
+
+		// within the leeway
+		claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+		_, err = verifier.Verify(signHS256(t, secret, claims))
+		assert.NoError(t, err)
+
+		claims = valid()
+		claims["nbf"] = time.Now().Add(time.Hour).Unix()
+		_, err = verifier.Verify(signHS256(t, secret, claims))
+		assert.Equal(t, errorsutil.KindUnauthenticated, errorsutil.KindOf(err))
+	})
+
+	t.Run("error when missing or unexpected claims", func(t *testing.T) {
+		for name, change := range map[string]func(claims map[string]interface{}){
+			"sub": func(claims map[string]interface{}) { delete(claims, "sub") },
+			"exp": func(claims map[string]interface{}) { delete(claims, "exp") },
+			"iss": func(claims map[string]interface{}) { claims["iss"] = "other" },
+			"aud": func(claims map[string]interface{}) { claims["aud"] = []string{"web"} },
+		} {
+			claims := valid()
+			change(claims)
+
+			_, err := verifier.Verify(signHS256(t, secret, claims))
+			assert.Equal(t, errorsutil.KindUnauthenticated, errorsutil.KindOf(err), name)
+		}
+	})
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := writeJWKS(t, map[string]*rsa.PublicKey{"key-1": &key.PublicKey, "key-2": &other.PublicKey})
+	t.Setenv("AUTH_JWT_SECRET", "")
+	t.Setenv("AUTH_JWKS_FILE", path)
+
+	verifier, err := pkgauth.NewFromEnv(nil)
+	require.NoError(t, err)
+
+	claims := map[string]interface{}{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+
+	result, err := verifier.Verify(sign(t, map[string]interface{}{"alg": pkgauth.AlgRS256, "kid": "key-1"}, claims, rs256(t, key)))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", result.Subject)
+
+	// signed with another key than the one of kid
+	_, err = verifier.Verify(sign(t, map[string]interface{}{"alg": pkgauth.AlgRS256, "kid": "key-2"}, claims, rs256(t, key)))
+	assert.Equal(t, pkgauth.ErrInvalidToken, err)
+
+	// the key can only be chosen without kid when there is a single one
+	_, err = verifier.Verify(sign(t, map[string]interface{}{"alg": pkgauth.AlgRS256}, claims, rs256(t, key)))
+	assert.Equal(t, pkgauth.ErrInvalidToken, err)
+
+	// HS256 tokens are rejected without secret, even when signed with the public key
+	_, err = verifier.Verify(sign(t, map[string]interface{}{"alg": pkgauth.AlgHS256}, claims, hs256(key.PublicKey.N.Bytes())))
+	assert.Equal(t, pkgauth.ErrInvalidToken, err)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("AUTH_JWT_SECRET", "")
+	t.Setenv("AUTH_JWKS_FILE", "")
+
+	_, err := pkgauth.NewFromEnv(nil)
+	assert.ErrorContains(t, err, "AUTH_DISABLED=true")
+
+	t.Setenv("AUTH_JWKS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	_, err = pkgauth.NewFromEnv(nil)
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kty":"EC","kid":"ec"}]}`), 0o600))
+	_, err = pkgauth.LoadJWKS(path)
+	assert.ErrorContains(t, err, "no RS256 signing key")
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := pkgauth.FromContext(context.Background())
+	assert.False(t, ok)
+	assert.Equal(t, "", pkgauth.UserID(context.Background()))
+
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+	claims, ok := pkgauth.FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, "alice", pkgauth.UserID(ctx))
+}
+
+func signHS256(t *testing.T, key []byte, claims map[string]interface{}) string {
+	return sign(t, map[string]interface{}{"alg": pkgauth.AlgHS256, "typ": "JWT"}, claims, hs256(key))
+}
+
+func sign(t *testing.T, header map[string]interface{}, claims map[string]interface{}, signature func(signed string) []byte) string {
+	signed := segment(t, header) + "." + segment(t, claims)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature(signed))
+}
+
+func hs256(key []byte) func(signed string) []byte {
+	return func(signed string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func(signed string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+
+		return signature
+	}
+}
+
+func segment(t *testing.T, value interface{}) string {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJWKS(t *testing.T, keys map[string]*rsa.PublicKey) string {
+	items := []string{}
+	for kid, key := range keys {
+		items = append(items, `{"kty":"RSA","use":"sig","alg":"RS256","kid":"`+kid+`","n":"`+
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes())+`","e":"`+
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())+`"}`)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[`+strings.Join(items, ",")+`]}`), 0o600))
+
+	return path
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go-clean-grpc/pkg/logger"
+	errorsutil "go-clean-grpc/utils/errors"
+	responseutil "go-clean-grpc/utils/response"
+)
+
+// Header - HTTP header and gRPC metadata key of the bearer token, either a JWT or an API key
+const Header = "Authorization"
+
+// Middleware - authenticate HTTP requests with the bearer token of the Authorization header
+// requests to the public paths are served without a token
+func Middleware(verifier *Verifier, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contains(public, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := verifier.authenticate(r.Context(), r.Header.Get(Header))
+			if err != nil {
+				// key store failures are not a challenge, they map by kind
+				if errorsutil.KindOf(err) == errorsutil.KindUnauthenticated {
+					challenge := "Bearer"
+					if err != ErrMissingToken {
+						challenge = `Bearer error="invalid_token"`
+					}
+					w.Header().Set("WWW-Authenticate", challenge)
+				}
+				responseutil.ResponseError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+// UnaryServerInterceptor - authenticate gRPC calls with the bearer token of the authorization metadata
+// calls to the public full methods are served without a token
+func UnaryServerInterceptor(verifier *Verifier, public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if contains(public, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := verifier.authenticateCall(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor - authenticate gRPC streams with the bearer token of the authorization metadata
+// streams of the public full methods are served without a token
+func StreamServerInterceptor(verifier *Verifier, public ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if contains(public, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx, err := verifier.authenticateCall(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticate - verify the bearer token of the authorization value, tokens with the KeyPrefix are API keys
+func (v *Verifier) authenticate(ctx context.Context, authorization string) (*Claims, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrMissingToken
+	}
+
+	token = strings.TrimSpace(token)
+	if strings.HasPrefix(token, KeyPrefix) {
+		return v.VerifyKey(ctx, token)
+	}
+
+	return v.Verify(token)
+}
+
+// authenticateCall - context of a gRPC call carrying the claims of its token, errors are status errors
+func (v *Verifier) authenticateCall(ctx context.Context) (context.Context, error) {
+	authorization := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(Header); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+
+	claims, err := v.authenticate(ctx, authorization)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return NewContext(ctx, claims), nil
+}
+
+// statusError - translate authentication errors to gRPC status errors, only bad credentials are unauthenticated
+// key store failures are logged and their message is hidden, so clients can retry them
+func statusError(err error) error {
+	kind := errorsutil.KindOf(err)
+	if kind == errorsutil.KindUnauthenticated {
+		return status.Error(codes.Unauthenticated, errorsutil.Message(err))
+	}
+
+	logger.Error(err)
+
+	code := codes.Internal
+	switch kind {
+	case errorsutil.KindUnavailable:
+		code = codes.Unavailable
+	case errorsutil.KindDeadlineExceeded:
+		code = codes.DeadlineExceeded
+	}
+
+	return status.Error(code, "There is something error")
+}
+
+// serverStream - server stream with the context of the authenticated user
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
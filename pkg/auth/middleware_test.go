@@ -0,0 +1,126 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+)
+
+func TestMiddleware(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret})
+	require.NoError(t, err)
+
+	userID := ""
+	handler := pkgauth.Middleware(verifier, "/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID = pkgauth.UserID(r.Context())
+	}))
+
+	send := func(path string, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set(pkgauth.Header, authorization)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("success when valid token", func(t *testing.T) {
+		rr := send("/todo", "Bearer "+signHS256(t, secret, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "alice", userID)
+	})
+
+	t.Run("error unauthorized when missing token", func(t *testing.T) {
+		rr := send("/todo", "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+
+		rr = send("/todo", "Basic YWxpY2U6c2VjcmV0")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("error unauthorized when invalid token", func(t *testing.T) {
+		rr := send("/todo", "Bearer "+signHS256(t, secret, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()}))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, rr.Header().Get("WWW-Authenticate"))
+		assert.Contains(t, rr.Body.String(), "token is expired")
+	})
+
+	t.Run("success when public path", func(t *testing.T) {
+		userID = "unset"
+		rr := send("/", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "", userID)
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret})
+	require.NoError(t, err)
+
+	interceptor := pkgauth.UnaryServerInterceptor(verifier, "/public")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return pkgauth.UserID(ctx), nil
+	}
+	token := signHS256(t, secret, map[string]interface{}{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	result, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", result)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	result, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/public"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "", result)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	verifier, err := pkgauth.New(pkgauth.Options{Secret: secret})
+	require.NoError(t, err)
+
+	interceptor := pkgauth.StreamServerInterceptor(verifier)
+	userID := ""
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		userID = pkgauth.UserID(stream.Context())
+		return nil
+	}
+	token := signHS256(t, secret, map[string]interface{}{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	err = interceptor(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/todo.Todo/Watch"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", userID)
+
+	err = interceptor(nil, &serverStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/todo.Todo/Watch"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// serverStream - server stream of a context, without messages
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
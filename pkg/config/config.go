@@ -1,6 +1,10 @@
 package config
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/joho/godotenv"
 )
 
@@ -13,3 +17,33 @@ func LoadConfig() error {
 
 	return nil
 }
+
+// GetDuration - get duration from environment variable (e.g. "15s"), fallback when empty or invalid
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// GetBool - get bool from environment variable (e.g. "true", "0"), fallback when empty or invalid
+func GetBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// GetInt - get positive int from environment variable, fallback when empty or invalid
+func GetInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
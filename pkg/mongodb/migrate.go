@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-clean-grpc/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationsCollection - collection recording the applied migrations
+const MigrationsCollection = "schema_migrations"
+
+// Migration - versioned change of a mongo database, Down is nil when the change can not be rolled back
+// migrations must be idempotent, a migration interrupted before it was recorded runs again
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus - migration and when it was applied, AppliedAt is nil when pending
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// MigrateUp - apply the pending migrations in version order
+func MigrateUp(ctx context.Context, db *mongo.Database, migrations []Migration) error {
+	migrations, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	collection := db.Collection(MigrationsCollection)
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := migration.Up(ctx, db)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = collection.InsertOne(ctx, migrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		logger.Printf("Applied migration %d (%s)\n", migration.Version, migration.Description)
+	}
+
+	return nil
+}
+
+// MigrateDown - roll back the last steps applied migrations, latest first
+func MigrateDown(ctx context.Context, db *mongo.Database, migrations []Migration, steps int) error {
+	migrations, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	collection := db.Collection(MigrationsCollection)
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == nil {
+			return fmt.Errorf("migration %d (%s) can not be rolled back", migration.Version, migration.Description)
+		}
+
+		err := migration.Down(ctx, db)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = collection.DeleteOne(ctx, bson.M{"_id": migration.Version})
+		if err != nil {
+			return err
+		}
+		logger.Printf("Rolled back migration %d (%s)\n", migration.Version, migration.Description)
+
+		steps--
+	}
+
+	return nil
+}
+
+// MigrationStatuses - status of every migration in version order
+func MigrationStatuses(ctx context.Context, db *mongo.Database, migrations []Migration) ([]MigrationStatus, error) {
+	migrations, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MigrationStatus{}
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		results = append(results, status)
+	}
+
+	return results, nil
+}
+
+func appliedMigrations(ctx context.Context, db *mongo.Database) (map[int]migrationRecord, error) {
+	cur, err := db.Collection(MigrationsCollection).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	records := []migrationRecord{}
+	err = cur.All(ctx, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[int]migrationRecord{}
+	for _, record := range records {
+		results[record.Version] = record
+	}
+
+	return results, nil
+}
+
+// sortMigrations - copy of migrations in version order, versions must be positive and unique
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	results := append([]Migration{}, migrations...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Version < results[j].Version
+	})
+
+	for i, migration := range results {
+		if migration.Version <= 0 || migration.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) needs a positive version and Up", migration.Version, migration.Description)
+		}
+		if i > 0 && results[i-1].Version == migration.Version {
+			return nil, errors.New("duplicate migration version " + fmt.Sprint(migration.Version))
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestSortMigrations(t *testing.T) {
+	up := func(ctx context.Context, db *mongo.Database) error { return nil }
+
+	t.Run("success when unordered", func(t *testing.T) {
+		migrations := []Migration{{Version: 3, Up: up}, {Version: 1, Up: up}, {Version: 2, Up: up}}
+
+		results, err := sortMigrations(migrations)
+
+		require.NoError(t, err)
+		versions := []int{}
+		for _, migration := range results {
+			versions = append(versions, migration.Version)
+		}
+		assert.Equal(t, []int{1, 2, 3}, versions)
+
+		// the migrations are not sorted in place
+		assert.Equal(t, 3, migrations[0].Version)
+	})
+
+	t.Run("error when duplicate version", func(t *testing.T) {
+		_, err := sortMigrations([]Migration{{Version: 2, Up: up}, {Version: 1, Up: up}, {Version: 2, Up: up}})
+
+		assert.EqualError(t, err, "duplicate migration version 2")
+	})
+
+	t.Run("error when version is not positive", func(t *testing.T) {
+		for _, version := range []int{0, -1} {
+			_, err := sortMigrations([]Migration{{Version: 1, Up: up}, {Version: version, Up: up}})
+
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("error when up is missing", func(t *testing.T) {
+		_, err := sortMigrations([]Migration{{Version: 1, Description: "no up"}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("success when empty", func(t *testing.T) {
+		results, err := sortMigrations(nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
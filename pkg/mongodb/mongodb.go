@@ -36,3 +36,14 @@ func InitMongoDB() (context.Context, func(), *mongo.Client) {
 
 	return ctx, cancel, client
 }
+
+// SupportsChangeStreams - check whether change streams can be opened on the database, they need a replica set or sharded cluster
+func SupportsChangeStreams(ctx context.Context, db *mongo.Database) bool {
+	stream, err := db.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		return false
+	}
+	stream.Close(ctx)
+
+	return true
+}
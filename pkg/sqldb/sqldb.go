@@ -0,0 +1,185 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-grpc/pkg/logger"
+
+	// database/sql drivers
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQL dialects
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// InitSQLDB - initialize sql database of dialect, DB_URL is the file path for sqlite and the connection string for postgres
+func InitSQLDB(dialect string) (*sql.DB, error) {
+	return open(dialect, os.Getenv("DB_URL"))
+}
+
+// open - open and ping the sql database of dialect at dsn
+func open(dialect string, dsn string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+	switch dialect {
+	case DialectSQLite:
+		db, err = sql.Open("sqlite3", sqliteDSN(dsn))
+		if err != nil {
+			return nil, err
+		}
+
+		// sqlite allows a single writer, a single connection avoids "database is locked" errors
+		db.SetMaxOpenConns(1)
+	case DialectPostgres:
+		db, err = sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Checking the connection
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Printf("SQL Database (%s) connected\n", dialect)
+
+	return db, nil
+}
+
+// sqliteDSN - enable foreign keys and wait on locks instead of failing
+func sqliteDSN(dsn string) string {
+	if dsn == "" {
+		dsn = "todo.db"
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + "_foreign_keys=on&_busy_timeout=5000"
+}
+
+// Rebind - replace ? placeholders with the placeholders of dialect
+func Rebind(dialect string, query string) string {
+	if dialect != DialectPostgres {
+		return query
+	}
+
+	var result strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			result.WriteRune(r)
+			continue
+		}
+
+		n++
+		result.WriteString("$" + strconv.Itoa(n))
+	}
+
+	return result.String()
+}
+
+// Migrate - apply the *.sql files of migrations not applied yet, in name order
+// applied versions are recorded in schema_migrations, each file runs in its own transaction
+func Migrate(ctx context.Context, db *sql.DB, dialect string, migrations fs.FS) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+		if applied[version] {
+			continue
+		}
+
+		content, err := fs.ReadFile(migrations, name)
+		if err != nil {
+			return err
+		}
+
+		err = migrate(ctx, db, dialect, version, string(content))
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		logger.Printf("Applied migration %s\n", version)
+	}
+
+	return nil
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := map[string]bool{}
+	for rows.Next() {
+		var version string
+		err := rows.Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+
+		results[version] = true
+	}
+
+	return results, rows.Err()
+}
+
+func migrate(ctx context.Context, db *sql.DB, dialect string, version string, content string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, content)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, Rebind(dialect, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), version, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+// Tenants - connection of each tenant, the default tenant uses the connection of DB_URL
+// the connection of another tenant is opened on first use, to a file next to DB_URL for sqlite and to a schema for postgres
+type Tenants struct {
+	db      *sql.DB
+	dialect string
+	dsn     string
+	prepare func(ctx context.Context, db *sql.DB) error
+	mu      sync.RWMutex
+	dbs     map[string]*sql.DB
+}
+
+// NewTenants - connections of the tenants next to db of DB_URL, prepare runs once on each newly opened connection, e.g. to migrate it
+func NewTenants(db *sql.DB, dialect string, prepare func(ctx context.Context, db *sql.DB) error) *Tenants {
+	return &Tenants{
+		db:      db,
+		dialect: dialect,
+		dsn:     os.Getenv("DB_URL"),
+		prepare: prepare,
+		dbs:     map[string]*sql.DB{},
+	}
+}
+
+// Dialect - sql dialect of the connections
+func (t *Tenants) Dialect() string {
+	return t.dialect
+}
+
+// DB - connection of the tenant of ctx
+func (t *Tenants) DB(ctx context.Context) (*sql.DB, error) {
+	id := tenantutil.ID(ctx)
+	if id == "" {
+		return t.db, nil
+	}
+
+	t.mu.RLock()
+	db, ok := t.dbs[id]
+	t.mu.RUnlock()
+	if ok {
+		return db, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// another call may have opened it meanwhile
+	if db, ok := t.dbs[id]; ok {
+		return db, nil
+	}
+
+	db, err := t.open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.prepare != nil {
+		err = t.prepare(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	t.dbs[id] = db
+
+	return db, nil
+}
+
+// Close - close the connections of every tenant
+func (t *Tenants) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.db.Close()
+	for id, db := range t.dbs {
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+		delete(t.dbs, id)
+	}
+
+	return err
+}
+
+// open - open the connection of tenant id
+func (t *Tenants) open(ctx context.Context, id string) (*sql.DB, error) {
+	if t.dialect != DialectPostgres {
+		return open(t.dialect, sqliteTenantDSN(t.dsn, id))
+	}
+
+	schema := tenantutil.Name("tenant", id)
+	_, err := t.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(t.dialect, postgresTenantDSN(t.dsn, schema))
+}
+
+// sqliteTenantDSN - dsn of the file of tenant id next to the file of dsn, e.g. todo_acme.db next to todo.db
+func sqliteTenantDSN(dsn string, id string) string {
+	if dsn == "" {
+		dsn = "todo.db"
+	}
+
+	path, query, hasQuery := strings.Cut(dsn, "?")
+	ext := filepath.Ext(path)
+	path = tenantutil.Name(strings.TrimSuffix(path, ext), id) + ext
+	if hasQuery {
+		return path + "?" + query
+	}
+
+	return path
+}
+
+// postgresTenantDSN - dsn of dsn searching the tables in schema, dsn is either an URL or key=value pairs
+func postgresTenantDSN(dsn string, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+
+			return u.String()
+		}
+	}
+
+	return strings.TrimSpace(dsn + " search_path=" + schema)
+}
@@ -3,7 +3,9 @@ package validator
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/iancoleman/strcase"
@@ -35,6 +37,10 @@ func ValidatonError(err error) CommonError {
 		switch v.Tag() {
 		case "required":
 			res.Errors[field] = fmt.Sprintf("%v is %v", field, v.Tag())
+		case "required_without":
+			res.Errors[field] = fmt.Sprintf("%v is required without %v", field, strcase.ToSnake(v.Param()))
+		case "excluded_with":
+			res.Errors[field] = fmt.Sprintf("%v can not be set with %v", field, strcase.ToSnake(v.Param()))
 		case "sinteger":
 			res.Errors[field] = fmt.Sprintf("%v is number only", field)
 		case "sgte":
@@ -45,6 +51,14 @@ func ValidatonError(err error) CommonError {
 			res.Errors[field] = fmt.Sprintf("%v must less than %v character", field, v.Param())
 		case "min":
 			res.Errors[field] = fmt.Sprintf("%v must higher than %v character", field, v.Param())
+		case "gte":
+			res.Errors[field] = fmt.Sprintf("%v must higher than equal %v", field, v.Param())
+		case "lte":
+			res.Errors[field] = fmt.Sprintf("%v must less than equal %v", field, v.Param())
+		case "datetime":
+			res.Errors[field] = fmt.Sprintf("%v must be a RFC3339 date time", field)
+		case "oneof":
+			res.Errors[field] = fmt.Sprintf("%v must be one of %v", field, v.Param())
 		case "email":
 			res.Errors[field] = fmt.Sprintf("%v is not a valid email address", v.Value())
 		case "username":
@@ -55,6 +69,19 @@ func ValidatonError(err error) CommonError {
 	return res
 }
 
+// Message - field messages of validation error in one line, sorted by message
+func Message(err error) string {
+	fields := ValidatonError(err).Errors
+
+	messages := make([]string, 0, len(fields))
+	for _, message := range fields {
+		messages = append(messages, fmt.Sprint(message))
+	}
+	sort.Strings(messages)
+
+	return strings.Join(messages, ", ")
+}
+
 func ValidateStruct(i interface{}) error {
 	validate = validator.New()
 	validate.RegisterValidation("sinteger", Integer)
@@ -0,0 +1,44 @@
+package grpcdelivery
+
+import (
+	"go-clean-grpc/pkg/logger"
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	errorsutil "go-clean-grpc/utils/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes - translation table from domain error kind to gRPC status code
+var statusCodes = map[errorsutil.Kind]codes.Code{
+	errorsutil.KindInternal:           codes.Internal,
+	errorsutil.KindInvalidArgument:    codes.InvalidArgument,
+	errorsutil.KindNotFound:           codes.NotFound,
+	errorsutil.KindConflict:           codes.AlreadyExists,
+	errorsutil.KindFailedPrecondition: codes.FailedPrecondition,
+	errorsutil.KindUnauthenticated:    codes.Unauthenticated,
+	errorsutil.KindPermissionDenied:   codes.PermissionDenied,
+	errorsutil.KindResourceExhausted:  codes.ResourceExhausted,
+	errorsutil.KindUnavailable:        codes.Unavailable,
+	errorsutil.KindDeadlineExceeded:   codes.DeadlineExceeded,
+	errorsutil.KindCanceled:           codes.Canceled,
+	errorsutil.KindAborted:            codes.Aborted,
+}
+
+// statusError - translate domain error to gRPC status error
+func statusError(err error) error {
+	kind := errorsutil.KindOf(err)
+	code, ok := statusCodes[kind]
+	if !ok || kind == errorsutil.KindInternal {
+		logger.Error(err)
+
+		return status.Error(codes.Internal, "There is something error")
+	}
+
+	return status.Error(code, errorsutil.Message(err))
+}
+
+// validationError - translate validator errors to invalid argument status error
+func validationError(err error) error {
+	return status.Error(codes.InvalidArgument, pkgvalidator.Message(err))
+}
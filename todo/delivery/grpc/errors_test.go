@@ -0,0 +1,70 @@
+package grpcdelivery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{name: "internal", err: errorsutil.New(errorsutil.KindInternal, "secret"), code: codes.Internal, message: "There is something error"},
+		{name: "invalid argument", err: errorsutil.New(errorsutil.KindInvalidArgument, "bad page"), code: codes.InvalidArgument, message: "bad page"},
+		{name: "not found", err: errorsutil.ErrNotFound, code: codes.NotFound, message: "not found"},
+		{name: "conflict", err: errorsutil.New(errorsutil.KindConflict, "exists"), code: codes.AlreadyExists, message: "exists"},
+		{name: "failed precondition", err: errorsutil.New(errorsutil.KindFailedPrecondition, "in trash"), code: codes.FailedPrecondition, message: "in trash"},
+		{name: "unauthenticated", err: errorsutil.New(errorsutil.KindUnauthenticated, "no token"), code: codes.Unauthenticated, message: "no token"},
+		{name: "permission denied", err: errorsutil.New(errorsutil.KindPermissionDenied, "not owner"), code: codes.PermissionDenied, message: "not owner"},
+		{name: "resource exhausted", err: errorsutil.New(errorsutil.KindResourceExhausted, "quota"), code: codes.ResourceExhausted, message: "quota"},
+		{name: "unavailable", err: errorsutil.Wrap(errorsutil.KindUnavailable, "database unavailable", errors.New("dial tcp")), code: codes.Unavailable, message: "database unavailable"},
+		{name: "deadline exceeded", err: errorsutil.New(errorsutil.KindDeadlineExceeded, "database timeout"), code: codes.DeadlineExceeded, message: "database timeout"},
+		{name: "canceled", err: errorsutil.New(errorsutil.KindCanceled, "canceled"), code: codes.Canceled, message: "canceled"},
+		{name: "aborted", err: errorsutil.New(errorsutil.KindAborted, "version mismatch"), code: codes.Aborted, message: "version mismatch"},
+		{name: "unknown kind", err: errorsutil.New(errorsutil.Kind(99), "secret"), code: codes.Internal, message: "There is something error"},
+		{name: "unknown error", err: errors.New("driver: secret"), code: codes.Internal, message: "There is something error"},
+	}
+
+	// every kind has a code
+	for kind := errorsutil.KindInternal; kind <= errorsutil.KindAborted; kind++ {
+		_, ok := statusCodes[kind]
+		assert.True(t, ok, kind)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(statusError(tt.err))
+
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	pkgvalidator.New()
+	err := pkgvalidator.ValidateStruct(&models.TodoRequest{Priority: 9})
+	require.Error(t, err)
+
+	st, ok := status.FromError(validationError(err))
+
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, pkgvalidator.Message(err), st.Message())
+	for _, field := range []string{"title", "description", "priority"} {
+		assert.Contains(t, st.Message(), field)
+	}
+}
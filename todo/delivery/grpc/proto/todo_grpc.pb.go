@@ -26,7 +26,38 @@ type TodoClient interface {
 	GetAll(ctx context.Context, in *TodoGetAllInput, opts ...grpc.CallOption) (*TodoOutputs, error)
 	Get(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error)
 	Update(ctx context.Context, in *TodoInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	UpdateTodo(ctx context.Context, in *UpdateTodoRequest, opts ...grpc.CallOption) (*TodoOutput, error)
+	Complete(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	Reopen(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	AddTags(ctx context.Context, in *TodoTagsInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	RemoveTags(ctx context.Context, in *TodoTagsInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	GetTagCounts(ctx context.Context, in *TodoGetAllInput, opts ...grpc.CallOption) (*TagCounts, error)
+	// Delete moves the todo to the trash
 	Delete(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoSuccess, error)
+	GetTrash(ctx context.Context, in *TodoGetAllInput, opts ...grpc.CallOption) (*TodoOutputs, error)
+	Restore(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error)
+	// Purge permanently deletes a todo in the trash
+	Purge(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoSuccess, error)
+	// ListHistory lists the revisions of a todo, latest first
+	ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*RevisionOutputs, error)
+	// RevertTodo sets a todo back to its state after a revision
+	RevertTodo(ctx context.Context, in *RevertTodoRequest, opts ...grpc.CallOption) (*TodoOutput, error)
+	// Watch streams the created, updated and deleted todo matching the query
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Todo_WatchClient, error)
+	// BatchCreate creates todo, each item succeeds or fails on its own
+	BatchCreate(ctx context.Context, in *BatchCreateRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// BatchUpdate updates todo by id, each item succeeds or fails on its own
+	BatchUpdate(ctx context.Context, in *BatchUpdateRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// BatchDelete moves todo to the trash, each item succeeds or fails on its own
+	BatchDelete(ctx context.Context, in *BatchDeleteRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// CreateStream creates the streamed todo in batches, the response has the result of every item
+	CreateStream(ctx context.Context, opts ...grpc.CallOption) (Todo_CreateStreamClient, error)
+	// ListPermissions lists the permissions granted on a todo, oldest first
+	ListPermissions(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*PermissionOutputs, error)
+	// GrantPermission grants a role on a todo to a user or a group, granting again to them replaces the role
+	GrantPermission(ctx context.Context, in *GrantPermissionRequest, opts ...grpc.CallOption) (*PermissionOutput, error)
+	// RevokePermission revokes a permission on a todo
+	RevokePermission(ctx context.Context, in *RevokePermissionRequest, opts ...grpc.CallOption) (*TodoSuccess, error)
 }
 
 type todoClient struct {
@@ -73,6 +104,60 @@ func (c *todoClient) Update(ctx context.Context, in *TodoInput, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *todoClient) UpdateTodo(ctx context.Context, in *UpdateTodoRequest, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/UpdateTodo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) Complete(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/Complete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) Reopen(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/Reopen", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) AddTags(ctx context.Context, in *TodoTagsInput, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/AddTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) RemoveTags(ctx context.Context, in *TodoTagsInput, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/RemoveTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) GetTagCounts(ctx context.Context, in *TodoGetAllInput, opts ...grpc.CallOption) (*TagCounts, error) {
+	out := new(TagCounts)
+	err := c.cc.Invoke(ctx, "/Todo/GetTagCounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *todoClient) Delete(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoSuccess, error) {
 	out := new(TodoSuccess)
 	err := c.cc.Invoke(ctx, "/Todo/Delete", in, out, opts...)
@@ -82,6 +167,171 @@ func (c *todoClient) Delete(ctx context.Context, in *TodoIDInput, opts ...grpc.C
 	return out, nil
 }
 
+func (c *todoClient) GetTrash(ctx context.Context, in *TodoGetAllInput, opts ...grpc.CallOption) (*TodoOutputs, error) {
+	out := new(TodoOutputs)
+	err := c.cc.Invoke(ctx, "/Todo/GetTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) Restore(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) Purge(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*TodoSuccess, error) {
+	out := new(TodoSuccess)
+	err := c.cc.Invoke(ctx, "/Todo/Purge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*RevisionOutputs, error) {
+	out := new(RevisionOutputs)
+	err := c.cc.Invoke(ctx, "/Todo/ListHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) RevertTodo(ctx context.Context, in *RevertTodoRequest, opts ...grpc.CallOption) (*TodoOutput, error) {
+	out := new(TodoOutput)
+	err := c.cc.Invoke(ctx, "/Todo/RevertTodo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Todo_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Todo_ServiceDesc.Streams[0], "/Todo/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &todoWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Todo_WatchClient interface {
+	Recv() (*TodoEvent, error)
+	grpc.ClientStream
+}
+
+type todoWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *todoWatchClient) Recv() (*TodoEvent, error) {
+	m := new(TodoEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *todoClient) BatchCreate(ctx context.Context, in *BatchCreateRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/Todo/BatchCreate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) BatchUpdate(ctx context.Context, in *BatchUpdateRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/Todo/BatchUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) BatchDelete(ctx context.Context, in *BatchDeleteRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/Todo/BatchDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) CreateStream(ctx context.Context, opts ...grpc.CallOption) (Todo_CreateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Todo_ServiceDesc.Streams[1], "/Todo/CreateStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &todoCreateStreamClient{stream}
+	return x, nil
+}
+
+type Todo_CreateStreamClient interface {
+	Send(*TodoInput) error
+	CloseAndRecv() (*BatchResponse, error)
+	grpc.ClientStream
+}
+
+type todoCreateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *todoCreateStreamClient) Send(m *TodoInput) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *todoCreateStreamClient) CloseAndRecv() (*BatchResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *todoClient) ListPermissions(ctx context.Context, in *TodoIDInput, opts ...grpc.CallOption) (*PermissionOutputs, error) {
+	out := new(PermissionOutputs)
+	err := c.cc.Invoke(ctx, "/Todo/ListPermissions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) GrantPermission(ctx context.Context, in *GrantPermissionRequest, opts ...grpc.CallOption) (*PermissionOutput, error) {
+	out := new(PermissionOutput)
+	err := c.cc.Invoke(ctx, "/Todo/GrantPermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoClient) RevokePermission(ctx context.Context, in *RevokePermissionRequest, opts ...grpc.CallOption) (*TodoSuccess, error) {
+	out := new(TodoSuccess)
+	err := c.cc.Invoke(ctx, "/Todo/RevokePermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TodoServer is the server API for Todo service.
 // All implementations must embed UnimplementedTodoServer
 // for forward compatibility
@@ -90,7 +340,38 @@ type TodoServer interface {
 	GetAll(context.Context, *TodoGetAllInput) (*TodoOutputs, error)
 	Get(context.Context, *TodoIDInput) (*TodoOutput, error)
 	Update(context.Context, *TodoInput) (*TodoOutput, error)
+	UpdateTodo(context.Context, *UpdateTodoRequest) (*TodoOutput, error)
+	Complete(context.Context, *TodoIDInput) (*TodoOutput, error)
+	Reopen(context.Context, *TodoIDInput) (*TodoOutput, error)
+	AddTags(context.Context, *TodoTagsInput) (*TodoOutput, error)
+	RemoveTags(context.Context, *TodoTagsInput) (*TodoOutput, error)
+	GetTagCounts(context.Context, *TodoGetAllInput) (*TagCounts, error)
+	// Delete moves the todo to the trash
 	Delete(context.Context, *TodoIDInput) (*TodoSuccess, error)
+	GetTrash(context.Context, *TodoGetAllInput) (*TodoOutputs, error)
+	Restore(context.Context, *TodoIDInput) (*TodoOutput, error)
+	// Purge permanently deletes a todo in the trash
+	Purge(context.Context, *TodoIDInput) (*TodoSuccess, error)
+	// ListHistory lists the revisions of a todo, latest first
+	ListHistory(context.Context, *ListHistoryRequest) (*RevisionOutputs, error)
+	// RevertTodo sets a todo back to its state after a revision
+	RevertTodo(context.Context, *RevertTodoRequest) (*TodoOutput, error)
+	// Watch streams the created, updated and deleted todo matching the query
+	Watch(*WatchRequest, Todo_WatchServer) error
+	// BatchCreate creates todo, each item succeeds or fails on its own
+	BatchCreate(context.Context, *BatchCreateRequest) (*BatchResponse, error)
+	// BatchUpdate updates todo by id, each item succeeds or fails on its own
+	BatchUpdate(context.Context, *BatchUpdateRequest) (*BatchResponse, error)
+	// BatchDelete moves todo to the trash, each item succeeds or fails on its own
+	BatchDelete(context.Context, *BatchDeleteRequest) (*BatchResponse, error)
+	// CreateStream creates the streamed todo in batches, the response has the result of every item
+	CreateStream(Todo_CreateStreamServer) error
+	// ListPermissions lists the permissions granted on a todo, oldest first
+	ListPermissions(context.Context, *TodoIDInput) (*PermissionOutputs, error)
+	// GrantPermission grants a role on a todo to a user or a group, granting again to them replaces the role
+	GrantPermission(context.Context, *GrantPermissionRequest) (*PermissionOutput, error)
+	// RevokePermission revokes a permission on a todo
+	RevokePermission(context.Context, *RevokePermissionRequest) (*TodoSuccess, error)
 	mustEmbedUnimplementedTodoServer()
 }
 
@@ -110,9 +391,66 @@ func (UnimplementedTodoServer) Get(context.Context, *TodoIDInput) (*TodoOutput,
 func (UnimplementedTodoServer) Update(context.Context, *TodoInput) (*TodoOutput, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
 }
+func (UnimplementedTodoServer) UpdateTodo(context.Context, *UpdateTodoRequest) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTodo not implemented")
+}
+func (UnimplementedTodoServer) Complete(context.Context, *TodoIDInput) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Complete not implemented")
+}
+func (UnimplementedTodoServer) Reopen(context.Context, *TodoIDInput) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reopen not implemented")
+}
+func (UnimplementedTodoServer) AddTags(context.Context, *TodoTagsInput) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTags not implemented")
+}
+func (UnimplementedTodoServer) RemoveTags(context.Context, *TodoTagsInput) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTags not implemented")
+}
+func (UnimplementedTodoServer) GetTagCounts(context.Context, *TodoGetAllInput) (*TagCounts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTagCounts not implemented")
+}
 func (UnimplementedTodoServer) Delete(context.Context, *TodoIDInput) (*TodoSuccess, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedTodoServer) GetTrash(context.Context, *TodoGetAllInput) (*TodoOutputs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrash not implemented")
+}
+func (UnimplementedTodoServer) Restore(context.Context, *TodoIDInput) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedTodoServer) Purge(context.Context, *TodoIDInput) (*TodoSuccess, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Purge not implemented")
+}
+func (UnimplementedTodoServer) ListHistory(context.Context, *ListHistoryRequest) (*RevisionOutputs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHistory not implemented")
+}
+func (UnimplementedTodoServer) RevertTodo(context.Context, *RevertTodoRequest) (*TodoOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertTodo not implemented")
+}
+func (UnimplementedTodoServer) Watch(*WatchRequest, Todo_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedTodoServer) BatchCreate(context.Context, *BatchCreateRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreate not implemented")
+}
+func (UnimplementedTodoServer) BatchUpdate(context.Context, *BatchUpdateRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdate not implemented")
+}
+func (UnimplementedTodoServer) BatchDelete(context.Context, *BatchDeleteRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDelete not implemented")
+}
+func (UnimplementedTodoServer) CreateStream(Todo_CreateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CreateStream not implemented")
+}
+func (UnimplementedTodoServer) ListPermissions(context.Context, *TodoIDInput) (*PermissionOutputs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPermissions not implemented")
+}
+func (UnimplementedTodoServer) GrantPermission(context.Context, *GrantPermissionRequest) (*PermissionOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantPermission not implemented")
+}
+func (UnimplementedTodoServer) RevokePermission(context.Context, *RevokePermissionRequest) (*TodoSuccess, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePermission not implemented")
+}
 func (UnimplementedTodoServer) mustEmbedUnimplementedTodoServer() {}
 
 // UnsafeTodoServer may be embedded to opt out of forward compatibility for this service.
@@ -198,6 +536,114 @@ func _Todo_Update_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Todo_UpdateTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).UpdateTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/UpdateTodo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).UpdateTodo(ctx, req.(*UpdateTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_Complete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoIDInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).Complete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/Complete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).Complete(ctx, req.(*TodoIDInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_Reopen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoIDInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).Reopen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/Reopen",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).Reopen(ctx, req.(*TodoIDInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_AddTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoTagsInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).AddTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/AddTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).AddTags(ctx, req.(*TodoTagsInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_RemoveTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoTagsInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).RemoveTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/RemoveTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).RemoveTags(ctx, req.(*TodoTagsInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_GetTagCounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoGetAllInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).GetTagCounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/GetTagCounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).GetTagCounts(ctx, req.(*TodoGetAllInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Todo_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TodoIDInput)
 	if err := dec(in); err != nil {
@@ -216,6 +662,251 @@ func _Todo_Delete_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Todo_GetTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoGetAllInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).GetTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/GetTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).GetTrash(ctx, req.(*TodoGetAllInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoIDInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).Restore(ctx, req.(*TodoIDInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_Purge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoIDInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).Purge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/Purge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).Purge(ctx, req.(*TodoIDInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_ListHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).ListHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/ListHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).ListHistory(ctx, req.(*ListHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_RevertTodo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertTodoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).RevertTodo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/RevertTodo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).RevertTodo(ctx, req.(*RevertTodoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TodoServer).Watch(m, &todoWatchServer{stream})
+}
+
+type Todo_WatchServer interface {
+	Send(*TodoEvent) error
+	grpc.ServerStream
+}
+
+type todoWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *todoWatchServer) Send(m *TodoEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Todo_BatchCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).BatchCreate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/BatchCreate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).BatchCreate(ctx, req.(*BatchCreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_BatchUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).BatchUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/BatchUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).BatchUpdate(ctx, req.(*BatchUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_BatchDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).BatchDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/BatchDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).BatchDelete(ctx, req.(*BatchDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_CreateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TodoServer).CreateStream(&todoCreateStreamServer{stream})
+}
+
+type Todo_CreateStreamServer interface {
+	SendAndClose(*BatchResponse) error
+	Recv() (*TodoInput, error)
+	grpc.ServerStream
+}
+
+type todoCreateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *todoCreateStreamServer) SendAndClose(m *BatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *todoCreateStreamServer) Recv() (*TodoInput, error) {
+	m := new(TodoInput)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Todo_ListPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TodoIDInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).ListPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/ListPermissions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).ListPermissions(ctx, req.(*TodoIDInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_GrantPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantPermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).GrantPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/GrantPermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).GrantPermission(ctx, req.(*GrantPermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Todo_RevokePermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokePermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServer).RevokePermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Todo/RevokePermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServer).RevokePermission(ctx, req.(*RevokePermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Todo_ServiceDesc is the grpc.ServiceDesc for Todo service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -239,11 +930,90 @@ var Todo_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Update",
 			Handler:    _Todo_Update_Handler,
 		},
+		{
+			MethodName: "UpdateTodo",
+			Handler:    _Todo_UpdateTodo_Handler,
+		},
+		{
+			MethodName: "Complete",
+			Handler:    _Todo_Complete_Handler,
+		},
+		{
+			MethodName: "Reopen",
+			Handler:    _Todo_Reopen_Handler,
+		},
+		{
+			MethodName: "AddTags",
+			Handler:    _Todo_AddTags_Handler,
+		},
+		{
+			MethodName: "RemoveTags",
+			Handler:    _Todo_RemoveTags_Handler,
+		},
+		{
+			MethodName: "GetTagCounts",
+			Handler:    _Todo_GetTagCounts_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _Todo_Delete_Handler,
 		},
+		{
+			MethodName: "GetTrash",
+			Handler:    _Todo_GetTrash_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _Todo_Restore_Handler,
+		},
+		{
+			MethodName: "Purge",
+			Handler:    _Todo_Purge_Handler,
+		},
+		{
+			MethodName: "ListHistory",
+			Handler:    _Todo_ListHistory_Handler,
+		},
+		{
+			MethodName: "RevertTodo",
+			Handler:    _Todo_RevertTodo_Handler,
+		},
+		{
+			MethodName: "BatchCreate",
+			Handler:    _Todo_BatchCreate_Handler,
+		},
+		{
+			MethodName: "BatchUpdate",
+			Handler:    _Todo_BatchUpdate_Handler,
+		},
+		{
+			MethodName: "BatchDelete",
+			Handler:    _Todo_BatchDelete_Handler,
+		},
+		{
+			MethodName: "ListPermissions",
+			Handler:    _Todo_ListPermissions_Handler,
+		},
+		{
+			MethodName: "GrantPermission",
+			Handler:    _Todo_GrantPermission_Handler,
+		},
+		{
+			MethodName: "RevokePermission",
+			Handler:    _Todo_RevokePermission_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Todo_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CreateStream",
+			Handler:       _Todo_CreateStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "todo.proto",
 }
@@ -2,57 +2,78 @@ package grpcdelivery
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"go-clean-grpc/pkg/logger"
+	"io"
+	"strconv"
+
+	"go-clean-grpc/pkg/config"
+	pkgvalidator "go-clean-grpc/pkg/validator"
 	proto "go-clean-grpc/todo/delivery/grpc/proto"
 	models "go-clean-grpc/todo/models/http"
 	todoservice "go-clean-grpc/todo/service"
 	errorsutil "go-clean-grpc/utils/errors"
 	paginationutil "go-clean-grpc/utils/pagination"
+	timeutil "go-clean-grpc/utils/time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type GRPCHandler struct {
 	proto.UnimplementedTodoServer
-	service todoservice.Service
+	service   todoservice.Service
+	batchSize int // items of CreateStream created at once
 }
 
 func New(service todoservice.Service) *GRPCHandler {
 	return &GRPCHandler{
-		service: service,
+		service:   service,
+		batchSize: config.GetInt("BATCH_MAX_SIZE", 1000),
 	}
 }
 
 func (g *GRPCHandler) Create(ctx context.Context, input *proto.TodoInput) (*proto.TodoOutput, error) {
-	result, err := g.service.Create(&models.Todo{
-		Title:       input.Title,
-		Description: input.Description,
-	})
+	request := newTodoRequest(input)
+	err := pkgvalidator.ValidateStruct(request)
 	if err != nil {
-		fmt.Println(err)
+		return nil, validationError(err)
 	}
 
-	return &proto.TodoOutput{
-		Id:          result.ID.Hex(),
-		Title:       result.Title,
-		Description: result.Description,
-		CreatedAt:   result.CreatedAt.String(),
-		UpdatedAt:   result.UpdatedAt.String(),
-	}, nil
+	result, err := g.service.Create(ctx, request.Todo())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
 }
 
 func (g *GRPCHandler) GetAll(ctx context.Context, input *proto.TodoGetAllInput) (*proto.TodoOutputs, error) {
+	return g.list(ctx, input, g.service.GetAll)
+}
+
+// list - list todo of the list input
+func (g *GRPCHandler) list(ctx context.Context, input *proto.TodoGetAllInput, find func(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error)) (*proto.TodoOutputs, error) {
 	page := paginationutil.CurrentPage(int(input.Page))
 	perPage := paginationutil.PerPage(int(input.PerPage))
 	offset := paginationutil.Offset(page, perPage)
 
-	results, totalCount, err := g.service.GetAll(input.Q, perPage, offset)
+	listRequest := newListRequest(input)
+	err := pkgvalidator.ValidateStruct(listRequest)
 	if err != nil {
-		logger.Error(err)
+		return nil, validationError(err)
+	}
 
-		return nil, nil
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	results, totalCount, nextPageToken, err := find(ctx, filter, perPage, offset)
+	if err != nil {
+		return nil, statusError(err)
 	}
 
 	pageCount := paginationutil.TotalPage(totalCount, perPage)
@@ -60,64 +81,656 @@ func (g *GRPCHandler) GetAll(ctx context.Context, input *proto.TodoGetAllInput)
 	var data []*proto.TodoOutput
 
 	for _, item := range results {
-		data = append(data, &proto.TodoOutput{
-			Id:          item.ID.Hex(),
-			Title:       item.Title,
-			Description: item.Description,
-			CreatedAt:   item.CreatedAt.String(),
-			UpdatedAt:   item.UpdatedAt.String(),
-		})
+		data = append(data, toTodoOutput(item))
 	}
 
 	return &proto.TodoOutputs{
 		Data: data,
 		Meta: &proto.Meta{
-			PerPage:    int64(perPage),
-			Page:       int64(page),
-			PageCount:  int64(pageCount),
-			TotalCount: int64(totalCount),
+			PerPage:       int64(perPage),
+			Page:          int64(page),
+			PageCount:     int64(pageCount),
+			TotalCount:    int64(totalCount),
+			NextPageToken: nextPageToken,
 		},
 	}, nil
 }
 
 func (g *GRPCHandler) Get(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoOutput, error) {
-	result, err := g.service.GetByID(input.Id)
+	result, err := g.service.GetByID(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) Update(ctx context.Context, input *proto.TodoInput) (*proto.TodoOutput, error) {
+	request := newTodoRequest(input)
+	err := pkgvalidator.ValidateStruct(request)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	todo := request.Todo()
+	todo.Version = input.ExpectedVersion
+	result, err := g.service.Update(ctx, input.Id, todo)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, "Not Found")
+		return nil, statusError(err)
 	}
 
 	return &proto.TodoOutput{
-		Id:          result.ID.Hex(),
-		Title:       result.Title,
-		Description: result.Description,
-		CreatedAt:   result.CreatedAt.String(),
-		UpdatedAt:   result.UpdatedAt.String(),
+		Id:      result.ID,
+		Version: result.Version,
 	}, nil
 }
 
-func (g *GRPCHandler) Update(ctx context.Context, input *proto.TodoInput) (*proto.TodoOutput, error) {
-	result, err := g.service.Update(input.Id, &models.Todo{
-		Title:       input.Title,
-		Description: input.Description,
+// UpdateTodo - update only the fields named in update_mask
+func (g *GRPCHandler) UpdateTodo(ctx context.Context, input *proto.UpdateTodoRequest) (*proto.TodoOutput, error) {
+	if input.Todo == nil {
+		return nil, status.Error(codes.InvalidArgument, "todo is required")
+	}
+
+	request, err := newPatchRequest(input.Todo, input.UpdateMask)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	err = pkgvalidator.ValidateStruct(request)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	patch := request.TodoPatch()
+	patch.Version = input.ExpectedVersion
+	result, err := g.service.Patch(ctx, input.Todo.Id, patch)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) Complete(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoOutput, error) {
+	result, err := g.service.Complete(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) Reopen(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoOutput, error) {
+	result, err := g.service.Reopen(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) AddTags(ctx context.Context, input *proto.TodoTagsInput) (*proto.TodoOutput, error) {
+	err := pkgvalidator.ValidateStruct(&models.TodoTagsRequest{
+		Tags: input.Tags,
 	})
 	if err != nil {
-		fmt.Println(err)
+		return nil, validationError(err)
 	}
 
-	return &proto.TodoOutput{
-		Id: result.ID.Hex(),
+	result, err := g.service.AddTags(ctx, input.Id, input.Tags)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) RemoveTags(ctx context.Context, input *proto.TodoTagsInput) (*proto.TodoOutput, error) {
+	err := pkgvalidator.ValidateStruct(&models.TodoTagsRequest{
+		Tags: input.Tags,
+	})
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	result, err := g.service.RemoveTags(ctx, input.Id, input.Tags)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) GetTagCounts(ctx context.Context, input *proto.TodoGetAllInput) (*proto.TagCounts, error) {
+	listRequest := newListRequest(input)
+	err := pkgvalidator.ValidateStruct(listRequest)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	results, err := g.service.GetTagCounts(ctx, filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	var data []*proto.TagCount
+
+	for _, item := range results {
+		data = append(data, &proto.TagCount{
+			Tag:   item.Tag,
+			Count: int64(item.Count),
+		})
+	}
+
+	return &proto.TagCounts{
+		Data: data,
 	}, nil
 }
 
 func (g *GRPCHandler) Delete(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoSuccess, error) {
-	err := g.service.Delete(input.Id)
+	err := g.service.Delete(ctx, input.Id)
 	if err != nil {
-		if err == errorsutil.ErrNotFound {
-			return nil, status.Error(codes.NotFound, "Not Found")
-		}
+		return nil, statusError(err)
+	}
+
+	return &proto.TodoSuccess{
+		Success: true,
+	}, nil
+}
+
+func (g *GRPCHandler) GetTrash(ctx context.Context, input *proto.TodoGetAllInput) (*proto.TodoOutputs, error) {
+	return g.list(ctx, input, g.service.GetTrash)
+}
+
+func (g *GRPCHandler) Restore(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoOutput, error) {
+	result, err := g.service.Restore(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) Purge(ctx context.Context, input *proto.TodoIDInput) (*proto.TodoSuccess, error) {
+	err := g.service.Purge(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &proto.TodoSuccess{
+		Success: true,
+	}, nil
+}
+
+func (g *GRPCHandler) ListHistory(ctx context.Context, input *proto.ListHistoryRequest) (*proto.RevisionOutputs, error) {
+	page := paginationutil.CurrentPage(int(input.Page))
+	perPage := paginationutil.PerPage(int(input.PerPage))
+	offset := paginationutil.Offset(page, perPage)
+
+	results, totalCount, err := g.service.ListHistory(ctx, input.Id, perPage, offset)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	var data []*proto.RevisionOutput
+
+	for _, item := range results {
+		data = append(data, toRevisionOutput(item))
+	}
+
+	return &proto.RevisionOutputs{
+		Data: data,
+		Meta: &proto.Meta{
+			PerPage:    int64(perPage),
+			Page:       int64(page),
+			PageCount:  int64(paginationutil.TotalPage(totalCount, perPage)),
+			TotalCount: int64(totalCount),
+		},
+	}, nil
+}
+
+func (g *GRPCHandler) RevertTodo(ctx context.Context, input *proto.RevertTodoRequest) (*proto.TodoOutput, error) {
+	result, err := g.service.Revert(ctx, input.Id, input.RevisionId, input.ExpectedVersion)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toTodoOutput(result), nil
+}
+
+func (g *GRPCHandler) Watch(input *proto.WatchRequest, stream proto.Todo_WatchServer) error {
+	query := input.Query
+	if query == nil {
+		query = &proto.TodoGetAllInput{}
+	}
+
+	listRequest := newListRequest(query)
+	err := pkgvalidator.ValidateStruct(listRequest)
+	if err != nil {
+		return validationError(err)
+	}
+
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		return statusError(err)
+	}
+
+	err = g.service.Watch(stream.Context(), filter, input.ResumeToken, func(event *models.TodoEvent) error {
+		return stream.Send(toEventOutput(event))
+	})
+	// sending fails once the client is gone
+	if err != nil && stream.Context().Err() != nil {
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+	if err != nil {
+		return statusError(err)
+	}
+
+	return nil
+}
+
+// ListPermissions - get permissions granted on todo grpc handler, oldest first
+func (g *GRPCHandler) ListPermissions(ctx context.Context, input *proto.TodoIDInput) (*proto.PermissionOutputs, error) {
+	results, err := g.service.ListPermissions(ctx, input.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	var data []*proto.PermissionOutput
+
+	for _, item := range results {
+		data = append(data, toPermissionOutput(item))
+	}
+
+	return &proto.PermissionOutputs{
+		Data: data,
+	}, nil
+}
+
+// GrantPermission - grant role on todo to a user or a group grpc handler, granting again to them replaces the role
+func (g *GRPCHandler) GrantPermission(ctx context.Context, input *proto.GrantPermissionRequest) (*proto.PermissionOutput, error) {
+	request := &models.PermissionRequest{
+		UserID: input.UserId,
+		Group:  input.Group,
+		Role:   input.Role,
+	}
+	err := pkgvalidator.ValidateStruct(request)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	result, err := g.service.Grant(ctx, input.Id, request.Permission())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toPermissionOutput(result), nil
+}
+
+// RevokePermission - revoke permission on todo grpc handler
+func (g *GRPCHandler) RevokePermission(ctx context.Context, input *proto.RevokePermissionRequest) (*proto.TodoSuccess, error) {
+	err := g.service.Revoke(ctx, input.Id, input.PermissionId)
+	if err != nil {
+		return nil, statusError(err)
 	}
 
 	return &proto.TodoSuccess{
 		Success: true,
 	}, nil
 }
+
+// BatchCreate - create todo of a batch, invalid items fail without stopping the others
+func (g *GRPCHandler) BatchCreate(ctx context.Context, input *proto.BatchCreateRequest) (*proto.BatchResponse, error) {
+	if len(input.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items is required")
+	}
+
+	batch := &models.Batch{}
+	for _, item := range input.Items {
+		addCreate(batch, item)
+	}
+
+	err := g.createMany(ctx, batch)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &proto.BatchResponse{}
+	appendBatchResults(response, batch)
+
+	return response, nil
+}
+
+// BatchUpdate - update todo of a batch by id, expected_version of an item is its expected current version
+func (g *GRPCHandler) BatchUpdate(ctx context.Context, input *proto.BatchUpdateRequest) (*proto.BatchResponse, error) {
+	if len(input.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items is required")
+	}
+
+	batch := &models.Batch{}
+	for _, item := range input.Items {
+		request := &models.TodoBatchUpdateItem{
+			ID:          item.Id,
+			Version:     item.ExpectedVersion,
+			TodoRequest: *newTodoRequest(item),
+		}
+		err := pkgvalidator.ValidateStruct(request)
+		if err != nil {
+			batch.Invalid(item.Id, err)
+			continue
+		}
+
+		batch.Add(request.Todo())
+	}
+
+	if len(batch.Todos) > 0 {
+		results, err := g.service.UpdateMany(ctx, batch.Todos)
+		if err != nil {
+			return nil, statusError(err)
+		}
+
+		batch.Merge(results)
+	}
+
+	response := &proto.BatchResponse{}
+	appendBatchResults(response, batch)
+
+	return response, nil
+}
+
+// BatchDelete - move todo of a batch to the trash
+func (g *GRPCHandler) BatchDelete(ctx context.Context, input *proto.BatchDeleteRequest) (*proto.BatchResponse, error) {
+	err := pkgvalidator.ValidateStruct(&models.TodoBatchDeleteRequest{Ids: input.Ids})
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	batch := &models.Batch{}
+	for _, id := range input.Ids {
+		batch.Add(&models.Todo{ID: id})
+	}
+
+	results, err := g.service.DeleteMany(ctx, batch.IDs())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	batch.Merge(results)
+
+	response := &proto.BatchResponse{}
+	appendBatchResults(response, batch)
+
+	return response, nil
+}
+
+// CreateStream - create the streamed todo in batches of BATCH_MAX_SIZE items, a batch that fails as a whole fails its items
+func (g *GRPCHandler) CreateStream(stream proto.Todo_CreateStreamServer) error {
+	response := &proto.BatchResponse{}
+	batch := &models.Batch{}
+	for {
+		input, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		addCreate(batch, input)
+		if len(batch.Results) < g.batchSize {
+			continue
+		}
+
+		g.createStreamed(stream.Context(), batch, response)
+		batch = &models.Batch{}
+	}
+
+	g.createStreamed(stream.Context(), batch, response)
+
+	return stream.SendAndClose(response)
+}
+
+// createMany - create the valid items of batch
+func (g *GRPCHandler) createMany(ctx context.Context, batch *models.Batch) error {
+	if len(batch.Todos) == 0 {
+		return nil
+	}
+
+	results, err := g.service.CreateMany(ctx, batch.Todos)
+	if err != nil {
+		return err
+	}
+	batch.Merge(results)
+
+	return nil
+}
+
+// createStreamed - create the valid items of a batch of CreateStream and add its results to response
+func (g *GRPCHandler) createStreamed(ctx context.Context, batch *models.Batch, response *proto.BatchResponse) {
+	err := g.createMany(ctx, batch)
+	if err != nil {
+		batch.Fail(err)
+	}
+
+	appendBatchResults(response, batch)
+}
+
+// addCreate - add item of a create batch, invalid when it fails validation
+func addCreate(batch *models.Batch, input *proto.TodoInput) {
+	request := newTodoRequest(input)
+	err := pkgvalidator.ValidateStruct(request)
+	if err != nil {
+		batch.Invalid("", err)
+		return
+	}
+
+	batch.Add(request.Todo())
+}
+
+// appendBatchResults - add the results of batch to response, indexed after the results already in it
+func appendBatchResults(response *proto.BatchResponse, batch *models.Batch) {
+	for _, result := range batch.Results {
+		item := &proto.BatchItemResult{
+			Index:   int32(len(response.Results)),
+			Id:      result.ID,
+			Success: result.Error == nil,
+		}
+		if result.Error != nil {
+			st := status.Convert(statusError(result.Error))
+			item.Code = int32(st.Code())
+			item.Message = st.Message()
+			response.Failed++
+		} else {
+			if result.Todo != nil {
+				item.Todo = toTodoOutput(result.Todo)
+			}
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, item)
+	}
+}
+
+// newTodoRequest - todo request of proto input, validated like the REST body
+func newTodoRequest(input *proto.TodoInput) *models.TodoRequest {
+	return &models.TodoRequest{
+		Title:       input.Title,
+		Description: input.Description,
+		Status:      input.Status,
+		Priority:    int(input.Priority),
+		DueAt:       input.DueAt,
+		Tags:        input.Tags,
+	}
+}
+
+// toTodoOutput - map todo model to proto output
+func toTodoOutput(item *models.Todo) *proto.TodoOutput {
+	output := &proto.TodoOutput{
+		Id:          item.ID,
+		OwnerId:     item.OwnerID,
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		Priority:    int32(item.Priority),
+		DueAt:       timeutil.FormatTime(item.DueAt),
+		Tags:        item.Tags,
+		Score:       item.Score,
+		Version:     item.Version,
+		CreatedAt:   item.CreatedAt.String(),
+		UpdatedAt:   item.UpdatedAt.String(),
+	}
+	if item.CompletedAt != nil {
+		output.CompletedAt = item.CompletedAt.String()
+	}
+	if item.DeletedAt != nil {
+		output.DeletedAt = item.DeletedAt.String()
+	}
+
+	return output
+}
+
+// toEventOutput - map todo event to proto output
+func toEventOutput(event *models.TodoEvent) *proto.TodoEvent {
+	output := &proto.TodoEvent{
+		Type:        event.Type,
+		Id:          event.TodoID,
+		ResumeToken: event.ResumeToken,
+		Time:        event.Time.String(),
+	}
+	if event.Todo != nil {
+		output.Todo = toTodoOutput(event.Todo)
+	}
+
+	return output
+}
+
+// toRevisionOutput - map revision model to proto output
+func toRevisionOutput(item *models.Revision) *proto.RevisionOutput {
+	output := &proto.RevisionOutput{
+		Id:        item.ID,
+		TodoId:    item.TodoID,
+		Version:   item.Version,
+		Action:    item.Action,
+		Actor:     item.Actor,
+		Transport: item.Transport,
+		Reverts:   item.Reverts,
+		CreatedAt: item.CreatedAt.String(),
+	}
+	for _, change := range item.Changes {
+		output.Changes = append(output.Changes, &proto.FieldChange{
+			Field:  change.Field,
+			Before: toValue(change.Before),
+			After:  toValue(change.After),
+		})
+	}
+	if item.Todo != nil {
+		output.Todo = toTodoOutput(item.Todo)
+	}
+
+	return output
+}
+
+// toPermissionOutput - map permission model to proto output
+func toPermissionOutput(item *models.Permission) *proto.PermissionOutput {
+	return &proto.PermissionOutput{
+		Id:        item.ID,
+		TodoId:    item.TodoID,
+		UserId:    item.UserID,
+		Group:     item.Group,
+		Role:      item.Role,
+		GrantedBy: item.GrantedBy,
+		CreatedAt: item.CreatedAt.String(),
+		UpdatedAt: item.UpdatedAt.String(),
+	}
+}
+
+// toValue - map field change value to proto value, string lists become lists
+func toValue(value interface{}) *structpb.Value {
+	if values, ok := value.([]string); ok {
+		items := make([]interface{}, 0, len(values))
+		for _, item := range values {
+			items = append(items, item)
+		}
+		value = items
+	}
+
+	result, err := structpb.NewValue(value)
+	if err != nil {
+		return structpb.NewNullValue()
+	}
+
+	return result
+}
+
+// newPatchRequest - make todo patch request from the masked fields of proto input
+func newPatchRequest(input *proto.TodoInput, mask *fieldmaskpb.FieldMask) (*models.TodoPatchRequest, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "update_mask is required")
+	}
+
+	for _, path := range mask.GetPaths() {
+		if !contains(models.TodoPatchFields, path) {
+			return nil, errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf("update_mask path %q is not supported", path))
+		}
+	}
+
+	request := &models.TodoPatchRequest{}
+	for _, field := range models.TodoPatchFields {
+		if !contains(mask.GetPaths(), field) {
+			continue
+		}
+
+		switch field {
+		case "title":
+			request.Title = &input.Title
+		case "description":
+			request.Description = &input.Description
+		case "status":
+			request.Status = &input.Status
+		case "priority":
+			priority := int(input.Priority)
+			request.Priority = &priority
+		case "due_at":
+			request.DueAt = input.DueAt
+		case "tags":
+			tags := input.Tags
+			request.Tags = &tags
+		}
+		request.Fields = append(request.Fields, field)
+	}
+
+	return request, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newListRequest - make todo list request from proto input
+func newListRequest(input *proto.TodoGetAllInput) *models.TodoListRequest {
+	return &models.TodoListRequest{
+		Keywords: &models.SearchForm{
+			Keywords: input.Q,
+		},
+		Status:    input.Status,
+		Overdue:   strconv.FormatBool(input.Overdue),
+		DueFrom:   input.DueFrom,
+		DueTo:     input.DueTo,
+		Tags:      input.Tags,
+		TagsMode:  input.TagsMode,
+		Filter:    input.Filter,
+		Search:    input.Search,
+		Sort:      input.Sort,
+		PageToken: input.PageToken,
+	}
+}
@@ -0,0 +1,84 @@
+package grpcdelivery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	proto "go-clean-grpc/todo/delivery/grpc/proto"
+	mockservice "go-clean-grpc/todo/mocks/service"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+func TestNewPatchRequest(t *testing.T) {
+	input := &proto.TodoInput{
+		Id:          "1",
+		Title:       "title",
+		Description: "description",
+		Status:      "done",
+		Priority:    2,
+		DueAt:       "2026-01-02T15:04:05Z",
+		Tags:        []string{"work"},
+	}
+
+	t.Run("error invalid argument when mask is empty", func(t *testing.T) {
+		for _, mask := range []*fieldmaskpb.FieldMask{nil, {}} {
+			_, err := newPatchRequest(input, mask)
+
+			assert.Equal(t, errorsutil.KindInvalidArgument, errorsutil.KindOf(err))
+			assert.Equal(t, "update_mask is required", errorsutil.Message(err))
+		}
+	})
+
+	t.Run("error invalid argument when path is unknown", func(t *testing.T) {
+		_, err := newPatchRequest(input, &fieldmaskpb.FieldMask{Paths: []string{"title", "owner_id"}})
+
+		assert.Equal(t, errorsutil.KindInvalidArgument, errorsutil.KindOf(err))
+		assert.Contains(t, errorsutil.Message(err), `"owner_id"`)
+	})
+
+	t.Run("success when priority, tags and due_at are masked", func(t *testing.T) {
+		result, err := newPatchRequest(input, &fieldmaskpb.FieldMask{Paths: []string{"tags", "due_at", "priority"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"priority", "due_at", "tags"}, result.Fields)
+		require.NotNil(t, result.Priority)
+		assert.Equal(t, 2, *result.Priority)
+		assert.Equal(t, "2026-01-02T15:04:05Z", result.DueAt)
+		require.NotNil(t, result.Tags)
+		assert.Equal(t, []string{"work"}, *result.Tags)
+
+		// unmasked fields are kept
+		assert.Nil(t, result.Title)
+		assert.Nil(t, result.Description)
+		assert.Nil(t, result.Status)
+	})
+
+	t.Run("success when masked field is reset", func(t *testing.T) {
+		result, err := newPatchRequest(&proto.TodoInput{Id: "1"}, &fieldmaskpb.FieldMask{Paths: []string{"due_at", "tags"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"due_at", "tags"}, result.Fields)
+		assert.Empty(t, result.DueAt)
+		require.NotNil(t, result.Tags)
+		assert.Empty(t, *result.Tags)
+		assert.Nil(t, result.Priority)
+	})
+}
+
+func TestUpdateTodo(t *testing.T) {
+	mockService := new(mockservice.Service)
+	handler := New(mockService)
+
+	_, err := handler.UpdateTodo(context.Background(), &proto.UpdateTodoRequest{Todo: &proto.TodoInput{Id: "1", Title: "a"}})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "update_mask is required", status.Convert(err).Message())
+	mockService.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,91 @@
+package httpdelivery
+
+import (
+	"net/http"
+
+	models "go-clean-grpc/todo/models/http"
+	todoservice "go-clean-grpc/todo/service"
+	responseutil "go-clean-grpc/utils/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type KeyHTTPHandler interface {
+	RegisterRoutes(router *chi.Mux)
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	CreateKey(w http.ResponseWriter, r *http.Request)
+	RevokeKey(w http.ResponseWriter, r *http.Request)
+}
+
+type KeyHTTPHandlerImpl struct {
+	service todoservice.KeyService
+}
+
+// NewKeyHandler - make http handler of the admin API key endpoints
+func NewKeyHandler(service todoservice.KeyService) KeyHTTPHandler {
+	return &KeyHTTPHandlerImpl{
+		service: service,
+	}
+}
+
+func (h *KeyHTTPHandlerImpl) RegisterRoutes(router *chi.Mux) {
+	router.Get("/admin/keys", h.ListKeys)
+	router.Post("/admin/keys", h.CreateKey)
+	router.Delete("/admin/keys/{id}", h.RevokeKey)
+}
+
+// ListKeys - get API keys of the user_id query param, of every user without it, http handler
+func (h *KeyHTTPHandlerImpl) ListKeys(w http.ResponseWriter, r *http.Request) {
+	results, err := h.service.ListKeys(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: results,
+	})
+}
+
+// CreateKey - mint API key http handler, the response is the only time the key is shown
+func (h *KeyHTTPHandlerImpl) CreateKey(w http.ResponseWriter, r *http.Request) {
+	data := &models.APIKeyRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
+			return
+		}
+
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	result, err := h.service.CreateKey(r.Context(), data.APIKey())
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	// the key must not be kept by caches nor the idempotency records
+	w.Header().Set("Cache-Control", "no-store")
+	responseutil.ResponseCreated(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// RevokeKey - revoke API key http handler
+func (h *KeyHTTPHandlerImpl) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.RevokeKey(r.Context(), id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
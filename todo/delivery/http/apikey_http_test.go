@@ -0,0 +1,168 @@
+package httpdelivery_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	tododelivery "go-clean-grpc/todo/delivery/http"
+	errorsutil "go-clean-grpc/utils/errors"
+
+	mockservice "go-clean-grpc/todo/mocks/service"
+
+	models "go-clean-grpc/todo/models/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestKeyCreate(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.KeyService)
+
+		for _, body := range []string{
+			`{"scopes":["todo:read"]}`,
+			`{"name":"ci","scopes":[]}`,
+			`{"name":"ci","scopes":["todo:admin"]}`,
+			`{"name":"ci","scopes":["todo:read"],"expires_at":"tomorrow"}`,
+		} {
+			req, err := http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(tododelivery.NewKeyHandler(mockService).CreateKey)
+
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code is what expected
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+
+		// Check if the mock called
+		mockService.AssertNotCalled(t, "CreateKey", mock.Anything, mock.Anything)
+	})
+	t.Run("when return 403 forbidden (permission denied)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.KeyService)
+
+		req, err := http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["todo:read"]}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("CreateKey", mock.Anything, mock.AnythingOfType("*auth.APIKey")).Return(nil, errorsutil.New(errorsutil.KindPermissionDenied, "admin role is required"))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.NewKeyHandler(mockService).CreateKey)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+	t.Run(WhenSuccess201Created, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.KeyService)
+		expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		mockService.On("CreateKey", mock.Anything, &pkgauth.APIKey{Name: "ci", UserID: "robot", Scopes: []string{"todo:read", "todo:write"}, ExpiresAt: &expiresAt}).
+			Return(&models.CreatedAPIKey{APIKey: &pkgauth.APIKey{ID: "k1", Hash: "hash", UserID: "robot"}, Key: "tk_secret"}, nil)
+
+		router := chi.NewRouter()
+		tododelivery.NewKeyHandler(mockService).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","user_id":"robot","scopes":["todo:read","todo:write"],"expires_at":"2030-01-02T03:04:05Z"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+		body := struct {
+			Data map[string]interface{} `json:"data"`
+		}{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "k1", body.Data["id"])
+		assert.Equal(t, "tk_secret", body.Data["key"])
+		assert.NotContains(t, body.Data, "hash")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestKeyList(t *testing.T) {
+	pkgvalidator.New()
+
+	mockService := new(mockservice.KeyService)
+
+	mockService.On("ListKeys", mock.Anything, "robot").Return([]*pkgauth.APIKey{{ID: "k1", UserID: "robot"}}, nil)
+
+	router := chi.NewRouter()
+	tododelivery.NewKeyHandler(mockService).RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/keys?user_id=robot", nil)
+	router.ServeHTTP(rr, req)
+
+	// Check the status code is what expected
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"id":"k1"`)
+
+	// Check if the mock called
+	mockService.AssertExpectations(t)
+}
+
+func TestKeyRevoke(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.KeyService)
+
+		mockService.On("RevokeKey", mock.Anything, "k1").Return(nil, errorsutil.ErrNotFound)
+
+		router := chi.NewRouter()
+		tododelivery.NewKeyHandler(mockService).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/admin/keys/k1", nil)
+		router.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.KeyService)
+		revokedAt := time.Now()
+
+		mockService.On("RevokeKey", mock.Anything, "k1").Return(&pkgauth.APIKey{ID: "k1", RevokedAt: &revokedAt}, nil)
+
+		router := chi.NewRouter()
+		tododelivery.NewKeyHandler(mockService).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/admin/keys/k1", nil)
+		router.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"revoked_at"`)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
@@ -1,12 +1,21 @@
 package httpdelivery
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"go-clean-grpc/pkg/config"
 	pkgvalidator "go-clean-grpc/pkg/validator"
 	models "go-clean-grpc/todo/models/http"
 	todoservice "go-clean-grpc/todo/service"
+	errorsutil "go-clean-grpc/utils/errors"
 	paginationutil "go-clean-grpc/utils/pagination"
 	responseutil "go-clean-grpc/utils/response"
 
@@ -20,41 +29,89 @@ type HTTPHandler interface {
 	GetByID(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	Patch(w http.ResponseWriter, r *http.Request)
+	Complete(w http.ResponseWriter, r *http.Request)
+	Reopen(w http.ResponseWriter, r *http.Request)
+	AddTags(w http.ResponseWriter, r *http.Request)
+	RemoveTags(w http.ResponseWriter, r *http.Request)
+	GetTagCounts(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	GetTrash(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	Purge(w http.ResponseWriter, r *http.Request)
+	ListHistory(w http.ResponseWriter, r *http.Request)
+	Revert(w http.ResponseWriter, r *http.Request)
+	Events(w http.ResponseWriter, r *http.Request)
+	BatchCreate(w http.ResponseWriter, r *http.Request)
+	BatchUpdate(w http.ResponseWriter, r *http.Request)
+	BatchDelete(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	ListPermissions(w http.ResponseWriter, r *http.Request)
+	Grant(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
 }
 
+// errSlowClient - the client did not read the events fast enough, it reconnects with its Last-Event-ID
+var errSlowClient = errors.New("client fell behind the todo events")
+
 type HTTPHandlerImpl struct {
-	service todoservice.Service
+	service           todoservice.Service
+	heartbeatInterval time.Duration
+	eventBuffer       int
+	batchSize         int // rows of an import created at once
 }
 
 // New - make http handler
 func New(service todoservice.Service) HTTPHandler {
 	return &HTTPHandlerImpl{
-		service: service,
+		service:           service,
+		heartbeatInterval: config.GetDuration("SSE_HEARTBEAT_INTERVAL", 15*time.Second),
+		eventBuffer:       config.GetInt("SSE_BUFFER_SIZE", 64),
+		batchSize:         config.GetInt("BATCH_MAX_SIZE", 1000),
 	}
 }
 
 func (h *HTTPHandlerImpl) RegisterRoutes(router *chi.Mux) {
 	router.Get("/todo", h.GetAll)
+	router.Get("/todo/tags", h.GetTagCounts)
+	router.Get("/todo/events", h.Events)
+	router.Get("/todo/export", h.Export)
+	router.Post("/todo/import", h.Import)
 	router.Get("/todo/{id}", h.GetByID)
 	router.Post("/todo", h.Create)
+	router.Post("/todo:batchCreate", h.BatchCreate)
+	router.Post("/todo:batchUpdate", h.BatchUpdate)
+	router.Post("/todo:batchDelete", h.BatchDelete)
 	router.Put("/todo/{id}", h.Update)
+	router.Patch("/todo/{id}", h.Patch)
+	router.Post("/todo/{id}/complete", h.Complete)
+	router.Post("/todo/{id}/reopen", h.Reopen)
+	router.Post("/todo/{id}/tags", h.AddTags)
+	router.Delete("/todo/{id}/tags/{tag}", h.RemoveTags)
 	router.Delete("/todo/{id}", h.Delete)
+	router.Get("/todo/trash", h.GetTrash)
+	router.Post("/todo/trash/{id}/restore", h.Restore)
+	router.Delete("/todo/trash/{id}", h.Purge)
+	router.Get("/todo/{id}/history", h.ListHistory)
+	router.Post("/todo/{id}/history/{revision_id}/revert", h.Revert)
+	router.Get("/todo/{id}/permissions", h.ListPermissions)
+	router.Post("/todo/{id}/permissions", h.Grant)
+	router.Delete("/todo/{id}/permissions/{permission_id}", h.Revoke)
 }
 
 // GetAll - get all todo http handler
 func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
-	qQuery := r.URL.Query().Get("q")
+	h.list(w, r, h.service.GetAll)
+}
+
+// list - list todo of the list query params
+func (h *HTTPHandlerImpl) list(w http.ResponseWriter, r *http.Request, find func(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error)) {
 	pageQueryStr := r.URL.Query().Get("page")
 	perPageQueryStr := r.URL.Query().Get("per_page")
 
-	err := pkgvalidator.ValidateStruct(&models.TodoListRequest{
-		Keywords: &models.SearchForm{
-			Keywords: qQuery,
-		},
-		Page:    pageQueryStr,
-		PerPage: perPageQueryStr,
-	})
+	listRequest := newListRequest(r)
+	err := pkgvalidator.ValidateStruct(listRequest)
 	if err != nil {
 		responseutil.ResponseErrorValidation(w, r, err)
 		return
@@ -67,7 +124,13 @@ func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
 	perPage := paginationutil.PerPage(perPageQuery)
 	offset := paginationutil.Offset(currentPage, perPage)
 
-	results, totalData, err := h.service.GetAll(qQuery, perPage, offset)
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	results, totalData, nextPageToken, err := find(r.Context(), filter, perPage, offset)
 	if err != nil {
 		responseutil.ResponseError(w, r, err)
 		return
@@ -77,10 +140,11 @@ func (h *HTTPHandlerImpl) GetAll(w http.ResponseWriter, r *http.Request) {
 	responseutil.ResponseOKList(w, r, &responseutil.ResponseSuccessList{
 		Data: results,
 		Meta: &responseutil.Meta{
-			PerPage:     perPage,
-			CurrentPage: currentPage,
-			TotalPage:   totalPages,
-			TotalData:   totalData,
+			PerPage:       perPage,
+			CurrentPage:   currentPage,
+			TotalPage:     totalPages,
+			TotalData:     totalData,
+			NextPageToken: nextPageToken,
 		},
 	})
 }
@@ -91,17 +155,18 @@ func (h *HTTPHandlerImpl) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// Get detail
-	result, err := h.service.GetByID(id)
+	result, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
-		if err.Error() == "not found" {
-			responseutil.ResponseNotFound(w, r, "Item not found")
-			return
-		}
-
 		responseutil.ResponseError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(result.Version))
+	if noneMatch(r, result.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
 		Data: result,
 	})
@@ -121,10 +186,7 @@ func (h *HTTPHandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.service.Create(&models.Todo{
-		Title:       data.Title,
-		Description: data.Description,
-	})
+	result, err := h.service.Create(r.Context(), data.Todo())
 	if err != nil {
 		responseutil.ResponseError(w, r, err)
 		return
@@ -151,15 +213,40 @@ func (h *HTTPHandlerImpl) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := h.ifMatchVersion(r, id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
 	// Edit data
-	_, err := h.service.Update(id, &models.Todo{
-		Title:       data.Title,
-		Description: data.Description,
+	todo := data.Todo()
+	todo.Version = version
+	result, err := h.service.Update(r.Context(), id, todo)
+
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(result.Version))
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id": id,
+		},
 	})
+}
 
+// Patch - partially update todo by id with JSON Merge Patch http handler
+func (h *HTTPHandlerImpl) Patch(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	data, err := models.DecodeTodoPatchRequest(r.Body)
 	if err != nil {
-		if err.Error() == "not found" {
-			responseutil.ResponseNotFound(w, r, "Item not found")
+		if err == io.EOF {
+			responseutil.ResponseBodyError(w, r, err)
 			return
 		}
 
@@ -167,10 +254,132 @@ func (h *HTTPHandlerImpl) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = pkgvalidator.ValidateStruct(data)
+	if err != nil {
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	patch := data.TodoPatch()
+	patch.Version = version
+	result, err := h.service.Patch(r.Context(), id, patch)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(result.Version))
+
 	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
-		Data: responseutil.H{
-			"id": id,
-		},
+		Data: result,
+	})
+}
+
+// Complete - mark todo as done http handler
+func (h *HTTPHandlerImpl) Complete(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.Complete(r.Context(), id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Reopen - move todo back to pending http handler
+func (h *HTTPHandlerImpl) Reopen(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.Reopen(r.Context(), id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// AddTags - add tags to todo http handler
+func (h *HTTPHandlerImpl) AddTags(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	data := &models.TodoTagsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
+			return
+		}
+
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	result, err := h.service.AddTags(r.Context(), id, data.Tags)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// RemoveTags - remove tag from todo http handler
+func (h *HTTPHandlerImpl) RemoveTags(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+	tag := chi.URLParam(r, "tag")
+
+	result, err := h.service.RemoveTags(r.Context(), id, []string{tag})
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// GetTagCounts - count todo per tag http handler
+func (h *HTTPHandlerImpl) GetTagCounts(w http.ResponseWriter, r *http.Request) {
+	listRequest := newListRequest(r)
+	err := pkgvalidator.ValidateStruct(listRequest)
+	if err != nil {
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	results, err := h.service.GetTagCounts(r.Context(), filter)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: results,
 	})
 }
 
@@ -180,13 +389,179 @@ func (h *HTTPHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// Delete record
-	err := h.service.Delete(id)
+	err := h.service.Delete(r.Context(), id)
 	if err != nil {
-		if err.Error() == "not found" {
-			responseutil.ResponseNotFound(w, r, "Item not found")
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id": id,
+		},
+	})
+}
+
+// BatchCreate - create todo of a batch http handler, invalid items fail without stopping the others
+func (h *HTTPHandlerImpl) BatchCreate(w http.ResponseWriter, r *http.Request) {
+	data := &models.TodoBatchCreateRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
+			return
+		}
+
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	batch := &models.Batch{}
+	for _, item := range data.Items {
+		if item == nil {
+			item = &models.TodoRequest{}
+		}
+
+		err := pkgvalidator.ValidateStruct(item)
+		if err != nil {
+			batch.Invalid("", err)
+			continue
+		}
+
+		batch.Add(item.Todo())
+	}
+
+	h.batch(w, r, batch, func(ctx context.Context) ([]*models.BatchResult, error) {
+		return h.service.CreateMany(ctx, batch.Todos)
+	})
+}
+
+// BatchUpdate - update todo of a batch by id http handler, the version of an item is its expected current version
+func (h *HTTPHandlerImpl) BatchUpdate(w http.ResponseWriter, r *http.Request) {
+	data := &models.TodoBatchUpdateRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
+			return
+		}
+
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	batch := &models.Batch{}
+	for _, item := range data.Items {
+		if item == nil {
+			item = &models.TodoBatchUpdateItem{}
+		}
+
+		err := pkgvalidator.ValidateStruct(item)
+		if err != nil {
+			batch.Invalid(item.ID, err)
+			continue
+		}
+
+		batch.Add(item.Todo())
+	}
+
+	h.batch(w, r, batch, func(ctx context.Context) ([]*models.BatchResult, error) {
+		return h.service.UpdateMany(ctx, batch.Todos)
+	})
+}
+
+// BatchDelete - move todo of a batch to the trash http handler
+func (h *HTTPHandlerImpl) BatchDelete(w http.ResponseWriter, r *http.Request) {
+	data := &models.TodoBatchDeleteRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
 			return
 		}
 
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	batch := &models.Batch{}
+	for _, id := range data.Ids {
+		batch.Add(&models.Todo{ID: id})
+	}
+
+	h.batch(w, r, batch, func(ctx context.Context) ([]*models.BatchResult, error) {
+		return h.service.DeleteMany(ctx, batch.IDs())
+	})
+}
+
+// batch - run the valid items of batch and respond with the result of every item in the order of the request
+func (h *HTTPHandlerImpl) batch(w http.ResponseWriter, r *http.Request, batch *models.Batch, run func(ctx context.Context) ([]*models.BatchResult, error)) {
+	if len(batch.Todos) > 0 {
+		results, err := run(r.Context())
+		if err != nil {
+			responseutil.ResponseError(w, r, err)
+			return
+		}
+
+		batch.Merge(results)
+	}
+
+	items := make([]responseutil.H, 0, len(batch.Results))
+	failed := 0
+	for i, result := range batch.Results {
+		item := responseutil.H{
+			"index":   i,
+			"id":      result.ID,
+			"success": result.Error == nil,
+		}
+		if result.Error != nil {
+			failed++
+
+			body := responseutil.ErrorBody(result.Error)
+			item["code"] = body["code"]
+			item["message"] = body["message"]
+		} else if result.Todo != nil {
+			item["data"] = result.Todo
+		}
+
+		items = append(items, item)
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"succeeded": len(items) - failed,
+			"failed":    failed,
+			"results":   items,
+		},
+	})
+}
+
+// GetTrash - get deleted todo http handler, accepts the query params of GetAll
+func (h *HTTPHandlerImpl) GetTrash(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.service.GetTrash)
+}
+
+// Restore - move todo out of the trash http handler
+func (h *HTTPHandlerImpl) Restore(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	result, err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(result.Version))
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Purge - permanently delete todo in the trash http handler
+func (h *HTTPHandlerImpl) Purge(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	err := h.service.Purge(r.Context(), id)
+	if err != nil {
 		responseutil.ResponseError(w, r, err)
 		return
 	}
@@ -197,3 +572,383 @@ func (h *HTTPHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// ListHistory - get revisions of todo http handler, latest first
+func (h *HTTPHandlerImpl) ListHistory(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	pageQuery, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPageQuery, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+	currentPage := paginationutil.CurrentPage(pageQuery)
+	perPage := paginationutil.PerPage(perPageQuery)
+	offset := paginationutil.Offset(currentPage, perPage)
+
+	results, totalData, err := h.service.ListHistory(r.Context(), id, perPage, offset)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOKList(w, r, &responseutil.ResponseSuccessList{
+		Data: results,
+		Meta: &responseutil.Meta{
+			PerPage:     perPage,
+			CurrentPage: currentPage,
+			TotalPage:   paginationutil.TotalPage(totalData, perPage),
+			TotalData:   totalData,
+		},
+	})
+}
+
+// Revert - set todo back to its state after a revision http handler
+func (h *HTTPHandlerImpl) Revert(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id params
+	id := chi.URLParam(r, "id")
+	revisionID := chi.URLParam(r, "revision_id")
+
+	version, err := h.ifMatchVersion(r, id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Revert(r.Context(), id, revisionID, version)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(result.Version))
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// ListPermissions - get permissions granted on todo http handler, oldest first
+func (h *HTTPHandlerImpl) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	results, err := h.service.ListPermissions(r.Context(), id)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: results,
+	})
+}
+
+// Grant - grant role on todo to a user or a group http handler
+func (h *HTTPHandlerImpl) Grant(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id param
+	id := chi.URLParam(r, "id")
+
+	data := &models.PermissionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		if err.Error() == "EOF" {
+			responseutil.ResponseBodyError(w, r, err)
+			return
+		}
+
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	result, err := h.service.Grant(r.Context(), id, data.Permission())
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: result,
+	})
+}
+
+// Revoke - revoke permission on todo http handler
+func (h *HTTPHandlerImpl) Revoke(w http.ResponseWriter, r *http.Request) {
+	// Get and filter id params
+	id := chi.URLParam(r, "id")
+	permissionID := chi.URLParam(r, "permission_id")
+
+	err := h.service.Revoke(r.Context(), id, permissionID)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: responseutil.H{
+			"id": permissionID,
+		},
+	})
+}
+
+// Events - stream change events of todo matching the query params of GetAll as server-sent events
+// the stream resumes after the Last-Event-ID header (or last_event_id query param) of a reconnecting client,
+// a client that does not keep up with the events is disconnected and resumes the same way
+func (h *HTTPHandlerImpl) Events(w http.ResponseWriter, r *http.Request) {
+	listRequest := newListRequest(r)
+	err := pkgvalidator.ValidateStruct(listRequest)
+	if err != nil {
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responseutil.ResponseError(w, r, errors.New("response writer does not support streaming"))
+		return
+	}
+
+	resumeToken := r.Header.Get("Last-Event-ID")
+	if resumeToken == "" {
+		resumeToken = r.URL.Query().Get("last_event_id")
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// events are buffered so a slow client never holds up the event source
+	events := make(chan *models.TodoEvent, h.eventBuffer)
+	watched := make(chan error, 1)
+	go func() {
+		watched <- h.service.Watch(ctx, filter, resumeToken, func(event *models.TodoEvent) error {
+			select {
+			case events <- event:
+				return nil
+			default:
+				return errSlowClient
+			}
+		})
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	stream := &eventStream{w: w, flusher: flusher}
+	for {
+		select {
+		case event := <-events:
+			err = stream.send(event)
+		case <-heartbeat.C:
+			err = stream.comment("heartbeat")
+		case err := <-watched:
+			h.endEvents(w, r, stream, events, err)
+			return
+		}
+
+		// the client is gone
+		if err != nil {
+			return
+		}
+	}
+}
+
+// endEvents - send the buffered events once watching ended, errors are sent as response error before the stream started and as error event after
+func (h *HTTPHandlerImpl) endEvents(w http.ResponseWriter, r *http.Request, stream *eventStream, events chan *models.TodoEvent, err error) {
+	if err != nil && !stream.started {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	for len(events) > 0 {
+		if stream.send(<-events) != nil {
+			return
+		}
+	}
+
+	if err == nil || errors.Is(err, errSlowClient) {
+		return
+	}
+
+	stream.write("error", "", responseutil.ErrorBody(err))
+}
+
+// eventStream - server-sent events response, the headers are written with the first event so watch errors can still be sent as response error
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+func (s *eventStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	// disable proxy buffering, e.g. nginx
+	s.w.Header().Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// send - write todo event, its resume token is the event id
+func (s *eventStream) send(event *models.TodoEvent) error {
+	return s.write(event.Type, event.ResumeToken, event)
+}
+
+func (s *eventStream) write(name string, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.start()
+	if id != "" {
+		_, err = fmt.Fprintf(s.w, "id: %s\n", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, payload)
+	if err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// comment - write comment line, ignored by the client and keeping idle connections open
+func (s *eventStream) comment(text string) error {
+	s.start()
+	_, err := fmt.Fprintf(s.w, ": %s\n\n", text)
+	if err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// newListRequest - read todo list query params
+func newListRequest(r *http.Request) *models.TodoListRequest {
+	query := r.URL.Query()
+
+	return &models.TodoListRequest{
+		Keywords: &models.SearchForm{
+			Keywords: query.Get("q"),
+		},
+		Status:    query.Get("status"),
+		Overdue:   query.Get("overdue"),
+		DueFrom:   query.Get("due_from"),
+		DueTo:     query.Get("due_to"),
+		Tags:      splitQuery(query["tags"]),
+		TagsMode:  query.Get("tags_mode"),
+		Filter:    query.Get("filter"),
+		Search:    query.Get("search"),
+		Sort:      query.Get("sort"),
+		Page:      query.Get("page"),
+		PerPage:   query.Get("per_page"),
+		PageToken: query.Get("page_token"),
+	}
+}
+
+// splitQuery - split comma separated query values, e.g. tags=a,b&tags=c
+func splitQuery(values []string) []string {
+	results := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item != "" {
+				results = append(results, item)
+			}
+		}
+	}
+
+	return results
+}
+
+// etag - strong entity tag of todo version
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// errIfMatch - If-Match header has no tag of the todo version
+var errIfMatch = errorsutil.New(errorsutil.KindAborted, "If-Match does not match the todo version")
+
+// ifMatchVersion - expected version of todo id from If-Match header, 0 when the header is absent or "*"
+// weak tags never match, a list of tags is matched against the current version of the todo
+func (h *HTTPHandlerImpl) ifMatchVersion(r *http.Request, id string) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, nil
+	}
+
+	versions := []int64{}
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" {
+			return 0, nil
+		}
+
+		if version, ok := strongVersion(item); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, errIfMatch
+	case 1:
+		return versions[0], nil
+	}
+
+	// the matching version is still checked when the change is stored
+	current, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, version := range versions {
+		if version == current.Version {
+			return version, nil
+		}
+	}
+
+	return 0, errIfMatch
+}
+
+// strongVersion - version of a strong entity tag, versions start at 1
+func strongVersion(tag string) (int64, bool) {
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// noneMatch - check whether If-None-Match header matches the todo version, weak tags are compared too
+func noneMatch(r *http.Request, version int64) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "W/")
+		if item == "*" || item == etag(version) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,10 +2,12 @@ package httpdelivery_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	pkgvalidator "go-clean-grpc/pkg/validator"
 	tododelivery "go-clean-grpc/todo/delivery/http"
@@ -14,6 +16,8 @@ import (
 	mockservice "go-clean-grpc/todo/mocks/service"
 
 	models "go-clean-grpc/todo/models/http"
+	"go-clean-grpc/todo/policy"
+	todorepository "go-clean-grpc/todo/repository"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +29,7 @@ var WhenError500Service string = "when return 500 internal error (error service)
 var WhenError500Query string = "when return 500 internal error (error query)"
 var WhenError400Validation string = "when return 400 bad request (error validation)"
 var WhenError404NotFound string = "when return 404 not found (resouce not found)"
+var WhenError409Conflict string = "when return 409 conflict (error conflict)"
 var WhenSuccess201Created string = "when return 201 created"
 var WhenSuccess200OK string = "when return 200 ok"
 
@@ -33,7 +38,7 @@ func TestNewTodoHTTPHandler(t *testing.T) {
 
 	mockService := new(mockservice.Service)
 
-	mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+	mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 	handler := tododelivery.New(mockService)
 	handler.RegisterRoutes(chi.NewMux())
@@ -64,6 +69,157 @@ func TestTodoGetAll(t *testing.T) {
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
+	t.Run("when return 400 bad request (error validation status)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?status=unknown", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 400 bad request (error validation due date)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?due_from=tomorrow&sort=title", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 400 bad request (error filter spec)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?filter=owner:eq:me", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (filter spec and search)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?filter=priority:gte:2,status:in:pending|in_progress&search=report", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("GetAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return len(filter.Conditions) == 2 && filter.Search == "report"
+		}), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*models.Todo{}, 0, "", nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (overdue sorted by priority)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?overdue=true&sort=-priority,due_at&due_to=2022-11-30T00:00:00Z", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("GetAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.Overdue && len(filter.Sort) == 2 && filter.Sort[0].Desc && filter.DueTo != nil && filter.DueFrom == nil
+		}), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*models.Todo{}, 0, "", nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (page token)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?per_page=10&page_token=token", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("GetAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.PageToken == "token"
+		}), 10, 0).Return([]*models.Todo{}, 0, "next", nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetAll)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"next_page_token":"next"`)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
 	t.Run(WhenError500Service, func(t *testing.T) {
 		pkgvalidator.New()
 
@@ -74,7 +230,7 @@ func TestTodoGetAll(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 1, errorsutil.ErrDefault)
+		mockService.On("GetAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 1, "", errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -102,7 +258,7 @@ func TestTodoGetAll(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockListTodo, 1, nil)
+		mockService.On("GetAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockListTodo, 1, "", nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -167,6 +323,34 @@ func TestTodoCreate(t *testing.T) {
 		// Check the status code is what expected
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
+	t.Run("when return 400 bad request (error validation priority and due date)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		mockPostBody := map[string]interface{}{
+			"title":       "lorem ipsum",
+			"description": "desc",
+			"priority":    9,
+			"due_at":      "2022-11-30",
+		}
+		body, _ := json.Marshal(mockPostBody)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Create)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
 	t.Run("when error 500 internal error (error service)", func(t *testing.T) {
 		pkgvalidator.New()
 
@@ -183,7 +367,7 @@ func TestTodoCreate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -214,7 +398,7 @@ func TestTodoCreate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Create", mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -243,7 +427,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -258,6 +442,31 @@ func TestTodoGetByID(t *testing.T) {
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
+	t.Run(WhenError409Conflict, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?id=1", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrConflict)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetByID)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
 	t.Run(WhenError500Service, func(t *testing.T) {
 		pkgvalidator.New()
 
@@ -268,7 +477,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -293,7 +502,7 @@ func TestTodoGetByID(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("GetByID", mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -305,6 +514,34 @@ func TestTodoGetByID(t *testing.T) {
 		// Check the status code is what expected
 		assert.Equal(t, http.StatusOK, rr.Code)
 
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 304 not modified", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo?id=1", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-None-Match", `"1", W/"3"`)
+
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Version: 3}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetByID)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Body.String())
+
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
@@ -322,7 +559,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -379,7 +616,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrNotFound)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrNotFound)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -410,7 +647,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -441,7 +678,7 @@ func TestTodoUpdate(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
 
 		todoHandler := tododelivery.New(mockService)
 
@@ -456,74 +693,1352 @@ func TestTodoUpdate(t *testing.T) {
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
-}
-
-// TestDeleteSuccess - testing delete [200]
-func TestTodoDelete(t *testing.T) {
-	t.Run(WhenError404NotFound, func(t *testing.T) {
+	t.Run("when return 412 precondition failed", func(t *testing.T) {
 		pkgvalidator.New()
+
 		mockService := new(mockservice.Service)
 
-		req, err := http.NewRequest(http.MethodDelete, "/api/v1/product?id=", nil)
+		mockPostBody := map[string]interface{}{
+			"title":       "a",
+			"description": "a",
+		}
+		body, _ := json.Marshal(mockPostBody)
+
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/todo?id=1", bytes.NewReader(body))
 		assert.NoError(t, err)
+
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"2"`)
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
+		mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Version == 2
+		})).Return(nil, todorepository.ErrVersionConflict)
 
 		todoHandler := tododelivery.New(mockService)
 
 		rr := httptest.NewRecorder()
-		handler := http.HandlerFunc(todoHandler.Delete)
+		handler := http.HandlerFunc(todoHandler.Update)
+
 		handler.ServeHTTP(rr, req)
 
 		// Check the status code is what expected
-		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
 	})
-	t.Run(WhenError500Service, func(t *testing.T) {
+	t.Run("when return 412 precondition failed (invalid If-Match)", func(t *testing.T) {
 		pkgvalidator.New()
+
 		mockService := new(mockservice.Service)
 
-		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo?id=1", nil)
+		mockPostBody := map[string]interface{}{
+			"title":       "a",
+			"description": "a",
+		}
+		body, _ := json.Marshal(mockPostBody)
+
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/todo?id=1", bytes.NewReader(body))
 		assert.NoError(t, err)
-		req.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"2"`)
 
 		todoHandler := tododelivery.New(mockService)
 
 		rr := httptest.NewRecorder()
-		handler := http.HandlerFunc(todoHandler.Delete)
+		handler := http.HandlerFunc(todoHandler.Update)
+
 		handler.ServeHTTP(rr, req)
 
 		// Check the status code is what expected
-		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
 
 		// Check if the mock called
 		mockService.AssertExpectations(t)
 	})
-	t.Run(WhenSuccess200OK, func(t *testing.T) {
+	t.Run("when return 412 precondition failed (version below 1 or weak If-Match)", func(t *testing.T) {
 		pkgvalidator.New()
 
-		mockService := new(mockservice.Service)
+		for _, header := range []string{`"0"`, `"-1"`, `W/"2", W/"3"`, `2`} {
+			mockService := new(mockservice.Service)
 
-		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo?id=1", nil)
-		assert.NoError(t, err)
+			body, _ := json.Marshal(map[string]interface{}{"title": "a", "description": "a"})
+			req, err := http.NewRequest(http.MethodPut, "/api/v1/todo?id=1", bytes.NewReader(body))
+			assert.NoError(t, err)
 
-		req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", header)
 
-		mockService.On("Delete", mock.AnythingOfType("string")).Return(nil)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(tododelivery.New(mockService).Update).ServeHTTP(rr, req)
 
-		todoHandler := tododelivery.New(mockService)
+			assert.Equal(t, http.StatusPreconditionFailed, rr.Code, header)
+			mockService.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+	t.Run("when return 200 ok (If-Match list)", func(t *testing.T) {
+		pkgvalidator.New()
 
-		rr := httptest.NewRecorder()
-		handler := http.HandlerFunc(todoHandler.Delete)
+		tests := map[string]int64{
+			`"3", "4"`:   4,
+			`"3", W/"4"`: 3,
+			`W/"4", "5"`: 5,
+			`"3", *`:     0,
+		}
+		for header, version := range tests {
+			mockService := new(mockservice.Service)
 
-		handler.ServeHTTP(rr, req)
+			body, _ := json.Marshal(map[string]interface{}{"title": "a", "description": "a"})
+			req, err := http.NewRequest(http.MethodPut, "/api/v1/todo?id=1", bytes.NewReader(body))
+			assert.NoError(t, err)
 
-		// Check the status code is what expected
-		assert.Equal(t, http.StatusOK, rr.Code)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", header)
 
-		// Check if the mock called
-		mockService.AssertExpectations(t)
+			mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Version: 4}, nil).Maybe()
+			mockService.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(todo *models.Todo) bool {
+				return todo.Version == version
+			})).Return(&models.Todo{Version: 5}, nil)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(tododelivery.New(mockService).Update).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code, header)
+			mockService.AssertExpectations(t)
+		}
 	})
+	t.Run("when return 412 precondition failed (If-Match list without the version)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"title": "a", "description": "a"})
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/todo?id=1", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"1", "2"`)
+
+		mockService.On("GetByID", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Version: 4}, nil)
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(tododelivery.New(mockService).Update).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockService.AssertExpectations(t)
+		mockService.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestTodoPatch - testing partial update [200]
+func TestTodoPatch(t *testing.T) {
+	t.Run(WhenError400EOF, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte("")))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+	t.Run("when return 400 bad request (unknown field)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte(`{"title":"a","owner":"me"}`)))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "owner")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte(`{"title":null,"priority":9}`)))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "title")
+		assert.Contains(t, rr.Body.String(), "priority")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte(`{"title":"a"}`)))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		mockService.On("Patch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.TodoPatch")).Return(nil, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte(`{"description":"b","due_at":null,"tags":null}`)))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		mockService.On("Patch", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(patch *models.TodoPatch) bool {
+			return assert.ObjectsAreEqual([]string{"description", "due_at", "tags"}, patch.Fields) &&
+				patch.Todo.Description == "b" && patch.Todo.DueAt == nil && patch.Todo.Tags != nil && patch.Todo.Title == ""
+		})).Return(&models.Todo{}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (If-Match)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/todo/1", bytes.NewReader([]byte(`{"title":"a"}`)))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"4"`)
+
+		mockService.On("Patch", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(patch *models.TodoPatch) bool {
+			return patch.Version == 4
+		})).Return(&models.Todo{Version: 5}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Patch)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestDeleteSuccess - testing delete [200]
+func TestTodoDelete(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/product?id=", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Delete)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+	t.Run(WhenError500Service, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo?id=1", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Delete)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo?id=1", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Delete)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoComplete - testing complete [200]
+func TestTodoComplete(t *testing.T) {
+	t.Run(WhenError409Conflict, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/complete", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Complete", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrFailedPrecondition)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Complete)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/complete", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Complete", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusDone}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Complete)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoReopen - testing reopen [200]
+func TestTodoReopen(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/reopen", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Reopen", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Reopen)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/reopen", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Reopen", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusPending}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Reopen)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoAddTags - testing add tags [200]
+func TestTodoAddTags(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		mockPostBody := map[string]interface{}{
+			"tags": []string{},
+		}
+		body, _ := json.Marshal(mockPostBody)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/tags", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.AddTags)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		mockPostBody := map[string]interface{}{
+			"tags": []string{"work"},
+		}
+		body, _ := json.Marshal(mockPostBody)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/tags", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("AddTags", mock.Anything, mock.AnythingOfType("string"), []string{"work"}).Return(&models.Todo{}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.AddTags)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoRemoveTags - testing remove tags [200]
+func TestTodoRemoveTags(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo/1/tags/work", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("RemoveTags", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]string")).Return(nil, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.RemoveTags)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoGetTagCounts - testing tag counts [200]
+func TestTodoGetTagCounts(t *testing.T) {
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/tags?tags=work,home&tags_mode=all", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("GetTagCounts", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return len(filter.Tags) == 2 && filter.TagsMode == models.TagsModeAll
+		})).Return([]*models.TagCount{{Tag: "work", Count: 1}}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetTagCounts)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoGetTrash - testing get trash [200]
+func TestTodoGetTrash(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/trash?per_page=-1", nil)
+		assert.NoError(t, err)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetTrash)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/trash?sort=-deleted_at", nil)
+		assert.NoError(t, err)
+
+		mockService.On("GetTrash", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return len(filter.Sort) == 1 && filter.Sort[0].Field.Name == "deleted_at"
+		}), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*models.Todo{{}}, 1, "", nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.GetTrash)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoRestore - testing restore [200]
+func TestTodoRestore(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/trash/1/restore", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Restore", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Restore)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/trash/1/restore", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Restore", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Version: 3}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Restore)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoPurge - testing purge [200]
+func TestTodoPurge(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo/trash/1", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Purge", mock.Anything, mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Purge)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo/trash/1", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Purge", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Purge)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoListHistory - testing list history [200]
+func TestTodoListHistory(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/1/history", nil)
+		assert.NoError(t, err)
+
+		mockService.On("ListHistory", mock.Anything, mock.AnythingOfType("string"), 10, 0).Return(nil, 0, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.ListHistory)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/1/history?page=2&per_page=5", nil)
+		assert.NoError(t, err)
+
+		mockList := []*models.Revision{{ID: "2", Action: models.ActionUpdate, Changes: []*models.FieldChange{{Field: "title", Before: "a", After: "b"}}}}
+		mockService.On("ListHistory", mock.Anything, mock.AnythingOfType("string"), 5, 5).Return(mockList, 6, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.ListHistory)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"changes":[{"field":"title","before":"a","after":"b"}]`)
+		assert.Contains(t, rr.Body.String(), `"total_count":6`)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoRevert - testing revert [200]
+func TestTodoRevert(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/history/2/revert", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Revert", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), int64(0)).Return(nil, errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Revert)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (If-Match)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/history/2/revert", nil)
+		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"4"`)
+
+		mockService.On("Revert", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), int64(4)).Return(&models.Todo{Version: 5}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Revert)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoGrant - testing grant permission [400, 403, 200]
+func TestTodoGrant(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		for _, body := range []string{
+			`{"role":"viewer"}`,
+			`{"user_id":"bob","group":"team","role":"viewer"}`,
+			`{"user_id":"bob","role":"admin"}`,
+		} {
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/permissions", bytes.NewBufferString(body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			todoHandler := tododelivery.New(mockService)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(todoHandler.Grant)
+
+			handler.ServeHTTP(rr, req)
+
+			// Check the status code is what expected
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+
+		// Check if the mock called
+		mockService.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("when return 403 forbidden (permission denied)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/permissions", bytes.NewBufferString(`{"group":"team","role":"editor"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Grant", mock.Anything, mock.AnythingOfType("string"), &models.Permission{Group: "team", Role: models.RoleEditor}).Return(nil, policy.ErrPermissionDenied)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Grant)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "permission denied on todo")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/todo/1/permissions", bytes.NewBufferString(`{"user_id":"bob","role":"viewer"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Grant", mock.Anything, mock.AnythingOfType("string"), &models.Permission{UserID: "bob", Role: models.RoleViewer}).
+			Return(&models.Permission{ID: "2", TodoID: "1", UserID: "bob", Role: models.RoleViewer, GrantedBy: "alice"}, nil)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Grant)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"user_id":"bob","role":"viewer","granted_by":"alice"`)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoRevoke - testing revoke permission [404, 200]
+func TestTodoRevoke(t *testing.T) {
+	t.Run(WhenError404NotFound, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/todo/1/permissions/2", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Revoke", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errorsutil.ErrNotFound)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Revoke)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		mockService.On("Revoke", mock.Anything, "1", "2").Return(nil)
+		mockService.On("ListPermissions", mock.Anything, "1").Return([]*models.Permission{}, nil)
+
+		router := chi.NewRouter()
+		tododelivery.New(mockService).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/todo/1/permissions/2", nil)
+		router.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/todo/1/permissions", nil)
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"data":[]`)
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestTodoEvents - testing server-sent events [200]
+// TestTodoBatchCreate - testing batch create [400, 500, 200]
+func TestTodoBatchCreate(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"items": []interface{}{}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchCreate", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchCreate)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything)
+	})
+	t.Run(WhenError500Service, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"title": "a", "description": "a"},
+		}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchCreate", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("CreateMany", mock.Anything, mock.Anything).Return(nil, errorsutil.ErrDefault)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchCreate)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"title": "a", "description": "a"},
+			map[string]interface{}{"title": "", "description": "b"},
+			nil,
+			map[string]interface{}{"title": "c", "description": "c"},
+		}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchCreate", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("CreateMany", mock.Anything, mock.MatchedBy(func(values []*models.Todo) bool {
+			return len(values) == 2 && values[0].Title == "a" && values[1].Title == "c"
+		})).Return([]*models.BatchResult{
+			{ID: "1", Todo: &models.Todo{ID: "1", Title: "a"}},
+			{Error: errorsutil.ErrDefault},
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchCreate)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		result := batchResponse(t, rr)
+		assert.Equal(t, 1, result.Data.Succeeded)
+		assert.Equal(t, 3, result.Data.Failed)
+		assert.Len(t, result.Data.Results, 4)
+		assert.True(t, result.Data.Results[0].Success)
+		assert.Equal(t, "1", result.Data.Results[0].ID)
+		assert.Equal(t, http.StatusBadRequest, result.Data.Results[1].Code)
+		assert.Equal(t, "title is required", result.Data.Results[1].Message)
+		assert.Equal(t, http.StatusBadRequest, result.Data.Results[2].Code)
+		assert.Equal(t, 3, result.Data.Results[3].Index)
+		assert.Equal(t, http.StatusInternalServerError, result.Data.Results[3].Code)
+	})
+}
+
+// TestTodoBatchUpdate - testing batch update [400, 200]
+func TestTodoBatchUpdate(t *testing.T) {
+	t.Run(WhenError400EOF, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchUpdate", bytes.NewReader([]byte("")))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchUpdate)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"id": "1", "version": 2, "title": "a", "description": "a"},
+			map[string]interface{}{"id": "2", "title": "b"},
+			map[string]interface{}{"id": "3", "title": "c", "description": "c"},
+		}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchUpdate", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("UpdateMany", mock.Anything, mock.MatchedBy(func(values []*models.Todo) bool {
+			return len(values) == 2 && values[0].ID == "1" && values[0].Version == 2 && values[1].ID == "3"
+		})).Return([]*models.BatchResult{
+			{ID: "1", Todo: &models.Todo{ID: "1", Title: "a", Version: 3}},
+			{ID: "3", Error: todorepository.ErrVersionConflict},
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchUpdate)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		result := batchResponse(t, rr)
+		assert.Equal(t, 1, result.Data.Succeeded)
+		assert.Equal(t, 2, result.Data.Failed)
+		assert.True(t, result.Data.Results[0].Success)
+		assert.Equal(t, "2", result.Data.Results[1].ID)
+		assert.Equal(t, http.StatusBadRequest, result.Data.Results[1].Code)
+		assert.Equal(t, http.StatusPreconditionFailed, result.Data.Results[2].Code)
+	})
+}
+
+// TestTodoBatchDelete - testing batch delete [400, 200]
+func TestTodoBatchDelete(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"ids": []string{"1", ""}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchDelete", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchDelete)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		body, _ := json.Marshal(map[string]interface{}{"ids": []string{"1", "2"}})
+		req, err := http.NewRequest(http.MethodPost, "/todo:batchDelete", bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("DeleteMany", mock.Anything, []string{"1", "2"}).Return([]*models.BatchResult{
+			{ID: "1"},
+			{ID: "2", Error: errorsutil.ErrNotFound},
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).BatchDelete)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		result := batchResponse(t, rr)
+		assert.Equal(t, 1, result.Data.Succeeded)
+		assert.True(t, result.Data.Results[0].Success)
+		assert.Equal(t, http.StatusNotFound, result.Data.Results[1].Code)
+	})
+}
+
+// batchResult - body of a batch response
+type batchResult struct {
+	Data struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Results   []struct {
+			Index   int    `json:"index"`
+			ID      string `json:"id"`
+			Success bool   `json:"success"`
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"results"`
+	} `json:"data"`
+}
+
+func batchResponse(t *testing.T, rr *httptest.ResponseRecorder) *batchResult {
+	result := &batchResult{}
+	err := json.Unmarshal(rr.Body.Bytes(), result)
+	assert.NoError(t, err)
+
+	return result
+}
+
+func TestTodoEvents(t *testing.T) {
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/events?status=invalid", nil)
+		assert.NoError(t, err)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Events)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "Watch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("when return 409 conflict (resume token expired)", func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/events", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "expired")
+
+		mockService.On("Watch", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), "expired", mock.Anything).Return(todorepository.ErrResumeTokenExpired)
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Events)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "resume token is invalid or expired")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run(WhenSuccess200OK, func(t *testing.T) {
+		pkgvalidator.New()
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/events?status=done", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "t1")
+
+		mockService.On("Watch", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.Status == models.StatusDone
+		}), "t1", mock.Anything).Return(func(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(*models.TodoEvent) error) error {
+			send(&models.TodoEvent{Type: models.EventCreated, TodoID: "1", Todo: &models.Todo{ID: "1", Title: "a"}, ResumeToken: "t2"})
+			send(&models.TodoEvent{Type: models.EventDeleted, TodoID: "2", ResumeToken: "t3"})
+
+			return nil
+		})
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Events)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "id: t2\nevent: created\ndata: {\"type\":\"created\",\"todo_id\":\"1\",\"todo\":{")
+		assert.Contains(t, rr.Body.String(), "id: t3\nevent: deleted\ndata: {\"type\":\"deleted\",\"todo_id\":\"2\",\"resume_token\":\"t3\"")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (heartbeat)", func(t *testing.T) {
+		pkgvalidator.New()
+		t.Setenv("SSE_HEARTBEAT_INTERVAL", "5ms")
+
+		mockService := new(mockservice.Service)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/todo/events?last_event_id=t1", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Watch", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), "t1", mock.Anything).Return(func(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(*models.TodoEvent) error) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		todoHandler := tododelivery.New(mockService)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(todoHandler.Events)
+
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), ": heartbeat\n\n")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+	t.Run("when return 200 ok (slow client)", func(t *testing.T) {
+		pkgvalidator.New()
+		t.Setenv("SSE_BUFFER_SIZE", "1")
+
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/todo/events", nil)
+		assert.NoError(t, err)
+
+		rr := &blockingRecorder{ResponseRecorder: httptest.NewRecorder(), unblock: make(chan struct{})}
+
+		var sendErr error
+		mockService.On("Watch", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), "", mock.Anything).Return(func(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(*models.TodoEvent) error) error {
+			defer close(rr.unblock)
+
+			for i := 0; i < 10 && sendErr == nil; i++ {
+				sendErr = send(&models.TodoEvent{Type: models.EventUpdated, TodoID: "1"})
+			}
+
+			return sendErr
+		})
+
+		todoHandler := tododelivery.New(mockService)
+		handler := http.HandlerFunc(todoHandler.Events)
+
+		handler.ServeHTTP(rr, req)
+
+		// the event source is not held up by the client
+		assert.Error(t, sendErr)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "event: error")
+
+		// Check if the mock called
+		mockService.AssertExpectations(t)
+	})
+}
+
+// blockingRecorder - response recorder of a client reading nothing until unblocked
+type blockingRecorder struct {
+	*httptest.ResponseRecorder
+	unblock chan struct{}
+}
+
+func (r *blockingRecorder) Write(data []byte) (int, error) {
+	<-r.unblock
+	return r.ResponseRecorder.Write(data)
 }
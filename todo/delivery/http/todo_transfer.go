@@ -0,0 +1,631 @@
+package httpdelivery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"go-clean-grpc/pkg/logger"
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+	responseutil "go-clean-grpc/utils/response"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// transferFormat - file format of the import and export
+type transferFormat struct {
+	name        string // format query param and file extension
+	contentType string
+}
+
+var (
+	formatCSV    = &transferFormat{name: "csv", contentType: "text/csv"}
+	formatJSONL  = &transferFormat{name: "jsonl", contentType: "application/jsonl"}
+	formatNDJSON = &transferFormat{name: "ndjson", contentType: "application/x-ndjson"}
+)
+
+// transferFormats - formats by media type, JSON Lines and NDJSON are the same format under another name
+var transferFormats = map[string]*transferFormat{
+	"text/csv":                formatCSV,
+	"application/csv":         formatCSV,
+	"application/jsonl":       formatJSONL,
+	"application/jsonlines":   formatJSONL,
+	"application/x-jsonlines": formatJSONL,
+	"application/x-ndjson":    formatNDJSON,
+	"application/ndjson":      formatNDJSON,
+}
+
+// csvColumns - columns of the CSV export, the import reads the columns of TodoRequest and ignores the others
+var csvColumns = []string{"id", "title", "description", "status", "priority", "due_at", "tags", "completed_at", "created_at", "updated_at", "version"}
+
+const (
+	// tagSeparator - separator of the tags in the tags CSV column
+	tagSeparator = ";"
+	// maxLineSize - longest JSON line read by the import
+	maxLineSize = 1 << 20
+	// maxImportErrors - row errors listed by the import report, the others are only counted
+	maxImportErrors = 1000
+	// exportFlushRows - rows written between flushes of the export
+	exportFlushRows = 100
+	// byteOrderMark - UTF-8 byte order mark, spreadsheets may start the files with it
+	byteOrderMark = "\ufeff"
+)
+
+// ExportErrorTrailer - trailer of an export that failed after its first row, when its connection could not be closed
+const ExportErrorTrailer = "Export-Error"
+
+// Export - stream the todo matching the query params of GetAll as CSV or JSON Lines http handler
+// the format is the format query param (csv, jsonl or ndjson) or the accepted media type with the highest quality
+func (h *HTTPHandlerImpl) Export(w http.ResponseWriter, r *http.Request) {
+	format := exportFormat(r)
+	if format == nil {
+		responseutil.ResponseNotAcceptable(w, r, "Export is available as text/csv, application/jsonl or application/x-ndjson")
+		return
+	}
+
+	listRequest := newListRequest(r)
+	err := pkgvalidator.ValidateStruct(listRequest)
+	if err != nil {
+		responseutil.ResponseErrorValidation(w, r, err)
+		return
+	}
+
+	filter, err := listRequest.TodoFilter()
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	writer := &exportWriter{w: w, format: format}
+	err = h.service.Export(r.Context(), filter, writer.write)
+	if err == nil {
+		err = writer.close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !writer.started {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	// the status is already sent, send the rows written so far and abort the response
+	// so the client does not take the file as complete
+	logger.Error(fmt.Errorf("export todo: %w", err))
+	writer.flush()
+	abortResponse(w)
+}
+
+// abortResponse - end a response whose status was already sent as incomplete, the connection is closed before the end of the body
+// a panic would not do, the Recoverer middleware swallows http.ErrAbortHandler and the body would be ended normally
+// when the connection can not be taken over, e.g. with HTTP/2, the ExportErrorTrailer trailer tells the file is incomplete
+func abortResponse(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		conn, _, err := hijacker.Hijack()
+		if err == nil {
+			conn.Close()
+			return
+		}
+	}
+
+	w.Header().Set(http.TrailerPrefix+ExportErrorTrailer, "export failed, the file is incomplete")
+}
+
+// Import - create todo from a CSV or JSON Lines file http handler, responds with the error of every rejected row
+// the file is the file field of a multipart form or the request body, its format is the format query param, its content type or its extension
+// rows are read, validated and created BATCH_MAX_SIZE at a time, the file is never loaded at once
+func (h *HTTPHandlerImpl) Import(w http.ResponseWriter, r *http.Request) {
+	file, format, err := importFile(r)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+	if format == nil {
+		responseutil.ResponseUnsupportedMediaType(w, r, "Import accepts text/csv, application/jsonl or application/x-ndjson files")
+		return
+	}
+
+	rows, err := newRowReader(file, format)
+	if err != nil {
+		responseutil.ResponseError(w, r, err)
+		return
+	}
+
+	report := &importReport{Errors: []*importError{}}
+	batch := &importBatch{}
+	for {
+		request, line, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var invalid *rowError
+		switch {
+		case errors.As(err, &invalid):
+			batch.failed(line, invalid.err)
+		case err != nil:
+			h.createImported(r.Context(), batch, report)
+			responseutil.ResponseError(w, r, errorsutil.Wrap(errorsutil.KindInvalidArgument, fmt.Sprintf("file could not be read to the end, %d todo were imported", report.Imported), err))
+			return
+		default:
+			batch.add(line, request)
+		}
+
+		if len(batch.Results) >= h.batchSize {
+			h.createImported(r.Context(), batch, report)
+			batch = &importBatch{}
+		}
+	}
+	h.createImported(r.Context(), batch, report)
+
+	responseutil.ResponseOK(w, r, &responseutil.ResponseSuccess{
+		Data: report,
+	})
+}
+
+// createImported - create the valid rows of batch and add the result of every row to report
+func (h *HTTPHandlerImpl) createImported(ctx context.Context, batch *importBatch, report *importReport) {
+	if len(batch.Todos) > 0 {
+		results, err := h.service.CreateMany(ctx, batch.Todos)
+		if err != nil {
+			batch.Fail(err)
+		} else {
+			batch.Merge(results)
+		}
+	}
+
+	for i, result := range batch.Results {
+		report.add(batch.lines[i], result)
+	}
+}
+
+// exportFormat - format of the format query param or the Accept header, nil when none is supported
+// JSON Lines when any format is accepted
+func exportFormat(r *http.Request) *transferFormat {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return formatNamed(name)
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSONL
+	}
+
+	var result *transferFormat
+	best := 0.0
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		format, ok := transferFormats[mediaType]
+		switch mediaType {
+		case "*/*", "application/*":
+			format, ok = formatJSONL, true
+		case "text/*":
+			format, ok = formatCSV, true
+		}
+
+		if ok && quality > best {
+			result = format
+			best = quality
+		}
+	}
+
+	return result
+}
+
+// formatNamed - format by name, nil when there is none
+func formatNamed(name string) *transferFormat {
+	for _, format := range []*transferFormat{formatCSV, formatJSONL, formatNDJSON} {
+		if strings.EqualFold(format.name, name) {
+			return format
+		}
+	}
+
+	return nil
+}
+
+// exportWriter - writer of the export rows, the response starts with the first row
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  *transferFormat
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+	rows    int
+}
+
+func (e *exportWriter) start() error {
+	e.started = true
+	e.w.Header().Set("Content-Type", e.format.contentType)
+	e.w.Header().Set("Content-Disposition", `attachment; filename="todo.`+e.format.name+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == formatCSV {
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write(csvColumns)
+	}
+
+	e.json = json.NewEncoder(e.w)
+
+	return nil
+}
+
+func (e *exportWriter) write(todo *models.Todo) error {
+	if !e.started {
+		err := e.start()
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if e.csv != nil {
+		err = e.csv.Write(csvRecord(todo))
+	} else {
+		err = e.json.Encode(todo)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+
+	return nil
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// close - write the rows left, an empty export still has the CSV header
+func (e *exportWriter) close() error {
+	if !e.started {
+		err := e.start()
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.flush()
+}
+
+// csvRecord - CSV row of todo in the order of csvColumns
+func csvRecord(todo *models.Todo) []string {
+	return []string{
+		todo.ID,
+		todo.Title,
+		todo.Description,
+		todo.Status,
+		strconv.Itoa(todo.Priority),
+		timeutil.FormatTime(todo.DueAt),
+		strings.Join(todo.Tags, tagSeparator),
+		timeutil.FormatTime(todo.CompletedAt),
+		timeutil.FormatTime(&todo.CreatedAt),
+		timeutil.FormatTime(&todo.UpdatedAt),
+		strconv.FormatInt(todo.Version, 10),
+	}
+}
+
+// importFile - file of the import and its format, the format is nil when it is not supported
+func importFile(r *http.Request) (io.Reader, *transferFormat, error) {
+	var format *transferFormat
+	if name := r.URL.Query().Get("format"); name != "" {
+		format = formatNamed(name)
+		if format == nil {
+			return nil, nil, nil
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if format == nil {
+			format = transferFormats[mediaType]
+		}
+
+		return r.Body, format, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, errorsutil.Wrap(errorsutil.KindInvalidArgument, "invalid multipart form", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errorsutil.New(errorsutil.KindInvalidArgument, "file is required")
+		}
+		if err != nil {
+			return nil, nil, errorsutil.Wrap(errorsutil.KindInvalidArgument, "invalid multipart form", err)
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		if format == nil {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			format = transferFormats[partType]
+		}
+		if format == nil {
+			format = formatNamed(strings.TrimPrefix(path.Ext(part.FileName()), "."))
+		}
+
+		return part, format, nil
+	}
+}
+
+// importBatch - rows of the import created at once, with the line of every row
+type importBatch struct {
+	models.Batch
+	lines []int
+}
+
+func (b *importBatch) add(line int, request *models.TodoRequest) {
+	b.lines = append(b.lines, line)
+
+	err := pkgvalidator.ValidateStruct(request)
+	if err != nil {
+		b.Invalid("", err)
+		return
+	}
+
+	b.Add(request.Todo())
+}
+
+func (b *importBatch) failed(line int, err error) {
+	b.lines = append(b.lines, line)
+	b.Failed("", err)
+}
+
+// importReport - result of the import, errors has the first maxImportErrors rejected rows
+type importReport struct {
+	Total           int            `json:"total"`
+	Imported        int            `json:"imported"`
+	Failed          int            `json:"failed"`
+	Errors          []*importError `json:"errors"`
+	ErrorsTruncated bool           `json:"errors_truncated"`
+}
+
+// importError - error of a rejected row
+type importError struct {
+	Line    int         `json:"line"`
+	Code    interface{} `json:"code"`
+	Message interface{} `json:"message"`
+}
+
+func (r *importReport) add(line int, result *models.BatchResult) {
+	r.Total++
+	if result.Error == nil {
+		r.Imported++
+		return
+	}
+
+	r.Failed++
+	if len(r.Errors) == maxImportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+
+	body := responseutil.ErrorBody(result.Error)
+	r.Errors = append(r.Errors, &importError{
+		Line:    line,
+		Code:    body["code"],
+		Message: body["message"],
+	})
+}
+
+// rowReader - reader of the import rows
+type rowReader interface {
+	// next - next row and its line, a *rowError when the row can not be read, io.EOF after the last row
+	next() (*models.TodoRequest, int, error)
+}
+
+// rowError - error of a row that can not be read, the next rows can still be read
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+func newRowReader(r io.Reader, format *transferFormat) (rowReader, error) {
+	if format == formatCSV {
+		return newCSVRowReader(r)
+	}
+
+	return &jsonRowReader{reader: bufio.NewReader(r)}, nil
+}
+
+// csvRowReader - reader of CSV rows, the first line is the header naming the columns
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int // column index by name
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "csv file is empty, the first line must be the header")
+	}
+	if err != nil {
+		return nil, errorsutil.Wrap(errorsutil.KindInvalidArgument, "invalid csv header", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimPrefix(name, byteOrderMark)
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "csv header must have a title column")
+	}
+
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvRowReader) next() (*models.TodoRequest, int, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, 0, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, parseErr.StartLine, &rowError{err: errorsutil.Wrap(errorsutil.KindInvalidArgument, parseErr.Err.Error(), err)}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	request := &models.TodoRequest{
+		Title:       c.value(record, "title"),
+		Description: c.value(record, "description"),
+		Status:      c.value(record, "status"),
+		DueAt:       c.value(record, "due_at"),
+		Tags:        splitTags(c.value(record, "tags")),
+	}
+
+	if priority := c.value(record, "priority"); priority != "" {
+		request.Priority, err = strconv.Atoi(priority)
+		if err != nil {
+			return nil, line, &rowError{err: errorsutil.New(errorsutil.KindInvalidArgument, "priority is number only")}
+		}
+	}
+
+	return request, line, nil
+}
+
+// value - value of the column in record, empty when the file has no such column
+func (c *csvRowReader) value(record []string, column string) string {
+	i, ok := c.columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// splitTags - tags of the tags CSV column
+func splitTags(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	results := []string{}
+	for _, tag := range strings.Split(value, tagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			results = append(results, tag)
+		}
+	}
+
+	return results
+}
+
+// jsonRowReader - reader of JSON Lines rows, each line is a TodoRequest object and blank lines are skipped
+type jsonRowReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (j *jsonRowReader) next() (*models.TodoRequest, int, error) {
+	for {
+		j.line++
+		data, err := j.readLine()
+		if err != nil {
+			return nil, j.line, err
+		}
+
+		if j.line == 1 {
+			data = bytes.TrimPrefix(data, []byte(byteOrderMark))
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		request := &models.TodoRequest{}
+		err = json.Unmarshal(data, request)
+		if err != nil {
+			return nil, j.line, &rowError{err: errorsutil.Wrap(errorsutil.KindInvalidArgument, "invalid JSON: "+err.Error(), err)}
+		}
+
+		return request, j.line, nil
+	}
+}
+
+// readLine - next line without its end, a *rowError when it is longer than maxLineSize
+func (j *jsonRowReader) readLine() ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := j.reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line)+len(chunk) > maxLineSize {
+			tooLong = true
+			line = nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+
+		if !isPrefix {
+			break
+		}
+	}
+
+	if tooLong {
+		return nil, &rowError{err: errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf("line is longer than %d bytes", maxLineSize))}
+	}
+
+	return line, nil
+}
@@ -0,0 +1,339 @@
+package httpdelivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	tododelivery "go-clean-grpc/todo/delivery/http"
+	mockservice "go-clean-grpc/todo/mocks/service"
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTodoExport - testing export [406, 400, 500, 200]
+func TestTodoExport(t *testing.T) {
+	dueAt := time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC)
+	mockTodos := []*models.Todo{
+		{ID: "1", Title: "a", Description: "a, \"quoted\"", Status: models.StatusPending, Priority: 2, DueAt: &dueAt, Tags: []string{"work", "home"}, Version: 1},
+		{ID: "2", Title: "b", Description: "b", Status: models.StatusDone, Version: 3},
+	}
+	exportTodos := func(ctx context.Context, filter *models.TodoFilter, send func(*models.Todo) error) error {
+		for _, todo := range mockTodos {
+			err := send(todo)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	t.Run("when return 406 not acceptable", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept", "application/xml")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+		mockService.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run(WhenError400Validation, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export?status=unknown", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+	t.Run(WhenError500Service, func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(errorsutil.ErrDefault)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+	t.Run("when return 200 ok (csv)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export?status=pending", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept", "application/jsonl;q=0.5, text/csv")
+
+		mockService.On("Export", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.Status == models.StatusPending
+		}), mock.Anything).Return(exportTodos)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), "todo.csv")
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"id", "title", "description", "status", "priority", "due_at", "tags", "completed_at", "created_at", "updated_at", "version"}, records[0])
+		assert.Equal(t, []string{"1", "a", "a, \"quoted\"", "pending", "2", "2022-11-30T00:00:00Z", "work;home"}, records[1][:7])
+		assert.Equal(t, "3", records[2][10])
+	})
+	t.Run("when export fails after the first row", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export?format=jsonl", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, filter *models.TodoFilter, send func(*models.Todo) error) error {
+			err := send(mockTodos[0])
+			if err != nil {
+				return err
+			}
+
+			return errorsutil.ErrDefault
+		})
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// the recorder can not be closed, the trailer tells the file is incomplete
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Result().Trailer.Get(tododelivery.ExportErrorTrailer))
+	})
+	t.Run("when return 200 ok (ndjson)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export?format=ndjson", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept", "text/csv")
+
+		mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(exportTodos)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		todo := &models.Todo{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), todo))
+		assert.Equal(t, "b", todo.Title)
+	})
+	t.Run("when return 200 ok (empty csv)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodGet, "/todo/export?format=csv", nil)
+		assert.NoError(t, err)
+
+		mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Export)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "id,title,description,status,priority,due_at,tags,completed_at,created_at,updated_at,version\n", rr.Body.String())
+	})
+}
+
+// TestTodoImport - testing import [415, 400, 200]
+func TestTodoImport(t *testing.T) {
+	t.Run("when return 415 unsupported media type", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/todo/import", strings.NewReader("<todo/>"))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/xml")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Import)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	})
+	t.Run("when return 400 bad request (error csv header)", func(t *testing.T) {
+		mockService := new(mockservice.Service)
+
+		req, err := http.NewRequest(http.MethodPost, "/todo/import", strings.NewReader("name,description\na,a\n"))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "text/csv")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Import)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything)
+	})
+	t.Run("when return 200 ok (csv)", func(t *testing.T) {
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		file := "\ufeffTitle,description,priority,tags,id\n" +
+			"a,a,1,work; home,ignored\n" +
+			",missing title,0,,\n" +
+			"c,c,high,,\n" +
+			"d,d\n" +
+			"\"e\nmultiline\",e,3,,\n"
+		req, err := http.NewRequest(http.MethodPost, "/todo/import", strings.NewReader(file))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+		mockService.On("CreateMany", mock.Anything, mock.MatchedBy(func(values []*models.Todo) bool {
+			return len(values) == 2 && values[0].Title == "a" && values[0].Priority == 1 &&
+				assert.ObjectsAreEqual([]string{"work", "home"}, values[0].Tags) && values[1].Title == "e\nmultiline"
+		})).Return(func(ctx context.Context, values []*models.Todo) []*models.BatchResult {
+			return []*models.BatchResult{
+				{ID: "1", Todo: values[0]},
+				{ID: "2", Todo: values[1]},
+			}
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Import)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		result := importResponse(t, rr)
+		assert.Equal(t, 5, result.Data.Total)
+		assert.Equal(t, 2, result.Data.Imported)
+		assert.Equal(t, 3, result.Data.Failed)
+		require.Len(t, result.Data.Errors, 3)
+		assert.Equal(t, 3, result.Data.Errors[0].Line)
+		assert.Equal(t, "title is required", result.Data.Errors[0].Message)
+		assert.Equal(t, 4, result.Data.Errors[1].Line)
+		assert.Equal(t, "priority is number only", result.Data.Errors[1].Message)
+		assert.Equal(t, 5, result.Data.Errors[2].Line)
+		assert.Equal(t, http.StatusBadRequest, result.Data.Errors[2].Code)
+	})
+	t.Run("when return 200 ok (json lines upload in batches)", func(t *testing.T) {
+		t.Setenv("BATCH_MAX_SIZE", "2")
+		pkgvalidator.New()
+		mockService := new(mockservice.Service)
+
+		file := `{"title": "a", "description": "a", "id": "exported"}` + "\n" +
+			"\n" +
+			`{"title": "b", "description": "b", "priority": "high"}` + "\n" +
+			`{"title": "c", "description": "c", "due_at": "2022-11-30T00:00:00Z"}` + "\n" +
+			`not json` + "\n" +
+			`{"title": "e", "description": "e"}`
+		body, contentType := multipartFile(t, "todo.jsonl", file)
+		req, err := http.NewRequest(http.MethodPost, "/todo/import", body)
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+
+		mockService.On("CreateMany", mock.Anything, mock.Anything).Return(func(ctx context.Context, values []*models.Todo) []*models.BatchResult {
+			results := []*models.BatchResult{}
+			for _, value := range values {
+				results = append(results, &models.BatchResult{Todo: value})
+			}
+			if values[0].Title == "e" {
+				results[0].Error = errorsutil.ErrDefault
+			}
+
+			return results
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(tododelivery.New(mockService).Import)
+		handler.ServeHTTP(rr, req)
+
+		// Check the status code is what expected
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		result := importResponse(t, rr)
+		assert.Equal(t, 5, result.Data.Total)
+		assert.Equal(t, 2, result.Data.Imported)
+		require.Len(t, result.Data.Errors, 3)
+		assert.Equal(t, 3, result.Data.Errors[0].Line)
+		assert.Equal(t, 5, result.Data.Errors[1].Line)
+		assert.Equal(t, 6, result.Data.Errors[2].Line)
+		assert.Equal(t, http.StatusInternalServerError, result.Data.Errors[2].Code)
+		mockService.AssertNumberOfCalls(t, "CreateMany", 3)
+	})
+}
+
+// importResult - body of an import response
+type importResult struct {
+	Data struct {
+		Total    int `json:"total"`
+		Imported int `json:"imported"`
+		Failed   int `json:"failed"`
+		Errors   []struct {
+			Line    int    `json:"line"`
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"data"`
+}
+
+func importResponse(t *testing.T, rr *httptest.ResponseRecorder) *importResult {
+	result := &importResult{}
+	err := json.Unmarshal(rr.Body.Bytes(), result)
+	assert.NoError(t, err)
+
+	return result
+}
+
+// multipartFile - multipart form uploading content as the file field
+func multipartFile(t *testing.T, filename string, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return body, writer.FormDataContentType()
+}
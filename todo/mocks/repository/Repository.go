@@ -3,9 +3,12 @@
 package mocks
 
 import (
+	context "context"
 	models "go-clean-grpc/todo/models/http"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -13,20 +16,43 @@ type Repository struct {
 	mock.Mock
 }
 
-// CountFindAll provides a mock function with given fields: keyword
-func (_m *Repository) CountFindAll(keyword string) (int, error) {
-	ret := _m.Called(keyword)
+// AddTags provides a mock function with given fields: ctx, id, tags
+func (_m *Repository) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, tags)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *models.Todo); ok {
+		r0 = rf(ctx, id, tags)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, id, tags)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountFindAll provides a mock function with given fields: ctx, filter
+func (_m *Repository) CountFindAll(ctx context.Context, filter *models.TodoFilter) (int, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(keyword)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter) int); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(keyword)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,20 +60,43 @@ func (_m *Repository) CountFindAll(keyword string) (int, error) {
 	return r0, r1
 }
 
-// CountFindByID provides a mock function with given fields: id
-func (_m *Repository) CountFindByID(id string) (int, error) {
-	ret := _m.Called(id)
+// CountFindByID provides a mock function with given fields: ctx, id
+func (_m *Repository) CountFindByID(ctx context.Context, id string) (int, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountTags provides a mock function with given fields: ctx, filter
+func (_m *Repository) CountTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*models.TagCount
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter) []*models.TagCount); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.TagCount)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,13 +104,13 @@ func (_m *Repository) CountFindByID(id string) (int, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Repository) Delete(id string) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Repository) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -69,13 +118,59 @@ func (_m *Repository) Delete(id string) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields: keyword, limit, offset
-func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.Todo, error) {
-	ret := _m.Called(keyword, limit, offset)
+// DeleteMany provides a mock function with given fields: ctx, ids
+func (_m *Repository) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*models.BatchResult); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAll provides a mock function with given fields: ctx, filter, limit, offset
+func (_m *Repository) FindAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, error) {
+	ret := _m.Called(ctx, filter, limit, offset)
+
+	var r0 []*models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter, int, int) []*models.Todo); ok {
+		r0 = rf(ctx, filter, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter, int, int) error); ok {
+		r1 = rf(ctx, filter, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByIDs provides a mock function with given fields: ctx, ids
+func (_m *Repository) FindByIDs(ctx context.Context, ids []string) ([]*models.Todo, error) {
+	ret := _m.Called(ctx, ids)
 
 	var r0 []*models.Todo
-	if rf, ok := ret.Get(0).(func(string, int, int) []*models.Todo); ok {
-		r0 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*models.Todo); ok {
+		r0 = rf(ctx, ids)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Todo)
@@ -83,8 +178,114 @@ func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
-		r1 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindById provides a mock function with given fields: ctx, id
+func (_m *Repository) FindById(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, id, patch
+func (_m *Repository) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.TodoPatch) *models.Todo); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.TodoPatch) error); ok {
+		r1 = rf(ctx, id, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (_m *Repository) Purge(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *Repository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []string); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveTags provides a mock function with given fields: ctx, id, tags
+func (_m *Repository) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, tags)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *models.Todo); ok {
+		r0 = rf(ctx, id, tags)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, id, tags)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,13 +293,13 @@ func (_m *Repository) FindAll(keyword string, limit int, offset int) ([]*models.
 	return r0, r1
 }
 
-// FindById provides a mock function with given fields: id
-func (_m *Repository) FindById(id string) (*models.Todo, error) {
-	ret := _m.Called(id)
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Repository) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string) *models.Todo); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -106,8 +307,8 @@ func (_m *Repository) FindById(id string) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -115,13 +316,13 @@ func (_m *Repository) FindById(id string) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Store provides a mock function with given fields: value
-func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(value)
+// Store provides a mock function with given fields: ctx, value
+func (_m *Repository) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, value)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(*models.Todo) *models.Todo); ok {
-		r0 = rf(value)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -129,8 +330,77 @@ func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Todo) error); ok {
-		r1 = rf(value)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Todo) error); ok {
+		r1 = rf(ctx, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StoreMany provides a mock function with given fields: ctx, values
+func (_m *Repository) StoreMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, values)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.Todo) []*models.BatchResult); ok {
+		r0 = rf(ctx, values)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*models.Todo) error); ok {
+		r1 = rf(ctx, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, id, value
+func (_m *Repository) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, value)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, id, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.Todo) error); ok {
+		r1 = rf(ctx, id, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMany provides a mock function with given fields: ctx, values
+func (_m *Repository) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, values)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.Todo) []*models.BatchResult); ok {
+		r0 = rf(ctx, values)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*models.Todo) error); ok {
+		r1 = rf(ctx, values)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,13 +408,13 @@ func (_m *Repository) Store(value *models.Todo) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, value
-func (_m *Repository) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(id, value)
+// UpdateStatus provides a mock function with given fields: ctx, id, status, completedAt, version
+func (_m *Repository) UpdateStatus(ctx context.Context, id string, status string, completedAt *time.Time, version int64) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, status, completedAt, version)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string, *models.Todo) *models.Todo); ok {
-		r0 = rf(id, value)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *time.Time, int64) *models.Todo); ok {
+		r0 = rf(ctx, id, status, completedAt, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -152,8 +422,8 @@ func (_m *Repository) Update(id string, value *models.Todo) (*models.Todo, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *models.Todo) error); ok {
-		r1 = rf(id, value)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *time.Time, int64) error); ok {
+		r1 = rf(ctx, id, status, completedAt, version)
 	} else {
 		r1 = ret.Error(1)
 	}
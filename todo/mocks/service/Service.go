@@ -3,9 +3,12 @@
 package mocks
 
 import (
+	context "context"
 	models "go-clean-grpc/todo/models/http"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -13,13 +16,59 @@ type Service struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: value
-func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(value)
+// AddTags provides a mock function with given fields: ctx, id, tags
+func (_m *Service) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, tags)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *models.Todo); ok {
+		r0 = rf(ctx, id, tags)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, id, tags)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: ctx, id
+func (_m *Service) Complete(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, value
+func (_m *Service) Create(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, value)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(*models.Todo) *models.Todo); ok {
-		r0 = rf(value)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -27,8 +76,68 @@ func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Todo) error); ok {
-		r1 = rf(value)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Todo) error); ok {
+		r1 = rf(ctx, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMany provides a mock function with given fields: ctx, values
+func (_m *Service) CreateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, values)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.Todo) []*models.BatchResult); ok {
+		r0 = rf(ctx, values)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*models.Todo) error); ok {
+		r1 = rf(ctx, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Service) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMany provides a mock function with given fields: ctx, ids
+func (_m *Service) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*models.BatchResult); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,13 +145,13 @@ func (_m *Service) Create(value *models.Todo) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Service) Delete(id string) error {
-	ret := _m.Called(id)
+// Export provides a mock function with given fields: ctx, filter, send
+func (_m *Service) Export(ctx context.Context, filter *models.TodoFilter, send func(*models.Todo) error) error {
+	ret := _m.Called(ctx, filter, send)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter, func(*models.Todo) error) error); ok {
+		r0 = rf(ctx, filter, send)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,13 +159,13 @@ func (_m *Service) Delete(id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: keyword, limit, offset
-func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error) {
-	ret := _m.Called(keyword, limit, offset)
+// GetAll provides a mock function with given fields: ctx, filter, limit, offset
+func (_m *Service) GetAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error) {
+	ret := _m.Called(ctx, filter, limit, offset)
 
 	var r0 []*models.Todo
-	if rf, ok := ret.Get(0).(func(string, int, int) []*models.Todo); ok {
-		r0 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter, int, int) []*models.Todo); ok {
+		r0 = rf(ctx, filter, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Todo)
@@ -64,15 +173,158 @@ func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo
 	}
 
 	var r1 int
-	if rf, ok := ret.Get(1).(func(string, int, int) int); ok {
-		r1 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter, int, int) int); ok {
+		r1 = rf(ctx, filter, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 string
+	if rf, ok := ret.Get(2).(func(context.Context, *models.TodoFilter, int, int) string); ok {
+		r2 = rf(ctx, filter, limit, offset)
+	} else {
+		r2 = ret.Get(2).(string)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func(context.Context, *models.TodoFilter, int, int) error); ok {
+		r3 = rf(ctx, filter, limit, offset)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *Service) GetByID(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTagCounts provides a mock function with given fields: ctx, filter
+func (_m *Service) GetTagCounts(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*models.TagCount
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter) []*models.TagCount); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.TagCount)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTrash provides a mock function with given fields: ctx, filter, limit, offset
+func (_m *Service) GetTrash(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error) {
+	ret := _m.Called(ctx, filter, limit, offset)
+
+	var r0 []*models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter, int, int) []*models.Todo); ok {
+		r0 = rf(ctx, filter, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Todo)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *models.TodoFilter, int, int) int); ok {
+		r1 = rf(ctx, filter, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 string
+	if rf, ok := ret.Get(2).(func(context.Context, *models.TodoFilter, int, int) string); ok {
+		r2 = rf(ctx, filter, limit, offset)
+	} else {
+		r2 = ret.Get(2).(string)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func(context.Context, *models.TodoFilter, int, int) error); ok {
+		r3 = rf(ctx, filter, limit, offset)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// Grant provides a mock function with given fields: ctx, id, value
+func (_m *Service) Grant(ctx context.Context, id string, value *models.Permission) (*models.Permission, error) {
+	ret := _m.Called(ctx, id, value)
+
+	var r0 *models.Permission
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.Permission) *models.Permission); ok {
+		r0 = rf(ctx, id, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Permission)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.Permission) error); ok {
+		r1 = rf(ctx, id, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHistory provides a mock function with given fields: ctx, id, limit, offset
+func (_m *Service) ListHistory(ctx context.Context, id string, limit int, offset int) ([]*models.Revision, int, error) {
+	ret := _m.Called(ctx, id, limit, offset)
+
+	var r0 []*models.Revision
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Revision); ok {
+		r0 = rf(ctx, id, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Revision)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = rf(ctx, id, limit, offset)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, int, int) error); ok {
-		r2 = rf(keyword, limit, offset)
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, id, limit, offset)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -80,13 +332,140 @@ func (_m *Service) GetAll(keyword string, limit int, offset int) ([]*models.Todo
 	return r0, r1, r2
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *Service) GetByID(id string) (*models.Todo, error) {
-	ret := _m.Called(id)
+// ListPermissions provides a mock function with given fields: ctx, id
+func (_m *Service) ListPermissions(ctx context.Context, id string) ([]*models.Permission, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*models.Permission
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.Permission); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Permission)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, id, patch
+func (_m *Service) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.TodoPatch) *models.Todo); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.TodoPatch) error); ok {
+		r1 = rf(ctx, id, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (_m *Service) Purge(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeExpired provides a mock function with given fields: ctx, retention
+func (_m *Service) PurgeExpired(ctx context.Context, retention time.Duration) (int, error) {
+	ret := _m.Called(ctx, retention)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int); ok {
+		r0 = rf(ctx, retention)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, retention)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveTags provides a mock function with given fields: ctx, id, tags
+func (_m *Service) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, tags)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *models.Todo); ok {
+		r0 = rf(ctx, id, tags)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, id, tags)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reopen provides a mock function with given fields: ctx, id
+func (_m *Service) Reopen(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Service) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string) *models.Todo); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Todo); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -94,8 +473,8 @@ func (_m *Service) GetByID(id string) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -103,13 +482,13 @@ func (_m *Service) GetByID(id string) (*models.Todo, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, value
-func (_m *Service) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ret := _m.Called(id, value)
+// Revert provides a mock function with given fields: ctx, id, revisionID, version
+func (_m *Service) Revert(ctx context.Context, id string, revisionID string, version int64) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, revisionID, version)
 
 	var r0 *models.Todo
-	if rf, ok := ret.Get(0).(func(string, *models.Todo) *models.Todo); ok {
-		r0 = rf(id, value)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) *models.Todo); ok {
+		r0 = rf(ctx, id, revisionID, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Todo)
@@ -117,11 +496,85 @@ func (_m *Service) Update(id string, value *models.Todo) (*models.Todo, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *models.Todo) error); ok {
-		r1 = rf(id, value)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, id, revisionID, version)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// Revoke provides a mock function with given fields: ctx, id, permissionID
+func (_m *Service) Revoke(ctx context.Context, id string, permissionID string) error {
+	ret := _m.Called(ctx, id, permissionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, permissionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, value
+func (_m *Service) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	ret := _m.Called(ctx, id, value)
+
+	var r0 *models.Todo
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.Todo) *models.Todo); ok {
+		r0 = rf(ctx, id, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Todo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *models.Todo) error); ok {
+		r1 = rf(ctx, id, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMany provides a mock function with given fields: ctx, values
+func (_m *Service) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ret := _m.Called(ctx, values)
+
+	var r0 []*models.BatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.Todo) []*models.BatchResult); ok {
+		r0 = rf(ctx, values)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*models.Todo) error); ok {
+		r1 = rf(ctx, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Watch provides a mock function with given fields: ctx, filter, resumeToken, send
+func (_m *Service) Watch(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(*models.TodoEvent) error) error {
+	ret := _m.Called(ctx, filter, resumeToken, send)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.TodoFilter, string, func(*models.TodoEvent) error) error); ok {
+		r0 = rf(ctx, filter, resumeToken, send)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
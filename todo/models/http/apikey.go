@@ -0,0 +1,39 @@
+package models
+
+import (
+	"net/http"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// APIKeyRequest - create API key request, the key acts as user_id (the caller when empty) with the scopes
+type APIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=255"`
+	UserID    string   `json:"user_id" validate:"max=255"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,oneof=todo:read todo:write"`
+	ExpiresAt string   `json:"expires_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+func (kr *APIKeyRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(kr)
+}
+
+// APIKey - API key created by the request
+func (kr *APIKeyRequest) APIKey() *pkgauth.APIKey {
+	expiresAt, _ := timeutil.ParseTime(kr.ExpiresAt)
+
+	return &pkgauth.APIKey{
+		Name:      kr.Name,
+		UserID:    kr.UserID,
+		Scopes:    kr.Scopes,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// CreatedAPIKey - API key with the key itself, the key is only returned when it is created
+type CreatedAPIKey struct {
+	*pkgauth.APIKey
+	Key string `json:"key"`
+}
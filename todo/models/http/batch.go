@@ -0,0 +1,106 @@
+package models
+
+import (
+	"net/http"
+
+	pkgvalidator "go-clean-grpc/pkg/validator"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+// BatchResult - result of one item of a batch operation, Error is nil when the item succeeded
+type BatchResult struct {
+	ID    string
+	Todo  *Todo // todo after the change, nil when it failed or was deleted
+	Error error
+}
+
+// Batch - items of a batch request, invalid items fail before the valid ones are sent to the service
+type Batch struct {
+	Results []*BatchResult // results in the order of the request
+	Todos   []*Todo        // valid items
+	indexes []int          // index in Results of each valid item
+}
+
+// Add - add valid item
+func (b *Batch) Add(todo *Todo) {
+	b.indexes = append(b.indexes, len(b.Results))
+	b.Todos = append(b.Todos, todo)
+	b.Results = append(b.Results, &BatchResult{ID: todo.ID})
+}
+
+// Invalid - add item failing validation, it fails with the field messages of the validator error
+func (b *Batch) Invalid(id string, err error) {
+	b.Failed(id, errorsutil.Wrap(errorsutil.KindInvalidArgument, pkgvalidator.Message(err), err))
+}
+
+// Failed - add item failing with err before the batch is run
+func (b *Batch) Failed(id string, err error) {
+	b.Results = append(b.Results, &BatchResult{ID: id, Error: err})
+}
+
+// IDs - ids of the valid items
+func (b *Batch) IDs() []string {
+	results := make([]string, 0, len(b.Todos))
+	for _, todo := range b.Todos {
+		results = append(results, todo.ID)
+	}
+
+	return results
+}
+
+// Merge - set the results of the valid items, given in the order of Todos
+func (b *Batch) Merge(results []*BatchResult) {
+	for i, result := range results {
+		b.Results[b.indexes[i]] = result
+	}
+}
+
+// Fail - fail the valid items with err, when the batch could not be run
+func (b *Batch) Fail(err error) {
+	for _, i := range b.indexes {
+		b.Results[i].Error = err
+	}
+}
+
+// TodoBatchCreateRequest - batch create request, items are validated one by one
+type TodoBatchCreateRequest struct {
+	Items []*TodoRequest `json:"items" validate:"required,min=1"`
+}
+
+func (tr *TodoBatchCreateRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(tr)
+}
+
+// TodoBatchUpdateItem - item of batch update request, replacing the todo like TodoRequest
+type TodoBatchUpdateItem struct {
+	ID      string `json:"id" validate:"required"`
+	Version int64  `json:"version" validate:"gte=0"` // expected current version, 0 skips the check
+	TodoRequest
+}
+
+// Todo - make todo from validated item
+func (ti *TodoBatchUpdateItem) Todo() *Todo {
+	todo := ti.TodoRequest.Todo()
+	todo.ID = ti.ID
+	todo.Version = ti.Version
+
+	return todo
+}
+
+// TodoBatchUpdateRequest - batch update request, items are validated one by one
+type TodoBatchUpdateRequest struct {
+	Items []*TodoBatchUpdateItem `json:"items" validate:"required,min=1"`
+}
+
+func (tr *TodoBatchUpdateRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(tr)
+}
+
+// TodoBatchDeleteRequest - batch delete request
+type TodoBatchDeleteRequest struct {
+	Ids []string `json:"ids" validate:"required,min=1,dive,required"`
+}
+
+func (tr *TodoBatchDeleteRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(tr)
+}
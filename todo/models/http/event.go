@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Todo event types
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// TodoEvent - change of a todo sent to watchers, Todo is nil for deleted todo
+// a restored todo is created again, purging a todo in the trash sends no event
+type TodoEvent struct {
+	Type        string    `json:"type"`
+	TodoID      string    `json:"todo_id"`
+	Todo        *Todo     `json:"todo,omitempty"`
+	ResumeToken string    `json:"resume_token"` // watch again after this token to receive the following events
+	Time        time.Time `json:"time"`
+	OwnerID     string    `json:"-"` // owner of the todo, watchers only receive the events of their todo
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	pkgvalidator "go-clean-grpc/pkg/validator"
+)
+
+// Roles granted on a todo, the owner of the todo can do everything
+const (
+	RoleViewer = "viewer" // read the todo, its history and permissions
+	RoleEditor = "editor" // viewer that can also change the todo
+)
+
+// Permission - role on a todo granted to a user or to a group, exactly one of UserID and Group is set
+type Permission struct {
+	ID        string    `json:"id" bson:"-"`
+	TodoID    string    `json:"todo_id" bson:"todoId"`
+	UserID    string    `json:"user_id,omitempty" bson:"userId"` // subject of the user
+	Group     string    `json:"group,omitempty" bson:"group"`    // group claim of the users
+	Role      string    `json:"role" bson:"role"`
+	GrantedBy string    `json:"granted_by" bson:"grantedBy"` // actor of the last grant
+	CreatedAt time.Time `json:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updatedAt"`
+}
+
+// PermissionRequest - grant permission request, exactly one of user_id and group
+type PermissionRequest struct {
+	UserID string `json:"user_id" validate:"required_without=Group,excluded_with=Group,max=255"`
+	Group  string `json:"group" validate:"required_without=UserID,max=255"`
+	Role   string `json:"role" validate:"required,oneof=viewer editor"`
+}
+
+func (pr *PermissionRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(pr)
+}
+
+// Permission - permission granted by the request
+func (pr *PermissionRequest) Permission() *Permission {
+	return &Permission{
+		UserID: pr.UserID,
+		Group:  pr.Group,
+		Role:   pr.Role,
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+)
+
+// Revision actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionStatus  = "status"
+	ActionTags    = "tags"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionRevert  = "revert"
+)
+
+// RevisionFields - todo fields compared by revisions, TodoSchema names
+var RevisionFields = []string{"title", "description", "status", "priority", "due_at", "tags", "completed_at", "deleted_at"}
+
+// Revision - immutable record of a todo change
+type Revision struct {
+	ID        string         `json:"id" bson:"-"`
+	TodoID    string         `json:"todo_id" bson:"todoId"`
+	OwnerID   string         `json:"owner_id" bson:"ownerId"` // owner of the todo, the history is only listed to the owner
+	Version   int64          `json:"version" bson:"version"`  // todo version after the change
+	Action    string         `json:"action" bson:"action"`
+	Actor     string         `json:"actor" bson:"actor"`
+	Transport string         `json:"transport" bson:"transport"`
+	Changes   []*FieldChange `json:"changes" bson:"changes"`
+	Todo      *Todo          `json:"todo,omitempty" bson:"todo,omitempty"`       // todo after the change, nil when it was deleted
+	Reverts   string         `json:"reverts,omitempty" bson:"reverts,omitempty"` // id of the revision a revert went back to
+	CreatedAt time.Time      `json:"created_at" bson:"createdAt"`
+}
+
+// FieldChange - value of a todo field before and after a change
+// values are strings, numbers, string lists or nil, times are RFC3339 strings
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// Diff - changed RevisionFields between two states of todo, nil is a todo that does not exist
+func Diff(before *Todo, after *Todo) []*FieldChange {
+	results := []*FieldChange{}
+	for _, name := range RevisionFields {
+		field, _ := TodoSchema.Lookup(name)
+
+		beforeValue := revisionValue(before, field.Key)
+		afterValue := revisionValue(after, field.Key)
+		if equalValue(beforeValue, afterValue) {
+			continue
+		}
+
+		results = append(results, &FieldChange{Field: name, Before: beforeValue, After: afterValue})
+	}
+
+	return results
+}
+
+func revisionValue(todo *Todo, key string) interface{} {
+	if todo == nil {
+		return nil
+	}
+
+	switch value := todo.Value(key).(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case []string:
+		return append([]string{}, value...)
+	default:
+		return value
+	}
+}
+
+func equalValue(a interface{}, b interface{}) bool {
+	aValues, aOk := a.([]string)
+	bValues, bOk := b.([]string)
+	if !aOk || !bOk {
+		return a == b
+	}
+
+	if len(aValues) != len(bValues) {
+		return false
+	}
+	for i := range aValues {
+		if aValues[i] != bValues[i] {
+			return false
+		}
+	}
+
+	return true
+}
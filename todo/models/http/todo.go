@@ -1,37 +1,368 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	pkgvalidator "go-clean-grpc/pkg/validator"
+	errorsutil "go-clean-grpc/utils/errors"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+	timeutil "go-clean-grpc/utils/time"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
+)
+
+// Todo status
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+// Todo priority
+const (
+	PriorityNone   = 0
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+)
+
+// TodoSchema - todo fields allowed in filter and sort specs
+var TodoSchema = queryutil.Schema{
+	{Name: "title", Key: "title", Type: queryutil.TypeString},
+	{Name: "description", Key: "description", Type: queryutil.TypeString},
+	{Name: "status", Key: "status", Type: queryutil.TypeString},
+	{Name: "priority", Key: "priority", Type: queryutil.TypeInt},
+	{Name: "tags", Key: "tags", Type: queryutil.TypeString},
+	{Name: "due_at", Key: "dueAt", Type: queryutil.TypeTime},
+	{Name: "completed_at", Key: "completedAt", Type: queryutil.TypeTime},
+	{Name: "created_at", Key: "createdAt", Type: queryutil.TypeTime},
+	{Name: "updated_at", Key: "updatedAt", Type: queryutil.TypeTime},
+	{Name: "deleted_at", Key: "deletedAt", Type: queryutil.TypeTime},
+}
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+// Todo list tag matching
+const (
+	TagsModeAny = "any"
+	TagsModeAll = "all"
 )
 
 // Todo - todo model
 type Todo struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Title       string             `json:"title" bson:"title"`
-	Description string             `json:"description" bson:"description"`
-	CreatedAt   time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updatedAt"`
+	ID          string     `json:"id" bson:"-"`
+	OwnerID     string     `json:"owner_id" bson:"ownerId"` // subject of the user who created the todo, empty without authentication
+	Title       string     `json:"title" bson:"title"`
+	Description string     `json:"description" bson:"description"`
+	Status      string     `json:"status" bson:"status"`
+	CompletedAt *time.Time `json:"completed_at" bson:"completedAt"`
+	Priority    int        `json:"priority" bson:"priority"`
+	DueAt       *time.Time `json:"due_at" bson:"dueAt"`
+	Tags        []string   `json:"tags" bson:"tags"`
+	CreatedAt   time.Time  `json:"created_at" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updatedAt"`
+	Version     int64      `json:"version" bson:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+	Score       float64    `json:"score,omitempty" bson:"score,omitempty"`
+}
+
+// Value - get value of todo by document key, unset dates are nil
+func (t *Todo) Value(key string) interface{} {
+	switch key {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "status":
+		return t.Status
+	case "priority":
+		return t.Priority
+	case "tags":
+		return t.Tags
+	case "dueAt":
+		return timeValue(t.DueAt)
+	case "completedAt":
+		return timeValue(t.CompletedAt)
+	case "createdAt":
+		return t.CreatedAt
+	case "updatedAt":
+		return t.UpdatedAt
+	case "deletedAt":
+		return timeValue(t.DeletedAt)
+	}
+
+	return nil
+}
+
+func timeValue(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
+
+// TodoFilter - filter for todo list
+type TodoFilter struct {
+	Keyword    string
+	Status     string
+	Overdue    bool
+	DueFrom    *time.Time
+	DueTo      *time.Time
+	Tags       []string
+	TagsMode   string
+	Conditions []queryutil.Condition
+	Search     string
+	Sort       []queryutil.SortField
+	PageToken  string
+	After      *paginationutil.Cursor // decoded page token, values are typed by SortFields
+	Deleted    bool                   // list the trash instead of the todo that are not deleted
+	OwnerID    string                 // only todo of the owner, all todo when empty
+	SharedIDs  []string               // todo shared with the owner, listed with the todo of OwnerID
+	IDs        []string               // only todo with these ids, all todo when nil
+}
+
+// SortFields - effective sort of the list, empty when sorted by search relevance
+// the trash is sorted latest deleted first, other lists latest updated first
+func (f *TodoFilter) SortFields() []queryutil.SortField {
+	if len(f.Sort) > 0 {
+		return f.Sort
+	}
+
+	if f.Search != "" {
+		return []queryutil.SortField{}
+	}
+
+	if f.Deleted {
+		field, _ := TodoSchema.Lookup("deleted_at")
+
+		return []queryutil.SortField{{Field: field, Desc: true}}
+	}
+
+	field, _ := TodoSchema.Lookup("updated_at")
+
+	return []queryutil.SortField{{Field: field, Desc: true}}
+}
+
+// TagCount - number of todo labelled with the tag
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
 }
 
 // TodoRequest - todo request
 type TodoRequest struct {
-	Title       string `form:"title" json:"title" validate:"required"`
-	Description string `form:"description" json:"description" validate:"required"`
+	Title       string   `form:"title" json:"title" validate:"required"`
+	Description string   `form:"description" json:"description" validate:"required"`
+	Status      string   `form:"status" json:"status" validate:"omitempty,oneof=pending in_progress done cancelled"`
+	Priority    int      `form:"priority" json:"priority" validate:"gte=0,lte=3"`
+	DueAt       string   `form:"due_at" json:"due_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Tags        []string `form:"tags" json:"tags" validate:"max=20,dive,required,max=50"`
 }
 
 func (tr *TodoRequest) Bind(r *http.Request) error {
 	return pkgvalidator.ValidateStruct(tr)
 }
 
+// Todo - make todo from validated request
+func (tr *TodoRequest) Todo() *Todo {
+	dueAt, _ := timeutil.ParseTime(tr.DueAt)
+
+	return &Todo{
+		Title:       tr.Title,
+		Description: tr.Description,
+		Status:      tr.Status,
+		Priority:    tr.Priority,
+		DueAt:       dueAt,
+		Tags:        tr.Tags,
+	}
+}
+
+// TodoPatchFields - todo fields that can be partially updated
+var TodoPatchFields = []string{"title", "description", "status", "priority", "due_at", "tags"}
+
+// TodoPatch - partial update of todo, only the listed fields are changed
+type TodoPatch struct {
+	Fields  []string // TodoSchema names of the changed fields
+	Todo    *Todo
+	Version int64 // expected current version, 0 skips the check
+}
+
+// Has - check whether the field is changed by the patch
+func (p *TodoPatch) Has(field string) bool {
+	for _, item := range p.Fields {
+		if item == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TodoPatchRequest - partial todo request, fields not listed in Fields are left untouched
+type TodoPatchRequest struct {
+	Title       *string   `json:"title" validate:"omitempty,min=1"`
+	Description *string   `json:"description" validate:"omitempty,min=1"`
+	Status      *string   `json:"status" validate:"omitempty,oneof=pending in_progress done cancelled"`
+	Priority    *int      `json:"priority" validate:"omitempty,gte=0,lte=3"`
+	DueAt       string    `json:"due_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Tags        *[]string `json:"tags" validate:"omitempty,max=20,dive,required,max=50"`
+	Fields      []string  `json:"-"`
+}
+
+// DecodeTodoPatchRequest - decode JSON Merge Patch (RFC 7396) body, null resets the field
+func DecodeTodoPatchRequest(body io.Reader) (*TodoPatchRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, io.EOF
+	}
+
+	values := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errorsutil.Wrap(errorsutil.KindInvalidArgument, "body must be a JSON object", err)
+	}
+
+	request := &TodoPatchRequest{}
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		return nil, errorsutil.Wrap(errorsutil.KindInvalidArgument, "body has invalid field value", err)
+	}
+
+	for _, field := range TodoPatchFields {
+		if _, ok := values[field]; ok {
+			request.Fields = append(request.Fields, field)
+			delete(values, field)
+		}
+	}
+
+	if len(values) > 0 {
+		unknown := make([]string, 0, len(values))
+		for field := range values {
+			unknown = append(unknown, field)
+		}
+		sort.Strings(unknown)
+
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf("field %s can not be updated", strings.Join(unknown, ", ")))
+	}
+
+	request.resetNull()
+
+	return request, nil
+}
+
+// resetNull - listed fields without value are reset to zero value, which is validated as usual
+func (tr *TodoPatchRequest) resetNull() {
+	empty := ""
+	for _, field := range tr.Fields {
+		switch {
+		case field == "title" && tr.Title == nil:
+			tr.Title = &empty
+		case field == "description" && tr.Description == nil:
+			tr.Description = &empty
+		case field == "status" && tr.Status == nil:
+			tr.Status = &empty
+		case field == "priority" && tr.Priority == nil:
+			tr.Priority = new(int)
+		case field == "tags" && tr.Tags == nil:
+			tr.Tags = &[]string{}
+		}
+	}
+}
+
+// TodoPatch - make todo patch from validated request
+func (tr *TodoPatchRequest) TodoPatch() *TodoPatch {
+	tr.resetNull()
+
+	todo := &Todo{}
+	if tr.Title != nil {
+		todo.Title = *tr.Title
+	}
+	if tr.Description != nil {
+		todo.Description = *tr.Description
+	}
+	if tr.Status != nil {
+		todo.Status = *tr.Status
+	}
+	if tr.Priority != nil {
+		todo.Priority = *tr.Priority
+	}
+	if tr.Tags != nil {
+		todo.Tags = *tr.Tags
+	}
+	todo.DueAt, _ = timeutil.ParseTime(tr.DueAt)
+
+	return &TodoPatch{
+		Fields: tr.Fields,
+		Todo:   todo,
+	}
+}
+
+// TodoTagsRequest - todo tags request
+type TodoTagsRequest struct {
+	Tags []string `form:"tags" json:"tags" validate:"required,min=1,max=20,dive,required,max=50"`
+}
+
+func (tr *TodoTagsRequest) Bind(r *http.Request) error {
+	return pkgvalidator.ValidateStruct(tr)
+}
+
 // TodoListRequest - form for list validation
 type TodoListRequest struct {
-	Keywords *SearchForm
-	Page     string `form:"page" json:"page" validate:"sgte=1"`
-	PerPage  string `form:"per_page" json:"per_page" validate:"sgte=1,slte=100"`
+	Keywords  *SearchForm
+	Status    string   `form:"status" json:"status" validate:"omitempty,oneof=pending in_progress done cancelled"`
+	Overdue   string   `form:"overdue" json:"overdue" validate:"omitempty,oneof=true false"`
+	DueFrom   string   `form:"due_from" json:"due_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	DueTo     string   `form:"due_to" json:"due_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Tags      []string `form:"tags" json:"tags" validate:"max=20,dive,max=50"`
+	TagsMode  string   `form:"tags_mode" json:"tags_mode" validate:"omitempty,oneof=any all"`
+	Filter    string   `form:"filter" json:"filter" validate:"max=1000"`
+	Search    string   `form:"search" json:"search" validate:"max=255"`
+	Sort      string   `form:"sort" json:"sort" validate:"max=255"`
+	Page      string   `form:"page" json:"page" validate:"sgte=1"`
+	PerPage   string   `form:"per_page" json:"per_page" validate:"sgte=1,slte=100"`
+	PageToken string   `form:"page_token" json:"page_token" validate:"max=1024"`
+}
+
+// TodoFilter - make todo filter from validated list request, filter and sort specs are parsed with TodoSchema
+func (tr *TodoListRequest) TodoFilter() (*TodoFilter, error) {
+	dueFrom, _ := timeutil.ParseTime(tr.DueFrom)
+	dueTo, _ := timeutil.ParseTime(tr.DueTo)
+
+	conditions, err := queryutil.ParseFilter(TodoSchema, tr.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := queryutil.ParseSort(TodoSchema, tr.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &TodoFilter{
+		Status:     tr.Status,
+		Overdue:    tr.Overdue == "true",
+		DueFrom:    dueFrom,
+		DueTo:      dueTo,
+		Tags:       tr.Tags,
+		TagsMode:   tr.TagsMode,
+		Conditions: conditions,
+		Search:     tr.Search,
+		Sort:       sort,
+		PageToken:  tr.PageToken,
+	}
+	if tr.Keywords != nil {
+		filter.Keyword = tr.Keywords.Keywords
+	}
+
+	return filter, nil
 }
 
 // SearchForm - search list struct
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	queryutil "go-clean-grpc/utils/query"
+)
+
+// Title and description weights of the todo_text index
+const (
+	TitleWeight       = 3
+	DescriptionWeight = 1
+)
+
+// MatchOwner - check whether the todo by id of owner is of the owner of the filter or shared with them
+func (filter *TodoFilter) MatchOwner(owner string, id string) bool {
+	return filter.OwnerID == "" || owner == filter.OwnerID || contains(filter.SharedIDs, id)
+}
+
+// Match - check whether todo matches the filter, mirrors buildFilter of the mongo repository
+func (filter *TodoFilter) Match(todo *Todo, now time.Time) bool {
+	// deleted todo are only listed in the trash
+	if (todo.DeletedAt != nil) != filter.Deleted {
+		return false
+	}
+
+	if !filter.MatchOwner(todo.OwnerID, todo.ID) {
+		return false
+	}
+
+	if filter.IDs != nil && !contains(filter.IDs, todo.ID) {
+		return false
+	}
+
+	if filter.Keyword != "" && !containsFold(todo.Title, filter.Keyword) {
+		return false
+	}
+
+	if filter.Search != "" && todo.TextScore(filter.Search) == 0 {
+		return false
+	}
+
+	if filter.Status != "" && todo.Status != filter.Status {
+		return false
+	}
+
+	if filter.Overdue {
+		if todo.DueAt == nil || !todo.DueAt.Before(now) {
+			return false
+		}
+		if todo.Status == StatusDone || todo.Status == StatusCancelled {
+			return false
+		}
+	}
+
+	if filter.DueFrom != nil && (todo.DueAt == nil || todo.DueAt.Before(*filter.DueFrom)) {
+		return false
+	}
+
+	if filter.DueTo != nil && (todo.DueAt == nil || todo.DueAt.After(*filter.DueTo)) {
+		return false
+	}
+
+	if len(filter.Tags) > 0 {
+		matched := 0
+		for _, tag := range filter.Tags {
+			if contains(todo.Tags, tag) {
+				matched++
+			}
+		}
+
+		if matched == 0 || (filter.TagsMode == TagsModeAll && matched < len(filter.Tags)) {
+			return false
+		}
+	}
+
+	for _, condition := range filter.Conditions {
+		if !matchCondition(todo, condition) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchCondition - check filter spec condition, array fields match when any element matches
+func matchCondition(todo *Todo, condition queryutil.Condition) bool {
+	elements := elementsOf(todo.Value(condition.Field.Key))
+
+	if condition.Operator == queryutil.OpNe {
+		for _, element := range elements {
+			if CompareValues(element, condition.Values[0]) == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, element := range elements {
+		if element == nil {
+			continue
+		}
+
+		for _, value := range condition.Values {
+			if matchOperator(condition.Operator, element, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchOperator(operator string, element interface{}, value interface{}) bool {
+	if operator == queryutil.OpContains {
+		text, ok := element.(string)
+		return ok && containsFold(text, value.(string))
+	}
+
+	if !sameType(element, value) {
+		return false
+	}
+
+	result := CompareValues(element, value)
+	switch operator {
+	case queryutil.OpGt:
+		return result > 0
+	case queryutil.OpGte:
+		return result >= 0
+	case queryutil.OpLt:
+		return result < 0
+	case queryutil.OpLte:
+		return result <= 0
+	}
+
+	return result == 0
+}
+
+// elementsOf - elements of array value, a scalar is a single element
+func elementsOf(value interface{}) []interface{} {
+	values, ok := value.([]string)
+	if !ok {
+		return []interface{}{value}
+	}
+
+	results := make([]interface{}, 0, len(values))
+	for _, item := range values {
+		results = append(results, item)
+	}
+
+	return results
+}
+
+// CompareValues - compare todo values of the same type, nil sorts before any other value
+func CompareValues(a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch value := a.(type) {
+	case int:
+		other, _ := b.(int)
+		switch {
+		case value < other:
+			return -1
+		case value > other:
+			return 1
+		}
+	case time.Time:
+		other, _ := b.(time.Time)
+		switch {
+		case value.Before(other):
+			return -1
+		case value.After(other):
+			return 1
+		}
+	case string:
+		other, _ := b.(string)
+		return strings.Compare(value, other)
+	}
+
+	return 0
+}
+
+func sameType(a interface{}, b interface{}) bool {
+	switch a.(type) {
+	case int:
+		_, ok := b.(int)
+		return ok
+	case time.Time:
+		_, ok := b.(time.Time)
+		return ok
+	case string:
+		_, ok := b.(string)
+		return ok
+	}
+
+	return false
+}
+
+// TextScore - search relevance of todo, weighted like the todo_text index
+func (t *Todo) TextScore(search string) float64 {
+	return queryutil.TextScore(search,
+		queryutil.Text{Value: t.Title, Weight: TitleWeight},
+		queryutil.Text{Value: t.Description, Weight: DescriptionWeight},
+	)
+}
+
+func containsFold(value string, substr string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(substr))
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
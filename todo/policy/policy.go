@@ -0,0 +1,203 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+// Action - what is done on a todo, each role allows some of them
+type Action string
+
+const (
+	ActionRead   Action = "read"   // get the todo, its history and permissions
+	ActionWrite  Action = "write"  // change the todo, its status and tags or revert it
+	ActionDelete Action = "delete" // move the todo to the trash, restore or purge it
+	ActionShare  Action = "share"  // grant and revoke permissions on the todo
+)
+
+// RoleAdmin - token role of the users that can do everything on every todo
+const RoleAdmin = "admin"
+
+// Scopes of the API keys, JWTs are not limited to scopes
+const (
+	ScopeRead  = "todo:read"
+	ScopeWrite = "todo:write"
+)
+
+var ErrPermissionDenied error = errorsutil.New(errorsutil.KindPermissionDenied, "permission denied on todo")
+
+// actionScopes - scope an API key needs for each action
+var actionScopes = map[Action]string{
+	ActionRead:   ScopeRead,
+	ActionWrite:  ScopeWrite,
+	ActionDelete: ScopeWrite,
+	ActionShare:  ScopeWrite,
+}
+
+// roleActions - actions allowed by the roles granted on a todo, delete and share are left to the owner
+var roleActions = map[string][]Action{
+	models.RoleViewer: {ActionRead},
+	models.RoleEditor: {ActionRead, ActionWrite},
+}
+
+// roleRanks - the strongest role applies when a user is granted several on a todo
+var roleRanks = map[string]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+}
+
+// Policy - decide what the user of a context can do on todo
+type Policy interface {
+	Allow(ctx context.Context, action Action) error
+	Authorize(ctx context.Context, action Action, todo *models.Todo) error
+	Grants(ctx context.Context) (*Grants, error)
+	Scope(ctx context.Context, filter *models.TodoFilter) error
+	Unrestricted(ctx context.Context) bool
+}
+
+type PolicyImpl struct {
+	permissions todorepository.PermissionRepository
+}
+
+// New will create new an PolicyImpl object representation of Policy interface
+func New(permissions todorepository.PermissionRepository) Policy {
+	return &PolicyImpl{
+		permissions: permissions,
+	}
+}
+
+// Allow - fail unless the scopes of the API key of ctx allow action on any todo
+func (p *PolicyImpl) Allow(ctx context.Context, action Action) error {
+	claims, ok := pkgauth.FromContext(ctx)
+	if !ok || claims.HasScope(actionScopes[action]) {
+		return nil
+	}
+
+	return errorsutil.New(errorsutil.KindPermissionDenied, fmt.Sprintf("api key is missing scope %s", actionScopes[action]))
+}
+
+// Authorize - fail unless the user of ctx can do action on todo
+// todo that are neither owned nor shared are not found, the roles that do not allow action are denied
+func (p *PolicyImpl) Authorize(ctx context.Context, action Action, todo *models.Todo) error {
+	err := p.Allow(ctx, action)
+	if err != nil {
+		return err
+	}
+
+	if p.Unrestricted(ctx) || todo.OwnerID == pkgauth.UserID(ctx) {
+		return nil
+	}
+
+	grants, err := p.Grants(ctx)
+	if err != nil {
+		return err
+	}
+
+	return grants.Authorize(action, todo)
+}
+
+// Grants - roles granted to the user of ctx and to their groups, read once for many todo
+func (p *PolicyImpl) Grants(ctx context.Context) (*Grants, error) {
+	claims, ok := pkgauth.FromContext(ctx)
+	if !ok || claims.HasRole(RoleAdmin) {
+		return &Grants{unrestricted: true}, nil
+	}
+
+	permissions, err := p.permissions.FindGranted(ctx, claims.Subject, claims.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	grants := &Grants{userID: claims.Subject, roles: map[string]string{}}
+	for _, permission := range permissions {
+		current, ok := grants.roles[permission.TodoID]
+		if !ok {
+			grants.todoIDs = append(grants.todoIDs, permission.TodoID)
+		}
+		if roleRanks[permission.Role] > roleRanks[current] {
+			grants.roles[permission.TodoID] = permission.Role
+		}
+	}
+
+	return grants, nil
+}
+
+// Scope - restrict filter to the todo the user of ctx can read, the owner of the filter can not be chosen by the caller
+// the trash is only listed to the owners
+func (p *PolicyImpl) Scope(ctx context.Context, filter *models.TodoFilter) error {
+	filter.OwnerID = ""
+	filter.SharedIDs = nil
+
+	err := p.Allow(ctx, ActionRead)
+	if err != nil {
+		return err
+	}
+
+	if p.Unrestricted(ctx) {
+		return nil
+	}
+
+	filter.OwnerID = pkgauth.UserID(ctx)
+	if filter.Deleted {
+		return nil
+	}
+
+	grants, err := p.Grants(ctx)
+	if err != nil {
+		return err
+	}
+	filter.SharedIDs = grants.TodoIDs()
+
+	return nil
+}
+
+// Unrestricted - whether the user of ctx can do everything, true when the request was not authenticated
+func (p *PolicyImpl) Unrestricted(ctx context.Context) bool {
+	return Admin(ctx)
+}
+
+// Admin - whether the user of ctx has the admin role, true when the request was not authenticated
+// API keys have no roles, they are never admins
+func Admin(ctx context.Context) bool {
+	claims, ok := pkgauth.FromContext(ctx)
+
+	return !ok || claims.HasRole(RoleAdmin)
+}
+
+// Grants - strongest role of a user on each todo shared with them
+type Grants struct {
+	unrestricted bool
+	userID       string
+	roles        map[string]string
+	todoIDs      []string // in the order they were first granted
+}
+
+// Authorize - fail unless the user can do action on todo
+func (g *Grants) Authorize(action Action, todo *models.Todo) error {
+	if g.unrestricted || todo.OwnerID == g.userID {
+		return nil
+	}
+
+	role, ok := g.roles[todo.ID]
+	if !ok {
+		return errorsutil.ErrNotFound
+	}
+
+	for _, allowed := range roleActions[role] {
+		if allowed == action {
+			return nil
+		}
+	}
+
+	return ErrPermissionDenied
+}
+
+// TodoIDs - ids of the todo shared with the user
+func (g *Grants) TodoIDs() []string {
+	return append([]string{}, g.todoIDs...)
+}
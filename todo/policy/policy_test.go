@@ -0,0 +1,104 @@
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	mockrepository "go-clean-grpc/todo/mocks/repository"
+	models "go-clean-grpc/todo/models/http"
+	"go-clean-grpc/todo/policy"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+func TestAuthorize(t *testing.T) {
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice", Groups: []string{"team"}})
+
+	mockPermissions := new(mockrepository.PermissionRepository)
+	mockPermissions.On("FindGranted", mock.Anything, "alice", []string{"team"}).Return([]*models.Permission{
+		{TodoID: "a", UserID: "alice", Role: models.RoleViewer},
+		{TodoID: "b", UserID: "alice", Role: models.RoleEditor},
+		{TodoID: "a", Group: "team", Role: models.RoleEditor},
+		{TodoID: "b", Group: "team", Role: models.RoleViewer},
+	}, nil)
+	p := policy.New(mockPermissions)
+
+	for _, test := range []struct {
+		name   string
+		action policy.Action
+		todo   *models.Todo
+		err    error
+	}{
+		{"owner deletes", policy.ActionDelete, &models.Todo{ID: "c", OwnerID: "alice"}, nil},
+		{"owner shares", policy.ActionShare, &models.Todo{ID: "c", OwnerID: "alice"}, nil},
+		{"editor of group writes", policy.ActionWrite, &models.Todo{ID: "a", OwnerID: "bob"}, nil},
+		{"editor writes", policy.ActionWrite, &models.Todo{ID: "b", OwnerID: "bob"}, nil},
+		{"editor reads", policy.ActionRead, &models.Todo{ID: "b", OwnerID: "bob"}, nil},
+		{"editor deletes", policy.ActionDelete, &models.Todo{ID: "b", OwnerID: "bob"}, policy.ErrPermissionDenied},
+		{"editor shares", policy.ActionShare, &models.Todo{ID: "b", OwnerID: "bob"}, policy.ErrPermissionDenied},
+		{"not shared", policy.ActionRead, &models.Todo{ID: "c", OwnerID: "bob"}, errorsutil.ErrNotFound},
+	} {
+		err := p.Authorize(ctx, test.action, test.todo)
+		assert.Equal(t, test.err, err, test.name)
+	}
+
+	// unauthenticated requests and admins are not restricted
+	admin := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "carol", Roles: []string{policy.RoleAdmin}})
+	for _, ctx := range []context.Context{context.Background(), admin} {
+		assert.True(t, p.Unrestricted(ctx))
+		assert.NoError(t, p.Authorize(ctx, policy.ActionShare, &models.Todo{ID: "c", OwnerID: "bob"}))
+	}
+	mockPermissions.AssertNotCalled(t, "FindGranted", mock.Anything, "carol", mock.Anything)
+}
+
+func TestScope(t *testing.T) {
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+
+	mockPermissions := new(mockrepository.PermissionRepository)
+	mockPermissions.On("FindGranted", mock.Anything, "alice", []string(nil)).Return([]*models.Permission{
+		{TodoID: "b", UserID: "alice", Role: models.RoleViewer},
+		{TodoID: "a", UserID: "alice", Role: models.RoleEditor},
+	}, nil)
+	p := policy.New(mockPermissions)
+
+	filter := &models.TodoFilter{OwnerID: "bob", SharedIDs: []string{"c"}}
+	assert.NoError(t, p.Scope(ctx, filter))
+	assert.Equal(t, "alice", filter.OwnerID)
+	assert.Equal(t, []string{"b", "a"}, filter.SharedIDs)
+
+	// the trash is only listed to the owner
+	filter = &models.TodoFilter{Deleted: true, SharedIDs: []string{"c"}}
+	assert.NoError(t, p.Scope(ctx, filter))
+	assert.Equal(t, "alice", filter.OwnerID)
+	assert.Nil(t, filter.SharedIDs)
+
+	filter = &models.TodoFilter{OwnerID: "bob"}
+	assert.NoError(t, p.Scope(context.Background(), filter))
+	assert.Equal(t, "", filter.OwnerID)
+}
+
+func TestAllow(t *testing.T) {
+	p := policy.New(new(mockrepository.PermissionRepository))
+
+	reader := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "robot", KeyID: "k1", Scopes: []string{policy.ScopeRead}})
+	assert.NoError(t, p.Allow(reader, policy.ActionRead))
+	for _, action := range []policy.Action{policy.ActionWrite, policy.ActionDelete, policy.ActionShare} {
+		err := p.Allow(reader, action)
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err), action)
+	}
+
+	// the owner is still limited to the scopes of the key
+	err := p.Authorize(reader, policy.ActionWrite, &models.Todo{ID: "a", OwnerID: "robot"})
+	assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+	// JWTs and unauthenticated requests are not limited to scopes
+	user := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+	for _, ctx := range []context.Context{context.Background(), user} {
+		assert.NoError(t, p.Allow(ctx, policy.ActionShare))
+	}
+	assert.False(t, policy.Admin(user))
+	assert.True(t, policy.Admin(context.Background()))
+}
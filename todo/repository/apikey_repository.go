@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	"go-clean-grpc/pkg/config"
+	errorsutil "go-clean-grpc/utils/errors"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// APIKeyRepository - store of the hashed API keys of every tenant, keys are revoked instead of deleted
+// keys are found by hash in every tenant, listed and revoked in the tenant of the context only
+type APIKeyRepository interface {
+	pkgauth.KeyStore
+	StoreKey(ctx context.Context, value *pkgauth.APIKey) (*pkgauth.APIKey, error)
+	FindKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error)
+	RevokeKey(ctx context.Context, id string, revokedAt time.Time) (*pkgauth.APIKey, error)
+}
+
+// apiKeyDocument - API key as stored in mongo, keyed by object id
+type apiKeyDocument struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	Hash       string             `bson:"hash"`
+	Prefix     string             `bson:"prefix"`
+	UserID     string             `bson:"userId"`
+	Tenant     string             `bson:"tenant"`
+	Scopes     []string           `bson:"scopes"`
+	CreatedBy  string             `bson:"createdBy"`
+	ExpiresAt  *time.Time         `bson:"expiresAt"`
+	RevokedAt  *time.Time         `bson:"revokedAt"`
+	LastUsedAt *time.Time         `bson:"lastUsedAt"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+}
+
+type APIKeyRepositoryImpl struct {
+	client  *mongo.Client
+	timeout time.Duration
+}
+
+// NewAPIKeyRepository will create an object that represent the APIKeyRepository interface
+func NewAPIKeyRepository(client *mongo.Client) APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		client:  client,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StoreKey - store new API key, the hash is unique
+func (r *APIKeyRepositoryImpl) StoreKey(ctx context.Context, value *pkgauth.APIKey) (*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("api_key")
+
+	document := &apiKeyDocument{
+		ID:        primitive.NewObjectID(),
+		Name:      value.Name,
+		Hash:      value.Hash,
+		Prefix:    value.Prefix,
+		UserID:    value.UserID,
+		Tenant:    value.Tenant,
+		Scopes:    value.Scopes,
+		CreatedBy: value.CreatedBy,
+		ExpiresAt: value.ExpiresAt,
+		CreatedAt: timeutil.GetTimeNow(),
+	}
+	if document.Scopes == nil {
+		document.Scopes = []string{}
+	}
+
+	_, err := collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return document.key(), nil
+}
+
+// FindKeyByHash - find API key by the hash of the key
+func (r *APIKeyRepositoryImpl) FindKeyByHash(ctx context.Context, hash string) (*pkgauth.APIKey, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+// FindKeys - find API keys of the user in the tenant of ctx, of every user when empty, latest created first
+func (r *APIKeyRepositoryImpl) FindKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("api_key")
+
+	filter := bson.M{"tenant": tenantutil.ID(ctx)}
+	if userID != "" {
+		filter["userId"] = userID
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cur, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	results := []*pkgauth.APIKey{}
+	for cur.Next(ctx) {
+		var elem apiKeyDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		results = append(results, elem.key())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// TouchKey - set the last use of the API key by id
+func (r *APIKeyRepositoryImpl) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("api_key")
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": docID}, bson.M{"$set": bson.M{"lastUsedAt": usedAt}})
+	if err != nil {
+		return mapError(err)
+	}
+
+	if res.MatchedCount == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeKey - revoke the API key by id in the tenant of ctx, a revoked key keeps its first revocation time
+func (r *APIKeyRepositoryImpl) RevokeKey(ctx context.Context, id string, revokedAt time.Time) (*pkgauth.APIKey, error) {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("api_key")
+
+	tenant := tenantutil.ID(ctx)
+	_, err = collection.UpdateOne(timeoutCtx, bson.M{"_id": docID, "tenant": tenant, "revokedAt": nil}, bson.M{"$set": bson.M{"revokedAt": revokedAt}})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return r.findOne(ctx, bson.M{"_id": docID, "tenant": tenant})
+}
+
+func (r *APIKeyRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("api_key")
+
+	result := &apiKeyDocument{}
+	err := collection.FindOne(ctx, filter).Decode(result)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return result.key(), nil
+}
+
+func (d *apiKeyDocument) key() *pkgauth.APIKey {
+	return &pkgauth.APIKey{
+		ID:         d.ID.Hex(),
+		Name:       d.Name,
+		Hash:       d.Hash,
+		Prefix:     d.Prefix,
+		UserID:     d.UserID,
+		Tenant:     d.Tenant,
+		Scopes:     d.Scopes,
+		CreatedBy:  d.CreatedBy,
+		ExpiresAt:  utcTime(d.ExpiresAt),
+		RevokedAt:  utcTime(d.RevokedAt),
+		LastUsedAt: utcTime(d.LastUsedAt),
+		CreatedAt:  d.CreatedAt.UTC(),
+	}
+}
+
+func utcTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	result := value.UTC()
+
+	return &result
+}
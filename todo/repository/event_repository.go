@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+// ErrResumeTokenExpired - events after the resume token are no longer available, watch again without it
+var ErrResumeTokenExpired error = errorsutil.New(errorsutil.KindFailedPrecondition, "resume token is invalid or expired")
+
+// EventRepository - stream of todo change events
+type EventRepository interface {
+	// Publish - publish event of a change made by the service, ignored when changes are captured by the database
+	Publish(ctx context.Context, event *models.TodoEvent) error
+	// Watch - send events after resumeToken until ctx is done or send fails, an empty resumeToken starts with the next event
+	Watch(ctx context.Context, resumeToken string, send func(event *models.TodoEvent) error) error
+}
+
+// changeEvent - change stream event of the todo collection
+type changeEvent struct {
+	OperationType     string              `bson:"operationType"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+	FullDocument      *todoDocument       `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+type EventRepositoryImpl struct {
+	client *mongo.Client
+}
+
+// NewEventRepository will create an object that represent the EventRepository interface with mongo change streams
+// change streams need a replica set, see pkgmongodb.SupportsChangeStreams
+func NewEventRepository(client *mongo.Client) EventRepository {
+	return &EventRepositoryImpl{
+		client: client,
+	}
+}
+
+// Publish - changes are read from the change stream
+func (r *EventRepositoryImpl) Publish(ctx context.Context, event *models.TodoEvent) error {
+	return nil
+}
+
+// Watch - send the todo changes of the change stream, the resume token is the one of the change stream
+func (r *EventRepositoryImpl) Watch(ctx context.Context, resumeToken string, send func(event *models.TodoEvent) error) error {
+	collection := database(ctx, r.client).Collection("todo")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace"}}}}},
+	}
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != "" {
+		streamOptions.SetResumeAfter(bson.M{"_data": resumeToken})
+	}
+
+	stream, err := collection.Watch(ctx, pipeline, streamOptions)
+	if err != nil {
+		return mapWatchError(ctx, err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		change := &changeEvent{}
+		err := stream.Decode(change)
+		if err != nil {
+			return mapError(err)
+		}
+
+		event := change.event()
+		if event == nil {
+			continue
+		}
+		event.ResumeToken, _ = stream.ResumeToken().Lookup("_data").StringValueOK()
+
+		err = send(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mapWatchError(ctx, stream.Err())
+}
+
+// event - todo event of the change, nil when the change is not sent
+func (c *changeEvent) event() *models.TodoEvent {
+	// the todo was purged before the change was read
+	if c.FullDocument == nil {
+		return nil
+	}
+
+	todo := c.FullDocument.todo()
+	event := &models.TodoEvent{
+		Type:    models.EventUpdated,
+		TodoID:  todo.ID,
+		Todo:    todo,
+		Time:    time.Unix(int64(c.ClusterTime.T), 0).UTC(),
+		OwnerID: todo.OwnerID,
+	}
+
+	_, deleted := c.UpdateDescription.UpdatedFields["deletedAt"]
+	switch {
+	case c.OperationType == "insert" || contains(c.UpdateDescription.RemovedFields, "deletedAt"):
+		event.Type = models.EventCreated
+	case deleted && todo.DeletedAt != nil:
+		event.Type = models.EventDeleted
+		event.Todo = nil
+	case todo.DeletedAt != nil:
+		return nil
+	}
+
+	return event
+}
+
+// mapWatchError - translate change stream errors, ending the stream with ctx is not an error
+func mapWatchError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+
+	// InvalidResumeToken, ChangeStreamFatalError and ChangeStreamHistoryLost
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(260) || serverErr.HasErrorCode(280) || serverErr.HasErrorCode(286)) {
+		return ErrResumeTokenExpired
+	}
+
+	return mapError(err)
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
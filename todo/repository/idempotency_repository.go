@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-grpc/pkg/config"
+	errorsutil "go-clean-grpc/utils/errors"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// IdempotencyRepository - store of idempotency keys and the responses sent for them
+type IdempotencyRepository interface {
+	idempotencyutil.Store
+}
+
+// idempotencyDocument - idempotency record as stored in mongo, keyed by the idempotency key
+type idempotencyDocument struct {
+	Key         string               `bson:"_id"`
+	Fingerprint string               `bson:"fingerprint"`
+	Response    *idempotencyResponse `bson:"response"`
+	CreatedAt   time.Time            `bson:"createdAt"`
+	ExpiresAt   time.Time            `bson:"expiresAt"`
+}
+
+type idempotencyResponse struct {
+	Status int                 `bson:"status"`
+	Header map[string][]string `bson:"header"`
+	Body   []byte              `bson:"body"`
+}
+
+type IdempotencyRepositoryImpl struct {
+	client  *mongo.Client
+	timeout time.Duration
+}
+
+// NewIdempotencyRepository will create an object that represent the IdempotencyRepository interface
+// expired records are deleted by the TTL index of expiresAt
+func NewIdempotencyRepository(client *mongo.Client) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		client:  client,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Reserve - store record unless the key has a record that did not expire, which is returned instead
+func (r *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *idempotencyutil.Record) (*idempotencyutil.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("idempotency_key")
+
+	document := &idempotencyDocument{
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}
+
+	// replaces the expired record the TTL monitor did not delete yet, a record that did not expire fails with a duplicate key
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": record.Key, "expiresAt": bson.M{"$lte": timeutil.GetTimeNow()}},
+		document,
+		options.Replace().SetUpsert(true),
+	)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, mapError(err)
+	}
+
+	existing := &idempotencyDocument{}
+	err = collection.FindOne(ctx, bson.M{"_id": record.Key}).Decode(existing)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errorsutil.Wrap(errorsutil.KindUnavailable, "idempotency key was released, retry the request", err)
+		}
+
+		return nil, mapError(err)
+	}
+
+	return existing.record(), nil
+}
+
+// Complete - set the response and expiry of the record of the key
+func (r *IdempotencyRepositoryImpl) Complete(ctx context.Context, key string, response *idempotencyutil.Response, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("idempotency_key")
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"response": &idempotencyResponse{
+			Status: response.Status,
+			Header: response.Header,
+			Body:   response.Body,
+		},
+		"expiresAt": expiresAt,
+	}})
+	if err != nil {
+		return mapError(err)
+	}
+
+	if res.MatchedCount == 0 {
+		return mapError(mongo.ErrNoDocuments)
+	}
+
+	return nil
+}
+
+// Release - delete the record of the key, so the request can be retried
+func (r *IdempotencyRepositoryImpl) Release(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("idempotency_key")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key})
+
+	return mapError(err)
+}
+
+func (d *idempotencyDocument) record() *idempotencyutil.Record {
+	result := &idempotencyutil.Record{
+		Key:         d.Key,
+		Fingerprint: d.Fingerprint,
+		CreatedAt:   d.CreatedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+	if d.Response != nil {
+		result.Response = &idempotencyutil.Response{
+			Status: d.Response.Status,
+			Header: d.Response.Header,
+			Body:   d.Response.Body,
+		}
+	}
+
+	return result
+}
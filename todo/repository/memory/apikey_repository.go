@@ -0,0 +1,158 @@
+package memoryrepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+type APIKeyRepositoryImpl struct {
+	mu   sync.RWMutex
+	keys []*pkgauth.APIKey // in the order they were created
+}
+
+// NewAPIKeyRepository will create an in-memory object that represent the APIKeyRepository interface
+func NewAPIKeyRepository() todorepository.APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		keys: []*pkgauth.APIKey{},
+	}
+}
+
+// StoreKey - store new API key, the hash is unique
+func (r *APIKeyRepositoryImpl) StoreKey(ctx context.Context, value *pkgauth.APIKey) (*pkgauth.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.Hash == value.Hash {
+			return nil, errorsutil.New(errorsutil.KindConflict, "duplicate key")
+		}
+	}
+
+	key := cloneKey(value)
+	key.ID = idutil.New()
+	key.ExpiresAt = storedTimePointer(value.ExpiresAt)
+	key.RevokedAt = nil
+	key.LastUsedAt = nil
+	key.CreatedAt = now()
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	r.keys = append(r.keys, key)
+
+	return cloneKey(key), nil
+}
+
+// FindKeyByHash - find API key by the hash of the key
+func (r *APIKeyRepositoryImpl) FindKeyByHash(ctx context.Context, hash string) (*pkgauth.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return cloneKey(key), nil
+		}
+	}
+
+	return nil, errorsutil.ErrNotFound
+}
+
+// FindKeys - find API keys of the user in the tenant of ctx, of every user when empty, latest created first
+func (r *APIKeyRepositoryImpl) FindKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant := tenantutil.ID(ctx)
+	results := []*pkgauth.APIKey{}
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].Tenant == tenant && (userID == "" || r.keys[i].UserID == userID) {
+			results = append(results, cloneKey(r.keys[i]))
+		}
+	}
+
+	return results, nil
+}
+
+// TouchKey - set the last use of the API key by id
+func (r *APIKeyRepositoryImpl) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.find(id)
+	if key == nil {
+		return errorsutil.ErrNotFound
+	}
+	key.LastUsedAt = storedTimePointer(&usedAt)
+
+	return nil
+}
+
+// RevokeKey - revoke the API key by id in the tenant of ctx, a revoked key keeps its first revocation time
+func (r *APIKeyRepositoryImpl) RevokeKey(ctx context.Context, id string, revokedAt time.Time) (*pkgauth.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.find(id)
+	if key == nil || key.Tenant != tenantutil.ID(ctx) {
+		return nil, errorsutil.ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = storedTimePointer(&revokedAt)
+	}
+
+	return cloneKey(key), nil
+}
+
+func (r *APIKeyRepositoryImpl) find(id string) *pkgauth.APIKey {
+	for _, key := range r.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func cloneKey(value *pkgauth.APIKey) *pkgauth.APIKey {
+	result := *value
+	if value.Scopes != nil {
+		result.Scopes = append([]string{}, value.Scopes...)
+	}
+
+	return &result
+}
+
+func storedTimePointer(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	result := storedTime(*value)
+
+	return &result
+}
@@ -0,0 +1,155 @@
+package memoryrepository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+// watcherBuffer - events a watcher can fall behind before it is dropped
+const watcherBuffer = 64
+
+// ErrWatcherLagged - the watcher did not keep up with the events, it can watch again after its last resume token
+var ErrWatcherLagged error = errorsutil.New(errorsutil.KindResourceExhausted, "watcher fell behind, watch again with the last resume token")
+
+type sequencedEvent struct {
+	seq    uint64
+	tenant string
+	event  *models.TodoEvent
+}
+
+type EventRepositoryImpl struct {
+	mu       sync.Mutex
+	epoch    string // tells apart resume tokens of another process
+	seq      uint64
+	events   []sequencedEvent // latest events kept for resuming, oldest first
+	size     int
+	watchers map[chan *models.TodoEvent]string // tenant of each watcher
+}
+
+// NewEventRepository will create an in-process event bus that represent the EventRepository interface
+// the size latest events are kept, watchers can resume after any of them
+func NewEventRepository(size int) todorepository.EventRepository {
+	return &EventRepositoryImpl{
+		epoch:    idutil.New(),
+		size:     size,
+		watchers: map[chan *models.TodoEvent]string{},
+	}
+}
+
+// Publish - send event to the watchers of the tenant of ctx
+func (r *EventRepositoryImpl) Publish(ctx context.Context, event *models.TodoEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	published := *event
+	published.ResumeToken = r.epoch + "-" + strconv.FormatUint(r.seq, 10)
+	if published.Time.IsZero() {
+		published.Time = now()
+	}
+
+	tenant := tenantutil.ID(ctx)
+	r.events = append(r.events, sequencedEvent{seq: r.seq, tenant: tenant, event: &published})
+	if len(r.events) > r.size {
+		r.events = append([]sequencedEvent{}, r.events[len(r.events)-r.size:]...)
+	}
+
+	for watcher, watcherTenant := range r.watchers {
+		if watcherTenant != tenant {
+			continue
+		}
+
+		select {
+		case watcher <- &published:
+		default:
+			// a lagging watcher is dropped instead of blocking every change
+			delete(r.watchers, watcher)
+			close(watcher)
+		}
+	}
+
+	return nil
+}
+
+// Watch - send the events published in the tenant of ctx, resuming after the resume token when it is still kept
+func (r *EventRepositoryImpl) Watch(ctx context.Context, resumeToken string, send func(event *models.TodoEvent) error) error {
+	tenant := tenantutil.ID(ctx)
+
+	r.mu.Lock()
+	backlog, err := r.after(tenant, resumeToken)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+
+	watcher := make(chan *models.TodoEvent, watcherBuffer)
+	r.watchers[watcher] = tenant
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		if _, ok := r.watchers[watcher]; ok {
+			delete(r.watchers, watcher)
+			close(watcher)
+		}
+		r.mu.Unlock()
+	}()
+
+	for _, event := range backlog {
+		err := send(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher:
+			if !ok {
+				return ErrWatcherLagged
+			}
+
+			err := send(event)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// after - kept events of tenant after the resume token, the token must not be older than the kept events
+func (r *EventRepositoryImpl) after(tenant string, resumeToken string) ([]*models.TodoEvent, error) {
+	if resumeToken == "" {
+		return nil, nil
+	}
+
+	epoch, value, _ := strings.Cut(resumeToken, "-")
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || epoch != r.epoch || seq > r.seq {
+		return nil, todorepository.ErrResumeTokenExpired
+	}
+
+	// the event right after the token must still be kept
+	if seq < r.seq && (len(r.events) == 0 || r.events[0].seq > seq+1) {
+		return nil, todorepository.ErrResumeTokenExpired
+	}
+
+	results := []*models.TodoEvent{}
+	for _, item := range r.events {
+		if item.seq > seq && item.tenant == tenant {
+			results = append(results, item.event)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,205 @@
+package memoryrepository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	memoryrepository "go-clean-grpc/todo/repository/memory"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+var errStop = errors.New("stop")
+
+// probeID - todo id of the events published until a watcher is registered
+const probeID = "probe"
+
+func TestEventRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success when watch published events", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(10)
+		events := watch(t, repo)
+
+		repo.Publish(ctx, &models.TodoEvent{Type: models.EventCreated, TodoID: "a"})
+		repo.Publish(ctx, &models.TodoEvent{Type: models.EventDeleted, TodoID: "b"})
+
+		results := receive(t, events, 2)
+		assert.Equal(t, []string{"a", "b"}, eventIDs(results))
+		assert.Equal(t, models.EventCreated, results[0].Type)
+		assert.NotEmpty(t, results[0].ResumeToken)
+		assert.NotEqual(t, results[0].ResumeToken, results[1].ResumeToken)
+		assert.False(t, results[0].Time.IsZero())
+	})
+
+	t.Run("success when resume after token", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(10)
+		events := watch(t, repo)
+
+		for _, id := range []string{"a", "b", "c"} {
+			repo.Publish(ctx, &models.TodoEvent{Type: models.EventUpdated, TodoID: id})
+		}
+		published := receive(t, events, 3)
+
+		results, err := watchN(repo, published[0].ResumeToken, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "c"}, eventIDs(results))
+
+		results, err = watchN(repo, published[1].ResumeToken, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c"}, eventIDs(results))
+	})
+
+	t.Run("success when watch events of the tenant only", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(10)
+		events := watch(t, repo)
+
+		repo.Publish(ctx, &models.TodoEvent{Type: models.EventCreated, TodoID: "start"})
+		start := receive(t, events, 1)[0]
+
+		repo.Publish(tenantutil.NewContext(ctx, tenantutil.Tenant{ID: "acme"}), &models.TodoEvent{Type: models.EventCreated, TodoID: "a"})
+		repo.Publish(tenantutil.NewContext(ctx, tenantutil.Tenant{ID: "globex"}), &models.TodoEvent{Type: models.EventCreated, TodoID: "b"})
+		repo.Publish(ctx, &models.TodoEvent{Type: models.EventCreated, TodoID: "c"})
+		assert.Equal(t, []string{"c"}, eventIDs(receive(t, events, 1)))
+
+		// resuming skips the kept events of other tenants
+		results, err := watchN(repo, start.ResumeToken, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c"}, eventIDs(results))
+	})
+
+	t.Run("error when resume token expired", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(2)
+		events := watch(t, repo)
+
+		for _, id := range []string{"a", "b", "c", "d"} {
+			repo.Publish(ctx, &models.TodoEvent{TodoID: id})
+		}
+		published := receive(t, events, 4)
+
+		_, err := watchN(repo, published[0].ResumeToken, 1)
+		assert.ErrorIs(t, err, todorepository.ErrResumeTokenExpired)
+
+		results, err := watchN(repo, published[1].ResumeToken, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "d"}, eventIDs(results))
+
+		for _, token := range []string{"invalid", "other-1", "-1"} {
+			_, err = watchN(repo, token, 1)
+			assert.ErrorIs(t, err, todorepository.ErrResumeTokenExpired)
+		}
+	})
+
+	t.Run("error when watcher lags", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(10)
+
+		started := make(chan struct{}, 1)
+		blocked := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- repo.Watch(ctx, "", func(event *models.TodoEvent) error {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+				<-blocked
+				return nil
+			})
+		}()
+
+		require.Eventually(t, func() bool {
+			repo.Publish(ctx, &models.TodoEvent{TodoID: probeID})
+			return len(started) > 0
+		}, time.Second, 10*time.Millisecond)
+
+		// the watcher is stuck sending, its buffer fills up
+		for i := 0; i < 100; i++ {
+			repo.Publish(ctx, &models.TodoEvent{TodoID: "a"})
+		}
+		close(blocked)
+
+		err := <-done
+		assert.ErrorIs(t, err, memoryrepository.ErrWatcherLagged)
+	})
+
+	t.Run("success when context done", func(t *testing.T) {
+		repo := memoryrepository.NewEventRepository(10)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := repo.Watch(ctx, "", func(event *models.TodoEvent) error {
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+// watch - watch repo until the test ends, returns once the watcher receives events
+func watch(t *testing.T, repo todorepository.EventRepository) <-chan *models.TodoEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan *models.TodoEvent, 100)
+	go repo.Watch(ctx, "", func(event *models.TodoEvent) error {
+		events <- event
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		repo.Publish(ctx, &models.TodoEvent{TodoID: probeID})
+		return len(events) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	return events
+}
+
+// receive - next n events of the watcher, skipping the probes
+func receive(t *testing.T, events <-chan *models.TodoEvent, n int) []*models.TodoEvent {
+	results := []*models.TodoEvent{}
+	for len(results) < n {
+		select {
+		case event := <-events:
+			if event.TodoID != probeID {
+				results = append(results, event)
+			}
+		case <-time.After(time.Second):
+			require.FailNow(t, "event not received")
+		}
+	}
+
+	return results
+}
+
+// watchN - watch after resumeToken until n events were received
+func watchN(repo todorepository.EventRepository, resumeToken string, n int) ([]*models.TodoEvent, error) {
+	results := []*models.TodoEvent{}
+	err := repo.Watch(context.Background(), resumeToken, func(event *models.TodoEvent) error {
+		results = append(results, event)
+		if len(results) == n {
+			return errStop
+		}
+
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+
+	return results, err
+}
+
+func eventIDs(events []*models.TodoEvent) []string {
+	results := []string{}
+	for _, event := range events {
+		results = append(results, event.TodoID)
+	}
+
+	return results
+}
@@ -0,0 +1,108 @@
+package memoryrepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+)
+
+type IdempotencyRepositoryImpl struct {
+	mu      sync.Mutex
+	records map[tenantKey]*idempotencyutil.Record // records by key of each tenant
+}
+
+// NewIdempotencyRepository will create an in-memory object that represent the IdempotencyRepository interface
+func NewIdempotencyRepository() todorepository.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		records: map[tenantKey]*idempotencyutil.Record{},
+	}
+}
+
+// Reserve - store record unless the key has a record that did not expire, which is returned instead
+func (r *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *idempotencyutil.Record) (*idempotencyutil.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timeNow := now()
+	for key, item := range r.records {
+		if !item.ExpiresAt.After(timeNow) {
+			delete(r.records, key)
+		}
+	}
+
+	recordKey := newTenantKey(ctx, record.Key)
+	if existing, ok := r.records[recordKey]; ok {
+		return cloneRecord(existing), nil
+	}
+
+	r.records[recordKey] = cloneRecord(record)
+
+	return nil, nil
+}
+
+// Complete - set the response and expiry of the record of the key
+func (r *IdempotencyRepositoryImpl) Complete(ctx context.Context, key string, response *idempotencyutil.Response, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[newTenantKey(ctx, key)]
+	if !ok {
+		return errorsutil.ErrNotFound
+	}
+
+	record.Response = cloneResponse(response)
+	record.ExpiresAt = storedTime(expiresAt)
+
+	return nil
+}
+
+// Release - delete the record of the key, so the request can be retried
+func (r *IdempotencyRepositoryImpl) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	delete(r.records, newTenantKey(ctx, key))
+	r.mu.Unlock()
+
+	return nil
+}
+
+func cloneRecord(value *idempotencyutil.Record) *idempotencyutil.Record {
+	result := *value
+	result.Response = cloneResponse(value.Response)
+	result.CreatedAt = storedTime(value.CreatedAt)
+	result.ExpiresAt = storedTime(value.ExpiresAt)
+
+	return &result
+}
+
+func cloneResponse(value *idempotencyutil.Response) *idempotencyutil.Response {
+	if value == nil {
+		return nil
+	}
+
+	result := &idempotencyutil.Response{
+		Status: value.Status,
+		Header: map[string][]string{},
+		Body:   append([]byte{}, value.Body...),
+	}
+	for name, values := range value.Header {
+		result.Header[name] = append([]string{}, values...)
+	}
+
+	return result
+}
@@ -0,0 +1,135 @@
+package memoryrepository
+
+import (
+	"context"
+	"sync"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+type PermissionRepositoryImpl struct {
+	mu          sync.RWMutex
+	permissions map[string][]*models.Permission // permissions of each tenant in the order they were first granted
+}
+
+// NewPermissionRepository will create an in-memory object that represent the PermissionRepository interface
+func NewPermissionRepository() todorepository.PermissionRepository {
+	return &PermissionRepositoryImpl{
+		permissions: map[string][]*models.Permission{},
+	}
+}
+
+// StorePermission - grant the role of value, replacing the role already granted to the user or group on the todo
+func (r *PermissionRepositoryImpl) StorePermission(ctx context.Context, value *models.Permission) (*models.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	timeNow := now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant := tenantutil.ID(ctx)
+	for _, permission := range r.permissions[tenant] {
+		if permission.TodoID == value.TodoID && permission.UserID == value.UserID && permission.Group == value.Group {
+			permission.Role = value.Role
+			permission.GrantedBy = value.GrantedBy
+			permission.UpdatedAt = timeNow
+
+			return clonePermission(permission), nil
+		}
+	}
+
+	permission := clonePermission(value)
+	permission.ID = idutil.New()
+	permission.CreatedAt = timeNow
+	permission.UpdatedAt = timeNow
+	r.permissions[tenant] = append(r.permissions[tenant], permission)
+
+	return clonePermission(permission), nil
+}
+
+// FindPermissions - find permissions granted on todo, oldest first
+func (r *PermissionRepositoryImpl) FindPermissions(ctx context.Context, todoID string) ([]*models.Permission, error) {
+	return r.find(ctx, func(permission *models.Permission) bool {
+		return permission.TodoID == todoID
+	})
+}
+
+// FindGranted - find permissions granted to the user or to one of the groups
+func (r *PermissionRepositoryImpl) FindGranted(ctx context.Context, userID string, groups []string) ([]*models.Permission, error) {
+	return r.find(ctx, func(permission *models.Permission) bool {
+		return (userID != "" && permission.UserID == userID) || (permission.Group != "" && contains(groups, permission.Group))
+	})
+}
+
+// DeletePermission - revoke permission by id of todo
+func (r *PermissionRepositoryImpl) DeletePermission(ctx context.Context, todoID string, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant := tenantutil.ID(ctx)
+	for i, permission := range r.permissions[tenant] {
+		if permission.TodoID == todoID && permission.ID == id {
+			r.permissions[tenant] = append(r.permissions[tenant][:i], r.permissions[tenant][i+1:]...)
+			return nil
+		}
+	}
+
+	return errorsutil.ErrNotFound
+}
+
+// DeletePermissions - revoke every permission of todo
+func (r *PermissionRepositoryImpl) DeletePermissions(ctx context.Context, todoID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant := tenantutil.ID(ctx)
+	permissions := []*models.Permission{}
+	for _, permission := range r.permissions[tenant] {
+		if permission.TodoID != todoID {
+			permissions = append(permissions, permission)
+		}
+	}
+	r.permissions[tenant] = permissions
+
+	return nil
+}
+
+func (r *PermissionRepositoryImpl) find(ctx context.Context, match func(permission *models.Permission) bool) ([]*models.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant := tenantutil.ID(ctx)
+	results := []*models.Permission{}
+	for _, permission := range r.permissions[tenant] {
+		if match(permission) {
+			results = append(results, clonePermission(permission))
+		}
+	}
+
+	return results, nil
+}
+
+func clonePermission(value *models.Permission) *models.Permission {
+	result := *value
+
+	return &result
+}
@@ -0,0 +1,63 @@
+package memoryrepository
+
+import (
+	"strings"
+
+	models "go-clean-grpc/todo/models/http"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+)
+
+// compareTodo - compare todo in sort order, ties are broken by ascending id
+func compareTodo(a *models.Todo, b *models.Todo, sortFields []queryutil.SortField) int {
+	for _, field := range sortFields {
+		result := models.CompareValues(sortValue(a, field), sortValue(b, field))
+		if field.Desc {
+			result = -result
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return strings.Compare(a.ID, b.ID)
+}
+
+// isAfter - check whether todo comes after the page token cursor in sort order
+func isAfter(todo *models.Todo, sortFields []queryutil.SortField, cursor *paginationutil.Cursor) bool {
+	for i, field := range sortFields {
+		result := models.CompareValues(sortValue(todo, field), cursor.Values[i])
+		if field.Desc {
+			result = -result
+		}
+
+		if result != 0 {
+			return result > 0
+		}
+	}
+
+	return todo.ID > cursor.ID
+}
+
+// sortValue - value used for sorting, arrays sort by their lowest element ascending and highest descending
+func sortValue(todo *models.Todo, field queryutil.SortField) interface{} {
+	value := todo.Value(field.Field.Key)
+
+	values, ok := value.([]string)
+	if !ok {
+		return value
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := values[0]
+	for _, item := range values[1:] {
+		if (field.Desc && item > result) || (!field.Desc && item < result) {
+			result = item
+		}
+	}
+
+	return result
+}
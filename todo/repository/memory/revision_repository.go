@@ -0,0 +1,119 @@
+package memoryrepository
+
+import (
+	"context"
+	"sync"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+)
+
+type RevisionRepositoryImpl struct {
+	mu        sync.RWMutex
+	revisions map[tenantKey][]*models.Revision // revisions of todo id of each tenant in the order they were stored
+}
+
+// NewRevisionRepository will create an in-memory object that represent the RevisionRepository interface
+func NewRevisionRepository() todorepository.RevisionRepository {
+	return &RevisionRepositoryImpl{
+		revisions: map[tenantKey][]*models.Revision{},
+	}
+}
+
+// StoreRevision - store revision, the revision is never changed afterwards
+func (r *RevisionRepositoryImpl) StoreRevision(ctx context.Context, value *models.Revision) (*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	revision := cloneRevision(value)
+	revision.ID = idutil.New()
+	revision.CreatedAt = now()
+
+	key := newTenantKey(ctx, revision.TodoID)
+	r.mu.Lock()
+	r.revisions[key] = append(r.revisions[key], revision)
+	r.mu.Unlock()
+
+	return cloneRevision(revision), nil
+}
+
+// FindRevisions - find revisions of todo, latest first
+func (r *RevisionRepositoryImpl) FindRevisions(ctx context.Context, todoID string, limit int, offset int) ([]*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := r.revisions[newTenantKey(ctx, todoID)]
+
+	results := []*models.Revision{}
+	for i := len(revisions) - 1 - offset; i >= 0; i-- {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+
+		results = append(results, cloneRevision(revisions[i]))
+	}
+
+	return results, nil
+}
+
+// CountRevisions - count revisions of todo
+func (r *RevisionRepositoryImpl) CountRevisions(ctx context.Context, todoID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.revisions[newTenantKey(ctx, todoID)]), nil
+}
+
+// FindRevision - find revision of todo by id
+func (r *RevisionRepositoryImpl) FindRevision(ctx context.Context, todoID string, id string) (*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision := range r.revisions[newTenantKey(ctx, todoID)] {
+		if revision.ID == id {
+			return cloneRevision(revision), nil
+		}
+	}
+
+	return nil, errorsutil.ErrNotFound
+}
+
+func cloneRevision(value *models.Revision) *models.Revision {
+	result := *value
+	result.Changes = []*models.FieldChange{}
+	for _, change := range value.Changes {
+		result.Changes = append(result.Changes, &models.FieldChange{
+			Field:  change.Field,
+			Before: cloneChangeValue(change.Before),
+			After:  cloneChangeValue(change.After),
+		})
+	}
+	if value.Todo != nil {
+		result.Todo = clone(value.Todo)
+	}
+
+	return &result
+}
+
+func cloneChangeValue(value interface{}) interface{} {
+	if values, ok := value.([]string); ok {
+		return append([]string{}, values...)
+	}
+
+	return value
+}
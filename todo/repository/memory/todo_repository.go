@@ -0,0 +1,533 @@
+package memoryrepository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+type RepositoryImpl struct {
+	mu    sync.RWMutex
+	todos map[string]map[string]*models.Todo // todo by id of each tenant
+}
+
+// New will create an in-memory object that represent the Repository interface
+func New() todorepository.Repository {
+	return &RepositoryImpl{
+		todos: map[string]map[string]*models.Todo{},
+	}
+}
+
+// FindAll - find all todo
+func (r *RepositoryImpl) FindAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return []*models.Todo{}, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	r.mu.RLock()
+	results := r.findAll(ctx, filter)
+	r.mu.RUnlock()
+
+	sortFields := filter.SortFields()
+	if len(sortFields) == 0 {
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Score != results[j].Score {
+				return results[i].Score > results[j].Score
+			}
+
+			return results[i].ID < results[j].ID
+		})
+	} else {
+		sort.SliceStable(results, func(i, j int) bool {
+			return compareTodo(results[i], results[j], sortFields) < 0
+		})
+	}
+
+	if filter.After != nil {
+		after := []*models.Todo{}
+		for _, item := range results {
+			if isAfter(item, sortFields, filter.After) {
+				after = append(after, item)
+			}
+		}
+		results = after
+	}
+
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// CountFindAll - count find all todo
+func (r *RepositoryImpl) CountFindAll(ctx context.Context, filter *models.TodoFilter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.findAll(ctx, filter)), nil
+}
+
+// FindById - find todo by id
+func (r *RepositoryImpl) FindById(ctx context.Context, id string) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.tenantTodos(ctx)
+	todo, ok := todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	return clone(todo), nil
+}
+
+// CountFindByID - find count todo by id
+func (r *RepositoryImpl) CountFindByID(ctx context.Context, id string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.tenantTodos(ctx)
+	if todo, ok := todos[id]; !ok || todo.DeletedAt != nil {
+		return 0, errorsutil.ErrNotFound
+	}
+
+	return 1, nil
+}
+
+// Store - store todo
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	timeNow := now()
+	todo := clone(&models.Todo{
+		ID:          idutil.New(),
+		OwnerID:     value.OwnerID,
+		Title:       value.Title,
+		Description: value.Description,
+		Status:      value.Status,
+		CompletedAt: value.CompletedAt,
+		Priority:    value.Priority,
+		DueAt:       value.DueAt,
+		Tags:        value.Tags,
+		CreatedAt:   timeNow,
+		UpdatedAt:   timeNow,
+		Version:     1,
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant := tenantutil.ID(ctx)
+	if r.todos[tenant] == nil {
+		r.todos[tenant] = map[string]*models.Todo{}
+	}
+	r.todos[tenant][todo.ID] = todo
+
+	return clone(todo), nil
+}
+
+// Update - update todo by id, value.Version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	return r.update(ctx, id, value.Version, func(todo *models.Todo) {
+		todo.Title = value.Title
+		todo.Description = value.Description
+		todo.Priority = value.Priority
+		todo.DueAt = value.DueAt
+		if value.Tags != nil {
+			todo.Tags = value.Tags
+		}
+		if value.Status != "" {
+			todo.Status = value.Status
+			todo.CompletedAt = value.CompletedAt
+		}
+	})
+}
+
+// Patch - update only the patched fields of todo by id, patch.Version is the expected current version
+func (r *RepositoryImpl) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	for _, name := range patch.Fields {
+		if _, ok := models.TodoSchema.Lookup(name); !ok {
+			return nil, errorsutil.New(errorsutil.KindInvalidArgument, "field "+name+" can not be updated")
+		}
+	}
+
+	return r.update(ctx, id, patch.Version, func(todo *models.Todo) {
+		for _, name := range patch.Fields {
+			field, _ := models.TodoSchema.Lookup(name)
+			setValue(todo, patch.Todo, field.Key)
+		}
+	})
+}
+
+// UpdateStatus - update status of todo by id, version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) UpdateStatus(ctx context.Context, id string, status string, completedAt *time.Time, version int64) (*models.Todo, error) {
+	return r.update(ctx, id, version, func(todo *models.Todo) {
+		todo.Status = status
+		todo.CompletedAt = completedAt
+	})
+}
+
+// AddTags - add tags to todo by id, existing tags are kept once
+func (r *RepositoryImpl) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.update(ctx, id, 0, func(todo *models.Todo) {
+		for _, tag := range tags {
+			if !contains(todo.Tags, tag) {
+				todo.Tags = append(todo.Tags, tag)
+			}
+		}
+	})
+}
+
+// RemoveTags - remove tags from todo by id
+func (r *RepositoryImpl) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.update(ctx, id, 0, func(todo *models.Todo) {
+		kept := []string{}
+		for _, tag := range todo.Tags {
+			if !contains(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		todo.Tags = kept
+	})
+}
+
+// CountTags - count todo per tag, the most used tag first
+func (r *RepositoryImpl) CountTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	r.mu.RLock()
+	todos := r.findAll(ctx, filter)
+	r.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			counts[tag]++
+		}
+	}
+
+	results := []*models.TagCount{}
+	for tag, count := range counts {
+		results = append(results, &models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+
+		return results[i].Tag < results[j].Tag
+	})
+
+	return results, nil
+}
+
+// Delete - move todo by id to the trash
+func (r *RepositoryImpl) Delete(ctx context.Context, id string) error {
+	_, err := r.update(ctx, id, 0, func(todo *models.Todo) {
+		deletedAt := now()
+		todo.DeletedAt = &deletedAt
+	})
+
+	return err
+}
+
+// Restore - move todo by id out of the trash
+func (r *RepositoryImpl) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := r.tenantTodos(ctx)
+	todo, ok := todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	result := clone(todo)
+	result.DeletedAt = nil
+	result.UpdatedAt = now()
+	result.Version++
+	todos[id] = clone(result)
+
+	return result, nil
+}
+
+// Purge - permanently delete todo by id from the trash
+func (r *RepositoryImpl) Purge(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := r.tenantTodos(ctx)
+	todo, ok := todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return errorsutil.ErrNotFound
+	}
+	delete(todos, id)
+
+	return nil
+}
+
+// PurgeDeleted - permanently delete todo moved to the trash before the given time, the ids of the purged todo
+func (r *RepositoryImpl) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := r.tenantTodos(ctx)
+	ids := []string{}
+	for id, todo := range todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+			delete(todos, id)
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// FindByIDs - find todo by ids, ids of todo that do not exist or are in the trash are left out
+func (r *RepositoryImpl) FindByIDs(ctx context.Context, ids []string) ([]*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.tenantTodos(ctx)
+	results := []*models.Todo{}
+	for _, id := range ids {
+		todo, ok := todos[id]
+		if ok && todo.DeletedAt == nil {
+			results = append(results, clone(todo))
+		}
+	}
+
+	return results, nil
+}
+
+// StoreMany - store todo one by one
+func (r *RepositoryImpl) StoreMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	results := make([]*models.BatchResult, 0, len(values))
+	for _, value := range values {
+		todo, err := r.Store(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &models.BatchResult{ID: todo.ID, Todo: todo})
+	}
+
+	return results, nil
+}
+
+// UpdateMany - update todo by value.ID one by one, value.Version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.BatchResult, 0, len(values))
+	for _, value := range values {
+		todo, err := r.Update(ctx, value.ID, value)
+		results = append(results, &models.BatchResult{ID: value.ID, Todo: todo, Error: err})
+	}
+
+	return results, nil
+}
+
+// DeleteMany - move todo by ids to the trash one by one
+func (r *RepositoryImpl) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.BatchResult, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, &models.BatchResult{ID: id, Error: r.Delete(ctx, id)})
+	}
+
+	return results, nil
+}
+
+// findAll - copies of todo of the tenant of ctx matching the filter, the caller holds the lock
+func (r *RepositoryImpl) findAll(ctx context.Context, filter *models.TodoFilter) []*models.Todo {
+	timeNow := now()
+	todos := r.tenantTodos(ctx)
+
+	results := []*models.Todo{}
+	for _, todo := range todos {
+		if !filter.Match(todo, timeNow) {
+			continue
+		}
+
+		item := clone(todo)
+		if filter.Search != "" {
+			item.Score = todo.TextScore(filter.Search)
+		}
+		results = append(results, item)
+	}
+
+	return results
+}
+
+// update - apply change to todo by id, bumping version and updatedAt like the mongo repository
+func (r *RepositoryImpl) update(ctx context.Context, id string, version int64, change func(todo *models.Todo)) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := r.tenantTodos(ctx)
+	todo, ok := todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+	if version != 0 && todo.Version != version {
+		return nil, todorepository.ErrVersionConflict
+	}
+
+	result := clone(todo)
+	change(result)
+	result.UpdatedAt = now()
+	result.Version++
+
+	// store a copy so the caller can not mutate the stored todo through pointers it passed in
+	todos[id] = clone(result)
+
+	return clone(result), nil
+}
+
+// tenantTodos - todo by id of the tenant of ctx, nil until the tenant stores one, the caller holds the lock
+func (r *RepositoryImpl) tenantTodos(ctx context.Context) map[string]*models.Todo {
+	return r.todos[tenantutil.ID(ctx)]
+}
+
+// tenantKey - key of a value of a tenant
+type tenantKey struct {
+	tenant string
+	key    string
+}
+
+// newTenantKey - key of the tenant of ctx
+func newTenantKey(ctx context.Context, key string) tenantKey {
+	return tenantKey{tenant: tenantutil.ID(ctx), key: key}
+}
+
+// setValue - copy field of the given document key from source todo
+func setValue(todo *models.Todo, source *models.Todo, key string) {
+	switch key {
+	case "title":
+		todo.Title = source.Title
+	case "description":
+		todo.Description = source.Description
+	case "status":
+		todo.Status = source.Status
+	case "priority":
+		todo.Priority = source.Priority
+	case "tags":
+		todo.Tags = source.Tags
+	case "dueAt":
+		todo.DueAt = source.DueAt
+	case "completedAt":
+		todo.CompletedAt = source.CompletedAt
+	}
+}
+
+// clone - deep copy of todo, stored times have the millisecond UTC precision of MongoDB
+func clone(value *models.Todo) *models.Todo {
+	result := *value
+	result.Tags = append([]string{}, value.Tags...)
+	result.DueAt = cloneTime(value.DueAt)
+	result.CompletedAt = cloneTime(value.CompletedAt)
+	result.DeletedAt = cloneTime(value.DeletedAt)
+	result.CreatedAt = storedTime(value.CreatedAt)
+	result.UpdatedAt = storedTime(value.UpdatedAt)
+	if result.Status == "" {
+		result.Status = models.StatusPending
+	}
+
+	return &result
+}
+
+func cloneTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+
+	result := storedTime(*value)
+
+	return &result
+}
+
+func storedTime(value time.Time) time.Time {
+	return value.UTC().Truncate(time.Millisecond)
+}
+
+func now() time.Time {
+	return storedTime(timeutil.GetTimeNow())
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package memoryrepository_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	memoryrepository "go-clean-grpc/todo/repository/memory"
+	"go-clean-grpc/todo/repository/repositorytest"
+)
+
+func TestRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) todorepository.Repository {
+		return memoryrepository.New()
+	})
+}
+
+func TestTenants(t *testing.T) {
+	repositorytest.RunTenants(t, func(t *testing.T) todorepository.Repository {
+		return memoryrepository.New()
+	})
+}
+
+func TestRevisionRepository(t *testing.T) {
+	repositorytest.RunRevisions(t, func(t *testing.T) todorepository.RevisionRepository {
+		return memoryrepository.NewRevisionRepository()
+	})
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	repositorytest.RunIdempotency(t, func(t *testing.T) todorepository.IdempotencyRepository {
+		return memoryrepository.NewIdempotencyRepository()
+	})
+}
+
+func TestPermissionRepository(t *testing.T) {
+	repositorytest.RunPermissions(t, func(t *testing.T) todorepository.PermissionRepository {
+		return memoryrepository.NewPermissionRepository()
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	repositorytest.RunAPIKeys(t, func(t *testing.T) todorepository.APIKeyRepository {
+		return memoryrepository.NewAPIKeyRepository()
+	})
+}
+
+func TestRepositoryConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := memoryrepository.New()
+
+	stored, err := repo.Store(ctx, &models.Todo{Title: "a", Description: "a"})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			repo.Store(ctx, &models.Todo{Title: "b", Description: "b", Tags: []string{"x"}})
+			repo.AddTags(ctx, stored.ID, []string{"x"})
+			repo.FindAll(ctx, &models.TodoFilter{Tags: []string{"x"}}, 10, 0)
+		}()
+	}
+	wg.Wait()
+
+	result, err := repo.FindById(ctx, stored.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(51), result.Version)
+
+	total, err := repo.CountFindAll(ctx, &models.TodoFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 51, total)
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	pkgmongodb "go-clean-grpc/pkg/mongodb"
+	models "go-clean-grpc/todo/models/http"
+)
+
+// Migrations - schema migrations of the todo collection, append new migrations with the next version
+var Migrations = []pkgmongodb.Migration{
+	{
+		Version:     1,
+		Description: "create todo indexes",
+		Up:          createIndexes,
+		Down:        dropIndexes,
+	},
+	{
+		Version:     2,
+		Description: "backfill status, priority, tags and version",
+		Up:          backfillDefaults,
+	},
+	{
+		Version:     3,
+		Description: "create todo deletedAt index",
+		Up:          createDeletedAtIndex,
+		Down:        dropDeletedAtIndex,
+	},
+	{
+		Version:     4,
+		Description: "create todo_revision index",
+		Up:          createRevisionIndex,
+		Down:        dropRevisionIndex,
+	},
+	{
+		Version:     5,
+		Description: "create idempotency_key expiry index",
+		Up:          createIdempotencyIndex,
+		Down:        dropIdempotencyIndex,
+	},
+	{
+		Version:     6,
+		Description: "create todo ownerId index",
+		Up:          createOwnerIndex,
+		Down:        dropOwnerIndex,
+	},
+	{
+		Version:     7,
+		Description: "create todo_permission indexes",
+		Up:          createPermissionIndexes,
+		Down:        dropPermissionIndexes,
+	},
+	{
+		Version:     8,
+		Description: "create api_key indexes",
+		Up:          createAPIKeyIndexes,
+		Down:        dropAPIKeyIndexes,
+	},
+	{
+		Version:     9,
+		Description: "backfill api_key tenant and create its index",
+		Up:          createAPIKeyTenantIndex,
+		Down:        dropAPIKeyTenantIndex,
+	},
+}
+
+// todoIndexes - indexes used by todo queries, the updated_at indexes follow the default latest updated first sort
+var todoIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().SetName("todo_text").SetWeights(bson.M{"title": models.TitleWeight, "description": models.DescriptionWeight}),
+	},
+	{
+		Keys:    bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("todo_updated_at"),
+	},
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("todo_status_updated_at"),
+	},
+	{
+		Keys:    bson.D{{Key: "tags", Value: 1}},
+		Options: options.Index().SetName("todo_tags"),
+	},
+	{
+		Keys:    bson.D{{Key: "dueAt", Value: 1}},
+		Options: options.Index().SetName("todo_due_at"),
+	},
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo").Indexes().CreateMany(ctx, todoIndexes)
+
+	return mapError(err)
+}
+
+func dropIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range todoIndexes {
+		_, err := db.Collection("todo").Indexes().DropOne(ctx, *index.Options.Name)
+		if err != nil && !isIndexNotFound(err) {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
+
+// deletedAtIndex - index of the trash listing and purge
+var deletedAtIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+	Options: options.Index().SetName("todo_deleted_at"),
+}
+
+func createDeletedAtIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo").Indexes().CreateOne(ctx, deletedAtIndex)
+
+	return mapError(err)
+}
+
+func dropDeletedAtIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo").Indexes().DropOne(ctx, *deletedAtIndex.Options.Name)
+	if err != nil && !isIndexNotFound(err) {
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// revisionIndex - index of the history of a todo, latest first
+var revisionIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "todoId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+	Options: options.Index().SetName("todo_revision_todo_id_created_at"),
+}
+
+func createRevisionIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo_revision").Indexes().CreateOne(ctx, revisionIndex)
+
+	return mapError(err)
+}
+
+func dropRevisionIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo_revision").Indexes().DropOne(ctx, *revisionIndex.Options.Name)
+	if err != nil && !isIndexNotFound(err) {
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// idempotencyIndex - TTL index deleting idempotency keys once they expire
+var idempotencyIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+	Options: options.Index().SetName("idempotency_key_expires_at").SetExpireAfterSeconds(0),
+}
+
+func createIdempotencyIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("idempotency_key").Indexes().CreateOne(ctx, idempotencyIndex)
+
+	return mapError(err)
+}
+
+func dropIdempotencyIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("idempotency_key").Indexes().DropOne(ctx, *idempotencyIndex.Options.Name)
+	if err != nil && !isIndexNotFound(err) {
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// backfillDefaults - set the defaults of fields added after documents were stored
+func backfillDefaults(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("todo")
+
+	backfills := []struct {
+		filter bson.M
+		value  bson.M
+	}{
+		{bson.M{"status": bson.M{"$in": bson.A{nil, ""}}}, bson.M{"status": models.StatusPending}},
+		{bson.M{"priority": bson.M{"$exists": false}}, bson.M{"priority": models.PriorityNone}},
+		{bson.M{"tags": nil}, bson.M{"tags": bson.A{}}},
+		{bson.M{"version": bson.M{"$exists": false}}, bson.M{"version": 1}},
+	}
+	for _, backfill := range backfills {
+		_, err := collection.UpdateMany(ctx, backfill.filter, bson.M{"$set": backfill.value})
+		if err != nil {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
+
+// apiKeyTenantIndex - index of the key listing of a user in a tenant
+var apiKeyTenantIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+	Options: options.Index().SetName("api_key_tenant_user_id_created_at"),
+}
+
+// createAPIKeyTenantIndex - keys created before tenancy belong to the default tenant
+func createAPIKeyTenantIndex(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("api_key")
+
+	_, err := collection.UpdateMany(ctx, bson.M{"tenant": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"tenant": ""}})
+	if err != nil {
+		return mapError(err)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, apiKeyTenantIndex)
+
+	return mapError(err)
+}
+
+func dropAPIKeyTenantIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("api_key").Indexes().DropOne(ctx, *apiKeyTenantIndex.Options.Name)
+	if err != nil && !isIndexNotFound(err) {
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// isIndexNotFound - check whether the index was already dropped
+func isIndexNotFound(err error) bool {
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.Code == 27 || commandErr.HasErrorMessage("index not found")
+	}
+
+	return false
+}
+
+// ownerIndex - index of the todo of a user, latest updated first
+var ownerIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "ownerId", Value: 1}, {Key: "updatedAt", Value: -1}, {Key: "_id", Value: 1}},
+	Options: options.Index().SetName("todo_owner_id_updated_at"),
+}
+
+func createOwnerIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo").Indexes().CreateOne(ctx, ownerIndex)
+
+	return mapError(err)
+}
+
+func dropOwnerIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo").Indexes().DropOne(ctx, *ownerIndex.Options.Name)
+	if err != nil && !isIndexNotFound(err) {
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// permissionIndexes - one permission per todo and user or group, and the lookup of the permissions of a user
+var permissionIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "todoId", Value: 1}, {Key: "userId", Value: 1}, {Key: "group", Value: 1}},
+		Options: options.Index().SetName("todo_permission_todo_id_principal").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetName("todo_permission_user_id"),
+	},
+	{
+		Keys:    bson.D{{Key: "group", Value: 1}},
+		Options: options.Index().SetName("todo_permission_group"),
+	},
+}
+
+func createPermissionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("todo_permission").Indexes().CreateMany(ctx, permissionIndexes)
+
+	return mapError(err)
+}
+
+func dropPermissionIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range permissionIndexes {
+		_, err := db.Collection("todo_permission").Indexes().DropOne(ctx, *index.Options.Name)
+		if err != nil && !isIndexNotFound(err) {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
+
+// apiKeyIndexes - index of the verified key hashes and of the key listing of a user
+var apiKeyIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetName("api_key_hash").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("api_key_user_id_created_at"),
+	},
+}
+
+func createAPIKeyIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("api_key").Indexes().CreateMany(ctx, apiKeyIndexes)
+
+	return mapError(err)
+}
+
+func dropAPIKeyIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range apiKeyIndexes {
+		_, err := db.Collection("api_key").Indexes().DropOne(ctx, *index.Options.Name)
+		if err != nil && !isIndexNotFound(err) {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	pkgmongodb "go-clean-grpc/pkg/mongodb"
+)
+
+func TestMigrationRunner(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mtest.ClusterURI()))
+	require.NoError(t, err)
+	defer client.Disconnect(ctx)
+
+	db := client.Database("todo_test_" + primitive.NewObjectID().Hex())
+	defer db.Drop(ctx)
+
+	calls := []string{}
+	migration := func(version int, description string, down bool) pkgmongodb.Migration {
+		result := pkgmongodb.Migration{
+			Version:     version,
+			Description: description,
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				calls = append(calls, "up "+description)
+				return nil
+			},
+		}
+		if down {
+			result.Down = func(ctx context.Context, db *mongo.Database) error {
+				calls = append(calls, "down "+description)
+				return nil
+			}
+		}
+
+		return result
+	}
+	// unordered, the first one can not be rolled back
+	migrations := []pkgmongodb.Migration{migration(3, "c", true), migration(1, "a", false), migration(2, "b", true)}
+
+	t.Run("error when duplicate versions, nothing is applied", func(t *testing.T) {
+		err := pkgmongodb.MigrateUp(ctx, db, append(migrations, migration(2, "b again", true)))
+		assert.Error(t, err)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("up applies the migrations in version order once", func(t *testing.T) {
+		require.NoError(t, pkgmongodb.MigrateUp(ctx, db, migrations))
+		assert.Equal(t, []string{"up a", "up b", "up c"}, calls)
+
+		require.NoError(t, pkgmongodb.MigrateUp(ctx, db, migrations))
+		assert.Len(t, calls, 3)
+
+		statuses, err := pkgmongodb.MigrationStatuses(ctx, db, migrations)
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+		for i, status := range statuses {
+			assert.Equal(t, i+1, status.Version)
+			assert.NotNil(t, status.AppliedAt)
+		}
+	})
+
+	t.Run("down rolls back the latest migrations", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, pkgmongodb.MigrateDown(ctx, db, migrations, 2))
+		assert.Equal(t, []string{"down c", "down b"}, calls)
+
+		statuses, err := pkgmongodb.MigrationStatuses(ctx, db, migrations)
+		require.NoError(t, err)
+		assert.NotNil(t, statuses[0].AppliedAt)
+		assert.Nil(t, statuses[1].AppliedAt)
+		assert.Nil(t, statuses[2].AppliedAt)
+	})
+
+	t.Run("error when the migration has no down, it stays applied", func(t *testing.T) {
+		calls = nil
+		err := pkgmongodb.MigrateDown(ctx, db, migrations, 1)
+		assert.Error(t, err)
+		assert.Empty(t, calls)
+
+		statuses, err := pkgmongodb.MigrationStatuses(ctx, db, migrations)
+		require.NoError(t, err)
+		assert.NotNil(t, statuses[0].AppliedAt)
+	})
+
+	t.Run("up applies the rolled back migrations again", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, pkgmongodb.MigrateUp(ctx, db, migrations))
+		assert.Equal(t, []string{"up b", "up c"}, calls)
+	})
+}
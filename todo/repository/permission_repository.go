@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-grpc/pkg/config"
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// PermissionRepository - roles granted on todo, at most one per todo and user or group
+type PermissionRepository interface {
+	StorePermission(ctx context.Context, value *models.Permission) (*models.Permission, error)
+	FindPermissions(ctx context.Context, todoID string) ([]*models.Permission, error)
+	FindGranted(ctx context.Context, userID string, groups []string) ([]*models.Permission, error)
+	DeletePermission(ctx context.Context, todoID string, id string) error
+	DeletePermissions(ctx context.Context, todoID string) error
+}
+
+// permissionDocument - permission as stored in mongo, keyed by object id
+type permissionDocument struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty"`
+	models.Permission `bson:",inline"`
+}
+
+type PermissionRepositoryImpl struct {
+	client  *mongo.Client
+	timeout time.Duration
+}
+
+// NewPermissionRepository will create an object that represent the PermissionRepository interface
+func NewPermissionRepository(client *mongo.Client) PermissionRepository {
+	return &PermissionRepositoryImpl{
+		client:  client,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StorePermission - grant the role of value, replacing the role already granted to the user or group on the todo
+func (r *PermissionRepositoryImpl) StorePermission(ctx context.Context, value *models.Permission) (*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_permission")
+
+	timeNow := timeutil.GetTimeNow()
+	update := bson.M{
+		"$set": bson.M{
+			"role":      value.Role,
+			"grantedBy": value.GrantedBy,
+			"updatedAt": timeNow,
+		},
+		"$setOnInsert": bson.M{"createdAt": timeNow},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	result := &permissionDocument{}
+	err := collection.FindOneAndUpdate(ctx, principalFilter(value), update, updateOptions).Decode(result)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return result.permission(), nil
+}
+
+// FindPermissions - find permissions granted on todo, oldest first
+func (r *PermissionRepositoryImpl) FindPermissions(ctx context.Context, todoID string) ([]*models.Permission, error) {
+	return r.find(ctx, bson.M{"todoId": todoID})
+}
+
+// FindGranted - find permissions granted to the user or to one of the groups
+func (r *PermissionRepositoryImpl) FindGranted(ctx context.Context, userID string, groups []string) ([]*models.Permission, error) {
+	principals := bson.A{}
+	if userID != "" {
+		principals = append(principals, bson.M{"userId": userID})
+	}
+	if len(groups) > 0 {
+		principals = append(principals, bson.M{"group": bson.M{"$in": groups}})
+	}
+	if len(principals) == 0 {
+		return []*models.Permission{}, nil
+	}
+
+	return r.find(ctx, bson.M{"$or": principals})
+}
+
+// DeletePermission - revoke permission by id of todo
+func (r *PermissionRepositoryImpl) DeletePermission(ctx context.Context, todoID string, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo_permission")
+
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": docID, "todoId": todoID})
+	if err != nil {
+		return mapError(err)
+	}
+
+	if res.DeletedCount == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeletePermissions - revoke every permission of todo
+func (r *PermissionRepositoryImpl) DeletePermissions(ctx context.Context, todoID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_permission")
+
+	_, err := collection.DeleteMany(ctx, bson.M{"todoId": todoID})
+
+	return mapError(err)
+}
+
+func (r *PermissionRepositoryImpl) find(ctx context.Context, filter bson.M) ([]*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_permission")
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cur, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.Permission{}
+	for cur.Next(ctx) {
+		var elem permissionDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		results = append(results, elem.permission())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// principalFilter - match the permission of the user or group of value on its todo
+func principalFilter(value *models.Permission) bson.M {
+	return bson.M{"todoId": value.TodoID, "userId": value.UserID, "group": value.Group}
+}
+
+func (d *permissionDocument) permission() *models.Permission {
+	value := d.Permission
+	value.ID = d.ID.Hex()
+	value.CreatedAt = value.CreatedAt.UTC()
+	value.UpdatedAt = value.UpdatedAt.UTC()
+
+	return &value
+}
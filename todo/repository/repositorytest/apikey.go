@@ -0,0 +1,166 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+// NewAPIKeyRepository - make an empty API key repository for a single test
+type NewAPIKeyRepository func(t *testing.T) todorepository.APIKeyRepository
+
+// RunAPIKeys - run the conformance suite of APIKeyRepository implementations
+func RunAPIKeys(t *testing.T, newRepository NewAPIKeyRepository) {
+	t.Run("store and find keys", func(t *testing.T) { testStoreKey(t, newRepository(t)) })
+	t.Run("touch key", func(t *testing.T) { testTouchKey(t, newRepository(t)) })
+	t.Run("revoke key", func(t *testing.T) { testRevokeKey(t, newRepository(t)) })
+	t.Run("tenant key", func(t *testing.T) { testTenantKey(t, newRepository(t)) })
+}
+
+func testStoreKey(t *testing.T, repo todorepository.APIKeyRepository) {
+	ctx := context.Background()
+	user := "user-" + idutil.New()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+	first, err := repo.StoreKey(ctx, &pkgauth.APIKey{
+		Name:      "ci",
+		Hash:      "hash-" + idutil.New(),
+		Prefix:    "tk_abcdefgh",
+		UserID:    user,
+		Scopes:    []string{"todo:read", "todo:write"},
+		CreatedBy: "admin",
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, "ci", first.Name)
+	assert.Equal(t, "tk_abcdefgh", first.Prefix)
+	assert.Equal(t, user, first.UserID)
+	assert.Equal(t, []string{"todo:read", "todo:write"}, first.Scopes)
+	assert.Equal(t, "admin", first.CreatedBy)
+	require.NotNil(t, first.ExpiresAt)
+	assert.WithinDuration(t, expiresAt, *first.ExpiresAt, time.Millisecond)
+	assert.Nil(t, first.RevokedAt)
+	assert.Nil(t, first.LastUsedAt)
+	assert.WithinDuration(t, time.Now(), first.CreatedAt, 5*time.Second)
+
+	time.Sleep(10 * time.Millisecond)
+	second, err := repo.StoreKey(ctx, &pkgauth.APIKey{Name: "backup", Hash: "hash-" + idutil.New(), UserID: user})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Empty(t, second.Scopes)
+	assert.Nil(t, second.ExpiresAt)
+
+	_, err = repo.StoreKey(ctx, &pkgauth.APIKey{Name: "other", Hash: "hash-" + idutil.New(), UserID: "other-" + idutil.New()})
+	require.NoError(t, err)
+
+	result, err := repo.FindKeyByHash(ctx, first.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, result.ID)
+	assert.Equal(t, first.Hash, result.Hash)
+	assert.Equal(t, first.Scopes, result.Scopes)
+
+	_, err = repo.FindKeyByHash(ctx, "hash-"+idutil.New())
+	assert.Equal(t, errorsutil.ErrNotFound, err)
+
+	results, err := repo.FindKeys(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, second.ID, results[0].ID)
+	assert.Equal(t, first.ID, results[1].ID)
+
+	results, err = repo.FindKeys(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	results, err = repo.FindKeys(ctx, "other")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testTouchKey(t *testing.T, repo todorepository.APIKeyRepository) {
+	ctx := context.Background()
+
+	value, err := repo.StoreKey(ctx, &pkgauth.APIKey{Name: "ci", Hash: "hash-" + idutil.New(), UserID: "robot"})
+	require.NoError(t, err)
+
+	usedAt := time.Now().UTC().Truncate(time.Millisecond)
+	err = repo.TouchKey(ctx, value.ID, usedAt)
+	require.NoError(t, err)
+
+	result, err := repo.FindKeyByHash(ctx, value.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, result.LastUsedAt)
+	assert.WithinDuration(t, usedAt, *result.LastUsedAt, time.Millisecond)
+
+	err = repo.TouchKey(ctx, idutil.New(), usedAt)
+	assert.Equal(t, errorsutil.ErrNotFound, err)
+}
+
+func testRevokeKey(t *testing.T, repo todorepository.APIKeyRepository) {
+	ctx := context.Background()
+
+	value, err := repo.StoreKey(ctx, &pkgauth.APIKey{Name: "ci", Hash: "hash-" + idutil.New(), UserID: "robot"})
+	require.NoError(t, err)
+
+	revokedAt := time.Now().UTC().Truncate(time.Millisecond)
+	result, err := repo.RevokeKey(ctx, value.ID, revokedAt)
+	require.NoError(t, err)
+	assert.Equal(t, value.ID, result.ID)
+	require.NotNil(t, result.RevokedAt)
+	assert.WithinDuration(t, revokedAt, *result.RevokedAt, time.Millisecond)
+
+	// revoking again keeps the first revocation time
+	result, err = repo.RevokeKey(ctx, value.ID, revokedAt.Add(time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, result.RevokedAt)
+	assert.WithinDuration(t, revokedAt, *result.RevokedAt, time.Millisecond)
+
+	result, err = repo.FindKeyByHash(ctx, value.Hash)
+	require.NoError(t, err)
+	assert.NotNil(t, result.RevokedAt)
+
+	_, err = repo.RevokeKey(ctx, idutil.New(), revokedAt)
+	assert.Equal(t, errorsutil.ErrNotFound, err)
+}
+
+func testTenantKey(t *testing.T, repo todorepository.APIKeyRepository) {
+	acme := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "acme"})
+	globex := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "globex"})
+
+	value, err := repo.StoreKey(acme, &pkgauth.APIKey{Name: "ci", Hash: "hash-" + idutil.New(), UserID: "robot", Tenant: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", value.Tenant)
+
+	// keys are verified before the tenant is known
+	result, err := repo.FindKeyByHash(context.Background(), value.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", result.Tenant)
+
+	results, err := repo.FindKeys(acme, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, value.ID, results[0].ID)
+
+	for _, ctx := range []context.Context{globex, context.Background()} {
+		results, err = repo.FindKeys(ctx, "robot")
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		_, err = repo.RevokeKey(ctx, value.ID, time.Now())
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+	}
+
+	result, err = repo.FindKeyByHash(context.Background(), value.Hash)
+	require.NoError(t, err)
+	assert.Nil(t, result.RevokedAt)
+}
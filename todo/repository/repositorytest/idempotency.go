@@ -0,0 +1,143 @@
+package repositorytest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	todorepository "go-clean-grpc/todo/repository"
+	idutil "go-clean-grpc/utils/id"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+)
+
+// NewIdempotencyRepository - make an empty idempotency repository for a single test
+type NewIdempotencyRepository func(t *testing.T) todorepository.IdempotencyRepository
+
+// RunIdempotency - run the conformance suite of IdempotencyRepository implementations
+func RunIdempotency(t *testing.T, newRepository NewIdempotencyRepository) {
+	t.Run("reserve and complete", func(t *testing.T) { testReserve(t, newRepository(t)) })
+	t.Run("release", func(t *testing.T) { testRelease(t, newRepository(t)) })
+	t.Run("expired", func(t *testing.T) { testReserveExpired(t, newRepository(t)) })
+	t.Run("tenant", func(t *testing.T) { testReserveTenant(t, newRepository(t)) })
+}
+
+func newRecord(expiresIn time.Duration) *idempotencyutil.Record {
+	timeNow := time.Now()
+
+	return &idempotencyutil.Record{
+		Key:         "http/alice/" + idutil.New(),
+		Fingerprint: "fingerprint",
+		CreatedAt:   timeNow,
+		ExpiresAt:   timeNow.Add(expiresIn),
+	}
+}
+
+func testReserve(t *testing.T, repo todorepository.IdempotencyRepository) {
+	ctx := context.Background()
+	record := newRecord(time.Minute)
+
+	existing, err := repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// in progress
+	existing, err = repo.Reserve(ctx, &idempotencyutil.Record{Key: record.Key, Fingerprint: "other", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, record.Key, existing.Key)
+	assert.Equal(t, "fingerprint", existing.Fingerprint)
+	assert.Nil(t, existing.Response)
+	assert.WithinDuration(t, record.ExpiresAt, existing.ExpiresAt, time.Millisecond)
+
+	expiresAt := time.Now().Add(time.Hour)
+	err = repo.Complete(ctx, record.Key, &idempotencyutil.Response{
+		Status: http.StatusCreated,
+		Header: map[string][]string{"Content-Type": {"application/json"}, "Etag": {`"1"`}},
+		Body:   []byte(`{"success":true}`),
+	}, expiresAt)
+	require.NoError(t, err)
+
+	existing, err = repo.Reserve(ctx, newRecord(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = repo.Reserve(ctx, &idempotencyutil.Record{Key: record.Key, Fingerprint: "fingerprint", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	require.NotNil(t, existing.Response)
+	assert.Equal(t, http.StatusCreated, existing.Response.Status)
+	assert.Equal(t, map[string][]string{"Content-Type": {"application/json"}, "Etag": {`"1"`}}, existing.Response.Header)
+	assert.Equal(t, []byte(`{"success":true}`), existing.Response.Body)
+	assert.WithinDuration(t, expiresAt, existing.ExpiresAt, time.Millisecond)
+
+	err = repo.Complete(ctx, "http/alice/"+idutil.New(), &idempotencyutil.Response{Status: http.StatusOK}, expiresAt)
+	assert.Error(t, err)
+}
+
+func testRelease(t *testing.T, repo todorepository.IdempotencyRepository) {
+	ctx := context.Background()
+	record := newRecord(time.Minute)
+
+	_, err := repo.Reserve(ctx, record)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Release(ctx, record.Key))
+	require.NoError(t, repo.Release(ctx, record.Key))
+
+	existing, err := repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func testReserveExpired(t *testing.T, repo todorepository.IdempotencyRepository) {
+	ctx := context.Background()
+	record := newRecord(-time.Second)
+
+	_, err := repo.Reserve(ctx, record)
+	require.NoError(t, err)
+
+	// the expired record is replaced
+	next := newRecord(time.Minute)
+	next.Key = record.Key
+	next.Fingerprint = "next"
+	existing, err := repo.Reserve(ctx, next)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "next", existing.Fingerprint)
+}
+
+func testReserveTenant(t *testing.T, repo todorepository.IdempotencyRepository) {
+	acme, globex := tenantContexts()
+	record := newRecord(time.Minute)
+
+	existing, err := repo.Reserve(acme, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// the same key of another tenant is another request
+	existing, err = repo.Reserve(globex, newRecordOf(record.Key))
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	require.NoError(t, repo.Release(globex, record.Key))
+
+	existing, err = repo.Reserve(acme, newRecordOf(record.Key))
+	require.NoError(t, err)
+	require.NotNil(t, existing, "releasing the key of another tenant keeps the record")
+}
+
+// newRecordOf - record of key expiring in a minute
+func newRecordOf(key string) *idempotencyutil.Record {
+	record := newRecord(time.Minute)
+	record.Key = key
+
+	return record
+}
@@ -0,0 +1,173 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+)
+
+// NewPermissionRepository - make an empty permission repository for a single test
+type NewPermissionRepository func(t *testing.T) todorepository.PermissionRepository
+
+// RunPermissions - run the conformance suite of PermissionRepository implementations
+func RunPermissions(t *testing.T, newRepository NewPermissionRepository) {
+	t.Run("store and find permissions", func(t *testing.T) { testStorePermission(t, newRepository(t)) })
+	t.Run("find granted", func(t *testing.T) { testFindGranted(t, newRepository(t)) })
+	t.Run("delete permissions", func(t *testing.T) { testDeletePermission(t, newRepository(t)) })
+	t.Run("tenant permissions", func(t *testing.T) { testTenantPermissions(t, newRepository(t)) })
+}
+
+func testStorePermission(t *testing.T, repo todorepository.PermissionRepository) {
+	ctx := context.Background()
+	todoID := idutil.New()
+
+	viewer, err := repo.StorePermission(ctx, &models.Permission{TodoID: todoID, UserID: "bob", Role: models.RoleViewer, GrantedBy: "alice"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, viewer.ID)
+	assert.Equal(t, todoID, viewer.TodoID)
+	assert.Equal(t, "bob", viewer.UserID)
+	assert.Equal(t, "", viewer.Group)
+	assert.Equal(t, models.RoleViewer, viewer.Role)
+	assert.Equal(t, "alice", viewer.GrantedBy)
+	assert.WithinDuration(t, time.Now(), viewer.CreatedAt, 5*time.Second)
+
+	group, err := repo.StorePermission(ctx, &models.Permission{TodoID: todoID, Group: "team", Role: models.RoleEditor, GrantedBy: "alice"})
+	require.NoError(t, err)
+	assert.NotEqual(t, viewer.ID, group.ID)
+
+	// granting again to the same user replaces the role
+	time.Sleep(10 * time.Millisecond)
+	editor, err := repo.StorePermission(ctx, &models.Permission{TodoID: todoID, UserID: "bob", Role: models.RoleEditor, GrantedBy: "carol"})
+	require.NoError(t, err)
+	assert.Equal(t, viewer.ID, editor.ID)
+	assert.Equal(t, models.RoleEditor, editor.Role)
+	assert.Equal(t, "carol", editor.GrantedBy)
+	assert.WithinDuration(t, viewer.CreatedAt, editor.CreatedAt, time.Millisecond)
+	assert.True(t, editor.UpdatedAt.After(viewer.UpdatedAt))
+
+	results, err := repo.FindPermissions(ctx, todoID)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, viewer.ID, results[0].ID)
+	assert.Equal(t, models.RoleEditor, results[0].Role)
+	assert.Equal(t, group.ID, results[1].ID)
+	assert.Equal(t, "team", results[1].Group)
+
+	results, err = repo.FindPermissions(ctx, idutil.New())
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testFindGranted(t *testing.T, repo todorepository.PermissionRepository) {
+	ctx := context.Background()
+	first, second, third := idutil.New(), idutil.New(), idutil.New()
+	user, group := "user-"+idutil.New(), "group-"+idutil.New()
+
+	for _, value := range []*models.Permission{
+		{TodoID: first, UserID: user, Role: models.RoleViewer},
+		{TodoID: second, Group: group, Role: models.RoleEditor},
+		{TodoID: third, UserID: "other-" + idutil.New(), Role: models.RoleViewer},
+	} {
+		_, err := repo.StorePermission(ctx, value)
+		require.NoError(t, err)
+	}
+
+	todoIDs := func(permissions []*models.Permission) []string {
+		results := []string{}
+		for _, permission := range permissions {
+			results = append(results, permission.TodoID)
+		}
+		return results
+	}
+
+	results, err := repo.FindGranted(ctx, user, []string{group, "other"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first, second}, todoIDs(results))
+
+	results, err = repo.FindGranted(ctx, user, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first}, todoIDs(results))
+
+	results, err = repo.FindGranted(ctx, "", []string{group})
+	require.NoError(t, err)
+	assert.Equal(t, []string{second}, todoIDs(results))
+
+	// permissions of groups are not granted to users without groups
+	results, err = repo.FindGranted(ctx, "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testDeletePermission(t *testing.T, repo todorepository.PermissionRepository) {
+	ctx := context.Background()
+	todoID, otherID := idutil.New(), idutil.New()
+
+	bob, err := repo.StorePermission(ctx, &models.Permission{TodoID: todoID, UserID: "bob", Role: models.RoleViewer})
+	require.NoError(t, err)
+	_, err = repo.StorePermission(ctx, &models.Permission{TodoID: todoID, UserID: "carol", Role: models.RoleViewer})
+	require.NoError(t, err)
+	_, err = repo.StorePermission(ctx, &models.Permission{TodoID: otherID, UserID: "bob", Role: models.RoleViewer})
+	require.NoError(t, err)
+
+	// the permission has to be one of the todo
+	err = repo.DeletePermission(ctx, otherID, bob.ID)
+	assert.Equal(t, errorsutil.ErrNotFound, err)
+
+	err = repo.DeletePermission(ctx, todoID, bob.ID)
+	require.NoError(t, err)
+
+	err = repo.DeletePermission(ctx, todoID, bob.ID)
+	assert.Equal(t, errorsutil.ErrNotFound, err)
+
+	results, err := repo.FindPermissions(ctx, todoID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "carol", results[0].UserID)
+
+	err = repo.DeletePermissions(ctx, todoID)
+	require.NoError(t, err)
+
+	results, err = repo.FindPermissions(ctx, todoID)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = repo.FindPermissions(ctx, otherID)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func testTenantPermissions(t *testing.T, repo todorepository.PermissionRepository) {
+	acme, globex := tenantContexts()
+	todoID := idutil.New()
+
+	stored, err := repo.StorePermission(acme, &models.Permission{TodoID: todoID, UserID: "bob", Role: models.RoleViewer, GrantedBy: "alice"})
+	require.NoError(t, err)
+
+	for _, ctx := range []context.Context{globex, context.Background()} {
+		results, err := repo.FindPermissions(ctx, todoID)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = repo.FindGranted(ctx, "bob", nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		err = repo.DeletePermission(ctx, todoID, stored.ID)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		require.NoError(t, repo.DeletePermissions(ctx, todoID))
+	}
+
+	results, err := repo.FindGranted(acme, "bob", nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, stored.ID, results[0].ID)
+}
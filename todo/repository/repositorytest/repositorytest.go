@@ -0,0 +1,600 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+)
+
+// NewRepository - make an empty repository for a single test
+type NewRepository func(t *testing.T) todorepository.Repository
+
+// Run - run the conformance suite, every Repository implementation must behave the same
+func Run(t *testing.T, newRepository NewRepository) {
+	t.Run("store and find by id", func(t *testing.T) { testStore(t, newRepository(t)) })
+	t.Run("not found", func(t *testing.T) { testNotFound(t, newRepository(t)) })
+	t.Run("update", func(t *testing.T) { testUpdate(t, newRepository(t)) })
+	t.Run("patch", func(t *testing.T) { testPatch(t, newRepository(t)) })
+	t.Run("update status", func(t *testing.T) { testUpdateStatus(t, newRepository(t)) })
+	t.Run("tags", func(t *testing.T) { testTags(t, newRepository(t)) })
+	t.Run("delete", func(t *testing.T) { testDelete(t, newRepository(t)) })
+	t.Run("trash", func(t *testing.T) { testTrash(t, newRepository(t)) })
+	t.Run("purge deleted", func(t *testing.T) { testPurgeDeleted(t, newRepository(t)) })
+	t.Run("find all", func(t *testing.T) { testFindAll(t, newRepository(t)) })
+	t.Run("owner", func(t *testing.T) { testOwner(t, newRepository(t)) })
+	t.Run("batch", func(t *testing.T) { testBatch(t, newRepository(t)) })
+}
+
+func testStore(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	dueAt := time.Now().Add(time.Hour)
+
+	result, err := repo.Store(ctx, &models.Todo{
+		Title:       "Write report",
+		Description: "quarterly numbers",
+		Status:      models.StatusPending,
+		Priority:    models.PriorityMedium,
+		DueAt:       &dueAt,
+		Tags:        []string{"work"},
+	})
+	require.NoError(t, err)
+	assert.True(t, idutil.IsValid(result.ID))
+	assert.Equal(t, int64(1), result.Version)
+	assert.WithinDuration(t, time.Now(), result.CreatedAt, 5*time.Second)
+
+	todo, err := repo.FindById(ctx, result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.ID, todo.ID)
+	assert.Equal(t, "Write report", todo.Title)
+	assert.Equal(t, "quarterly numbers", todo.Description)
+	assert.Equal(t, models.StatusPending, todo.Status)
+	assert.Equal(t, models.PriorityMedium, todo.Priority)
+	assert.Equal(t, []string{"work"}, todo.Tags)
+	assert.Nil(t, todo.CompletedAt)
+	assert.Equal(t, int64(1), todo.Version)
+	require.NotNil(t, todo.DueAt)
+	assert.WithinDuration(t, dueAt, *todo.DueAt, time.Millisecond)
+	assert.WithinDuration(t, result.CreatedAt, todo.CreatedAt, time.Millisecond)
+	assert.WithinDuration(t, result.UpdatedAt, todo.UpdatedAt, time.Millisecond)
+
+	total, err := repo.CountFindByID(ctx, result.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func testNotFound(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+
+	for _, id := range []string{idutil.New(), "invalid"} {
+		_, err := repo.FindById(ctx, id)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.CountFindByID(ctx, id)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.Update(ctx, id, &models.Todo{Title: "a", Description: "a", Version: 1})
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.Patch(ctx, id, &models.TodoPatch{Fields: []string{"title"}, Todo: &models.Todo{Title: "a"}})
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.UpdateStatus(ctx, id, models.StatusDone, nil, 0)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.AddTags(ctx, id, []string{"a"})
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.RemoveTags(ctx, id, []string{"a"})
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		err = repo.Delete(ctx, id)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	}
+}
+
+func testUpdate(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	stored := store(t, repo, &models.Todo{Title: "a", Description: "a", Status: models.StatusInProgress, Tags: []string{"work"}})
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := repo.Update(ctx, stored.ID, &models.Todo{Title: "b", Description: "c", Priority: models.PriorityHigh})
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, result.ID)
+	assert.Equal(t, "b", result.Title)
+	assert.Equal(t, "c", result.Description)
+	assert.Equal(t, models.PriorityHigh, result.Priority)
+	assert.Equal(t, models.StatusInProgress, result.Status, "empty status is kept")
+	assert.Equal(t, []string{"work"}, result.Tags, "nil tags are kept")
+	assert.Equal(t, int64(2), result.Version)
+	assert.True(t, result.UpdatedAt.After(stored.CreatedAt))
+
+	_, err = repo.Update(ctx, stored.ID, &models.Todo{Title: "d", Description: "d", Version: 1})
+	assert.ErrorIs(t, err, errorsutil.ErrAborted)
+
+	result, err = repo.Update(ctx, stored.ID, &models.Todo{Title: "d", Description: "d", Tags: []string{}, Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Version)
+	assert.Empty(t, result.Tags)
+}
+
+func testPatch(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	dueAt := time.Now()
+	stored := store(t, repo, &models.Todo{Title: "a", Description: "a", Priority: models.PriorityLow, DueAt: &dueAt})
+
+	result, err := repo.Patch(ctx, stored.ID, &models.TodoPatch{
+		Fields: []string{"title", "due_at"},
+		Todo:   &models.Todo{Title: "b", Description: "ignored"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.Title)
+	assert.Equal(t, "a", result.Description)
+	assert.Equal(t, models.PriorityLow, result.Priority)
+	assert.Nil(t, result.DueAt)
+	assert.Equal(t, int64(2), result.Version)
+
+	_, err = repo.Patch(ctx, stored.ID, &models.TodoPatch{Fields: []string{"title"}, Todo: &models.Todo{Title: "c"}, Version: 1})
+	assert.ErrorIs(t, err, errorsutil.ErrAborted)
+}
+
+func testUpdateStatus(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	completedAt := time.Now()
+	stored := store(t, repo, &models.Todo{Title: "a", Description: "a", Status: models.StatusPending})
+
+	result, err := repo.UpdateStatus(ctx, stored.ID, models.StatusDone, &completedAt, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDone, result.Status)
+	require.NotNil(t, result.CompletedAt)
+	assert.WithinDuration(t, completedAt, *result.CompletedAt, time.Millisecond)
+	assert.Equal(t, int64(2), result.Version)
+
+	// the status was changed since version 1 was read
+	_, err = repo.UpdateStatus(ctx, stored.ID, models.StatusCancelled, nil, 1)
+	assert.ErrorIs(t, err, todorepository.ErrVersionConflict)
+
+	result, err = repo.FindById(ctx, stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDone, result.Status)
+
+	result, err = repo.UpdateStatus(ctx, stored.ID, models.StatusPending, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Version)
+}
+
+func testTags(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	stored := store(t, repo, &models.Todo{Title: "a", Description: "a", Tags: []string{"work"}})
+	store(t, repo, &models.Todo{Title: "b", Description: "b", Tags: []string{"home"}})
+
+	result, err := repo.AddTags(ctx, stored.ID, []string{"home", "work", "urgent"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work", "home", "urgent"}, result.Tags)
+	assert.Equal(t, int64(2), result.Version)
+
+	counts, err := repo.CountTags(ctx, &models.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Tag: "home", Count: 2},
+		{Tag: "urgent", Count: 1},
+		{Tag: "work", Count: 1},
+	}, counts)
+
+	result, err = repo.RemoveTags(ctx, stored.ID, []string{"work", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home", "urgent"}, result.Tags)
+}
+
+func testDelete(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	stored := store(t, repo, &models.Todo{Title: "a", Description: "a"})
+
+	err := repo.Delete(ctx, stored.ID)
+	require.NoError(t, err)
+
+	_, err = repo.FindById(ctx, stored.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	err = repo.Delete(ctx, stored.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.Update(ctx, stored.ID, &models.Todo{Title: "b", Description: "b"})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.AddTags(ctx, stored.ID, []string{"work"})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	err = repo.Delete(ctx, idutil.New())
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+}
+
+func testTrash(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	kept := store(t, repo, &models.Todo{Title: "kept", Description: "a", Tags: []string{"work"}})
+	first := store(t, repo, &models.Todo{Title: "first", Description: "a", Tags: []string{"work"}})
+	second := store(t, repo, &models.Todo{Title: "second", Description: "a", Tags: []string{"home"}})
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, repo.Delete(ctx, second.ID))
+
+	results, err := repo.FindAll(ctx, &models.TodoFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept"}, titles(results))
+
+	total, err := repo.CountFindAll(ctx, &models.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	counts, err := repo.CountTags(ctx, &models.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{{Tag: "work", Count: 1}}, counts)
+
+	// the trash lists the latest deleted first
+	trash, err := repo.FindAll(ctx, &models.TodoFilter{Deleted: true}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second", "first"}, titles(trash))
+	require.NotNil(t, trash[0].DeletedAt)
+	assert.WithinDuration(t, time.Now(), *trash[0].DeletedAt, 5*time.Second)
+
+	total, err = repo.CountFindAll(ctx, &models.TodoFilter{Deleted: true, Tags: []string{"home"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	restored, err := repo.Restore(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, []string{"work"}, restored.Tags)
+	assert.Greater(t, restored.Version, first.Version)
+
+	_, err = repo.Restore(ctx, first.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.FindById(ctx, first.ID)
+	assert.NoError(t, err)
+
+	// only todo in the trash can be purged
+	err = repo.Purge(ctx, kept.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	err = repo.Purge(ctx, second.ID)
+	require.NoError(t, err)
+
+	_, err = repo.Restore(ctx, second.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	trash, err = repo.FindAll(ctx, &models.TodoFilter{Deleted: true}, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func testPurgeDeleted(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	old := store(t, repo, &models.Todo{Title: "old", Description: "a", Tags: []string{"work"}})
+	store(t, repo, &models.Todo{Title: "kept", Description: "a"})
+
+	require.NoError(t, repo.Delete(ctx, old.ID))
+	time.Sleep(2 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(2 * time.Millisecond)
+
+	recent := store(t, repo, &models.Todo{Title: "recent", Description: "a"})
+	require.NoError(t, repo.Delete(ctx, recent.ID))
+
+	ids, err := repo.PurgeDeleted(ctx, before)
+	require.NoError(t, err)
+	assert.Equal(t, []string{old.ID}, ids)
+
+	trash, err := repo.FindAll(ctx, &models.TodoFilter{Deleted: true}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"recent"}, titles(trash))
+
+	results, err := repo.FindAll(ctx, &models.TodoFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept"}, titles(results))
+}
+
+func testOwner(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+
+	report := store(t, repo, &models.Todo{OwnerID: "alice", Title: "Write report", Tags: []string{"work"}})
+	milk := store(t, repo, &models.Todo{OwnerID: "bob", Title: "Buy milk", Tags: []string{"home"}})
+	book := store(t, repo, &models.Todo{Title: "Read book", Tags: []string{"home"}})
+	assert.Equal(t, "alice", report.OwnerID)
+	assert.Equal(t, "", book.OwnerID)
+
+	// the owner is kept by updates
+	updated, err := repo.Update(ctx, milk.ID, &models.Todo{Title: "Buy oat milk"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", updated.OwnerID)
+
+	results, err := repo.FindAll(ctx, &models.TodoFilter{OwnerID: "alice"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write report"}, titles(results))
+
+	total, err := repo.CountFindAll(ctx, &models.TodoFilter{OwnerID: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	counts, err := repo.CountTags(ctx, &models.TodoFilter{OwnerID: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{{Tag: "home", Count: 1}}, counts)
+
+	// todo shared with the owner are listed with their own
+	shared := &models.TodoFilter{OwnerID: "alice", SharedIDs: []string{milk.ID}, Sort: sortBy(t, "title")}
+	results, err = repo.FindAll(ctx, shared, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Buy oat milk", "Write report"}, titles(results))
+
+	counts, err = repo.CountTags(ctx, shared)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*models.TagCount{{Tag: "home", Count: 1}, {Tag: "work", Count: 1}}, counts)
+
+	results, err = repo.FindAll(ctx, &models.TodoFilter{IDs: []string{report.ID, book.ID}, Sort: sortBy(t, "title")}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Read book", "Write report"}, titles(results))
+
+	total, err = repo.CountFindAll(ctx, &models.TodoFilter{OwnerID: "alice", IDs: []string{milk.ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	total, err = repo.CountFindAll(ctx, &models.TodoFilter{IDs: []string{}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	// the trash is scoped the same way
+	require.NoError(t, repo.Delete(ctx, report.ID))
+	total, err = repo.CountFindAll(ctx, &models.TodoFilter{Deleted: true, OwnerID: "alice", IDs: []string{report.ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func testFindAll(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	report := store(t, repo, &models.Todo{Title: "Write report", Description: "quarterly numbers", Status: models.StatusPending, Priority: models.PriorityLow, DueAt: &past, Tags: []string{"work"}})
+	milk := store(t, repo, &models.Todo{Title: "Buy milk", Description: "groceries report", Status: models.StatusDone, Priority: models.PriorityHigh, DueAt: &recent, Tags: []string{"home"}})
+	trip := store(t, repo, &models.Todo{Title: "Plan trip", Description: "summer holiday", Status: models.StatusInProgress, Priority: models.PriorityMedium, DueAt: &future, Tags: []string{"home", "travel"}})
+	book := store(t, repo, &models.Todo{Title: "Read book", Description: "novel", Status: models.StatusPending, Priority: models.PriorityNone, Tags: []string{}})
+
+	find := func(t *testing.T, filter *models.TodoFilter, limit int, offset int) []string {
+		results, err := repo.FindAll(ctx, filter, limit, offset)
+		require.NoError(t, err)
+
+		return titles(results)
+	}
+
+	t.Run("default sort is latest updated first", func(t *testing.T) {
+		results, err := repo.FindAll(ctx, &models.TodoFilter{}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		for i := 1; i < len(results); i++ {
+			assert.False(t, results[i].UpdatedAt.After(results[i-1].UpdatedAt))
+		}
+
+		total, err := repo.CountFindAll(ctx, &models.TodoFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, total)
+	})
+
+	t.Run("keyword", func(t *testing.T) {
+		assert.Equal(t, []string{"Write report"}, find(t, &models.TodoFilter{Keyword: "REP"}, 10, 0))
+		assert.Empty(t, find(t, &models.TodoFilter{Keyword: "(.*"}, 10, 0))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		filter := &models.TodoFilter{Status: models.StatusPending, Sort: sortBy(t, "title")}
+		assert.Equal(t, []string{"Read book", "Write report"}, find(t, filter, 10, 0))
+
+		total, err := repo.CountFindAll(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("due date", func(t *testing.T) {
+		assert.Equal(t, []string{"Write report"}, find(t, &models.TodoFilter{Overdue: true}, 10, 0))
+		assert.Equal(t, []string{"Plan trip"}, find(t, &models.TodoFilter{DueFrom: &now}, 10, 0))
+		assert.Equal(t, []string{"Write report", "Buy milk"}, find(t, &models.TodoFilter{DueTo: &now, Sort: sortBy(t, "due_at")}, 10, 0))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		assert.Equal(t, []string{"Buy milk", "Plan trip"}, find(t, &models.TodoFilter{Tags: []string{"home"}, Sort: sortBy(t, "title")}, 10, 0))
+		assert.Equal(t, []string{"Plan trip"}, find(t, &models.TodoFilter{Tags: []string{"home", "travel"}, TagsMode: models.TagsModeAll}, 10, 0))
+
+		counts, err := repo.CountTags(ctx, &models.TodoFilter{Status: models.StatusDone})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.TagCount{{Tag: "home", Count: 1}}, counts)
+	})
+
+	t.Run("filter spec", func(t *testing.T) {
+		specs := map[string][]string{
+			"priority:gte:2":                  {"Buy milk", "Plan trip"},
+			"title:contains:BOOK":             {"Read book"},
+			"status:in:pending|done":          {"Buy milk", "Read book", "Write report"},
+			"tags:eq:travel":                  {"Plan trip"},
+			"tags:ne:home":                    {"Read book", "Write report"},
+			"due_at:ne:2000-01-01T00:00:00Z":  {"Buy milk", "Plan trip", "Read book", "Write report"},
+			"due_at:lt:2100-01-01T00:00:00Z":  {"Buy milk", "Plan trip", "Write report"},
+			"priority:lt:3,status:ne:pending": {"Plan trip"},
+		}
+
+		for spec, expected := range specs {
+			conditions, err := queryutil.ParseFilter(models.TodoSchema, spec)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, find(t, &models.TodoFilter{Conditions: conditions, Sort: sortBy(t, "title")}, 10, 0), spec)
+		}
+	})
+
+	t.Run("sort and pagination", func(t *testing.T) {
+		assert.Equal(t, []string{"Buy milk", "Plan trip", "Write report", "Read book"}, find(t, &models.TodoFilter{Sort: sortBy(t, "-priority")}, 10, 0))
+		assert.Equal(t, []string{"Plan trip", "Write report"}, find(t, &models.TodoFilter{Sort: sortBy(t, "-priority")}, 2, 1))
+		assert.Equal(t, []string{"Read book", "Write report", "Buy milk", "Plan trip"}, find(t, &models.TodoFilter{Sort: sortBy(t, "due_at")}, 10, 0), "unset sorts first")
+		assert.Empty(t, find(t, &models.TodoFilter{}, 10, 10))
+	})
+
+	t.Run("page token", func(t *testing.T) {
+		filter := &models.TodoFilter{Sort: sortBy(t, "-priority"), After: after(trip, sorted(t, "-priority"))}
+		assert.Equal(t, []string{"Write report", "Read book"}, find(t, filter, 10, 0))
+
+		total, err := repo.CountFindAll(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, total, "count ignores the page token")
+
+		filter = &models.TodoFilter{Sort: sortBy(t, "due_at"), After: after(book, sorted(t, "due_at"))}
+		assert.Equal(t, []string{"Write report", "Buy milk", "Plan trip"}, find(t, filter, 10, 0))
+
+		filter = &models.TodoFilter{Sort: sortBy(t, "-due_at"), After: after(milk, sorted(t, "-due_at"))}
+		assert.Equal(t, []string{"Write report", "Read book"}, find(t, filter, 10, 0))
+
+		filter = &models.TodoFilter{Sort: sortBy(t, "-due_at"), After: after(book, sorted(t, "-due_at"))}
+		assert.Empty(t, find(t, filter, 10, 0))
+
+		filter = &models.TodoFilter{Sort: sortBy(t, "status,title"), After: after(report, sorted(t, "status,title"))}
+		assert.Empty(t, find(t, filter, 10, 0))
+	})
+
+	t.Run("search", func(t *testing.T) {
+		results, err := repo.FindAll(ctx, &models.TodoFilter{Search: "report"}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Write report", "Buy milk"}, titles(results), "title matches weigh more")
+		assert.Greater(t, results[0].Score, results[1].Score)
+		assert.Greater(t, results[1].Score, float64(0))
+
+		assert.Equal(t, []string{"Buy milk", "Write report"}, find(t, &models.TodoFilter{Search: "report", Sort: sortBy(t, "-priority")}, 10, 0))
+		assert.Equal(t, []string{"Write report"}, find(t, &models.TodoFilter{Search: "report -groceries"}, 10, 0))
+	})
+}
+
+// testBatch - store, update and delete todo in batches, each item succeeds or fails on its own
+func testBatch(t *testing.T, repo todorepository.Repository) {
+	ctx := context.Background()
+
+	stored, err := repo.StoreMany(ctx, []*models.Todo{
+		{Title: "a", Description: "a", Status: models.StatusPending, Tags: []string{"work", "home"}},
+		{Title: "b", Description: "b", Status: models.StatusInProgress},
+		{Title: "c", Description: "c", Status: models.StatusPending},
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	for i, result := range stored {
+		require.NoError(t, result.Error)
+		assert.True(t, idutil.IsValid(result.ID))
+		assert.Equal(t, result.ID, result.Todo.ID)
+		assert.Equal(t, []string{"a", "b", "c"}[i], result.Todo.Title)
+		assert.Equal(t, int64(1), result.Todo.Version)
+	}
+	assert.Equal(t, []string{"work", "home"}, stored[0].Todo.Tags)
+
+	results, err := repo.StoreMany(ctx, []*models.Todo{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	found, err := repo.FindByIDs(ctx, []string{stored[1].ID, idutil.New(), "invalid", stored[0].ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, titles(found))
+
+	missing := idutil.New()
+	updated, err := repo.UpdateMany(ctx, []*models.Todo{
+		{ID: stored[0].ID, Title: "a2", Description: "a", Tags: []string{"home"}, Version: 1},
+		{ID: stored[1].ID, Title: "b2", Description: "b", Version: 2},
+		{ID: missing, Title: "d", Description: "d"},
+		{ID: stored[2].ID, Title: "c2", Description: "c", Status: models.StatusDone},
+	})
+	require.NoError(t, err)
+	require.Len(t, updated, 4)
+	require.NoError(t, updated[0].Error)
+	assert.Equal(t, "a2", updated[0].Todo.Title)
+	assert.Equal(t, []string{"home"}, updated[0].Todo.Tags)
+	assert.Equal(t, int64(2), updated[0].Todo.Version)
+	assert.ErrorIs(t, updated[1].Error, errorsutil.ErrAborted)
+	assert.Nil(t, updated[1].Todo)
+	assert.Equal(t, missing, updated[2].ID)
+	assert.ErrorIs(t, updated[2].Error, errorsutil.ErrNotFound)
+	require.NoError(t, updated[3].Error)
+	assert.Equal(t, models.StatusDone, updated[3].Todo.Status)
+
+	todo, err := repo.FindById(ctx, stored[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", todo.Title, "failed items are not written")
+
+	deleted, err := repo.DeleteMany(ctx, []string{stored[0].ID, missing, "invalid", stored[2].ID})
+	require.NoError(t, err)
+	require.Len(t, deleted, 4)
+	assert.NoError(t, deleted[0].Error)
+	assert.ErrorIs(t, deleted[1].Error, errorsutil.ErrNotFound)
+	assert.ErrorIs(t, deleted[2].Error, errorsutil.ErrNotFound)
+	assert.NoError(t, deleted[3].Error)
+
+	found, err = repo.FindByIDs(ctx, []string{stored[0].ID, stored[1].ID, stored[2].ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, titles(found))
+
+	deleted, err = repo.DeleteMany(ctx, []string{stored[0].ID})
+	require.NoError(t, err)
+	assert.ErrorIs(t, deleted[0].Error, errorsutil.ErrNotFound, "todo in the trash can not be deleted again")
+
+	trash, err := repo.FindAll(ctx, &models.TodoFilter{Deleted: true}, 0, 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a2", "c2"}, titles(trash))
+}
+
+// store - store todo and read it back, so values have the stored precision
+func store(t *testing.T, repo todorepository.Repository, value *models.Todo) *models.Todo {
+	result, err := repo.Store(context.Background(), value)
+	require.NoError(t, err)
+
+	result, err = repo.FindById(context.Background(), result.ID)
+	require.NoError(t, err)
+
+	return result
+}
+
+func titles(values []*models.Todo) []string {
+	results := []string{}
+	for _, item := range values {
+		results = append(results, item.Title)
+	}
+
+	return results
+}
+
+func sortBy(t *testing.T, spec string) []queryutil.SortField {
+	sort, err := queryutil.ParseSort(models.TodoSchema, spec)
+	require.NoError(t, err)
+
+	return sort
+}
+
+func sorted(t *testing.T, spec string) *models.TodoFilter {
+	return &models.TodoFilter{Sort: sortBy(t, spec)}
+}
+
+// after - page token cursor pointing after the todo, values are typed like a decoded token
+func after(todo *models.Todo, filter *models.TodoFilter) *paginationutil.Cursor {
+	values := []interface{}{}
+	for _, field := range filter.SortFields() {
+		values = append(values, todo.Value(field.Field.Key))
+	}
+
+	return &paginationutil.Cursor{
+		Sort:   queryutil.FormatSort(filter.SortFields()),
+		Values: values,
+		ID:     todo.ID,
+	}
+}
@@ -0,0 +1,160 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+)
+
+// NewRevisionRepository - make an empty revision repository for a single test
+type NewRevisionRepository func(t *testing.T) todorepository.RevisionRepository
+
+// RunRevisions - run the conformance suite of RevisionRepository implementations
+func RunRevisions(t *testing.T, newRepository NewRevisionRepository) {
+	t.Run("store and find revision", func(t *testing.T) { testStoreRevision(t, newRepository(t)) })
+	t.Run("find revisions", func(t *testing.T) { testFindRevisions(t, newRepository(t)) })
+	t.Run("tenant revisions", func(t *testing.T) { testTenantRevisions(t, newRepository(t)) })
+}
+
+func testStoreRevision(t *testing.T, repo todorepository.RevisionRepository) {
+	ctx := context.Background()
+	todoID := idutil.New()
+
+	result, err := repo.StoreRevision(ctx, &models.Revision{
+		TodoID:    todoID,
+		OwnerID:   "alice",
+		Version:   2,
+		Action:    models.ActionUpdate,
+		Actor:     "alice",
+		Transport: "http",
+		Changes: []*models.FieldChange{
+			{Field: "title", Before: "a", After: "b"},
+			{Field: "priority", Before: 0, After: 2},
+			{Field: "tags", Before: []string{}, After: []string{"work", "home"}},
+			{Field: "due_at", Before: nil, After: "2030-01-02T03:04:05Z"},
+		},
+		Todo: &models.Todo{ID: todoID, Title: "b", Status: models.StatusPending, Priority: 2, Tags: []string{"work", "home"}, Version: 2},
+	})
+	require.NoError(t, err)
+	assert.True(t, idutil.IsValid(result.ID))
+	assert.WithinDuration(t, time.Now(), result.CreatedAt, 5*time.Second)
+
+	revision, err := repo.FindRevision(ctx, todoID, result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.ID, revision.ID)
+	assert.Equal(t, todoID, revision.TodoID)
+	assert.Equal(t, "alice", revision.OwnerID)
+	assert.Equal(t, int64(2), revision.Version)
+	assert.Equal(t, models.ActionUpdate, revision.Action)
+	assert.Equal(t, "alice", revision.Actor)
+	assert.Equal(t, "http", revision.Transport)
+	assert.Equal(t, "", revision.Reverts)
+	assert.WithinDuration(t, result.CreatedAt, revision.CreatedAt, time.Millisecond)
+	assert.Equal(t, []*models.FieldChange{
+		{Field: "title", Before: "a", After: "b"},
+		{Field: "priority", Before: 0, After: 2},
+		{Field: "tags", Before: []string{}, After: []string{"work", "home"}},
+		{Field: "due_at", Before: nil, After: "2030-01-02T03:04:05Z"},
+	}, revision.Changes)
+	require.NotNil(t, revision.Todo)
+	assert.Equal(t, todoID, revision.Todo.ID)
+	assert.Equal(t, "b", revision.Todo.Title)
+	assert.Equal(t, []string{"work", "home"}, revision.Todo.Tags)
+
+	// a revision without snapshot, like the one of a delete
+	deleted, err := repo.StoreRevision(ctx, &models.Revision{TodoID: todoID, Version: 3, Action: models.ActionDelete, Reverts: result.ID})
+	require.NoError(t, err)
+
+	revision, err = repo.FindRevision(ctx, todoID, deleted.ID)
+	require.NoError(t, err)
+	assert.Nil(t, revision.Todo)
+	assert.Empty(t, revision.Changes)
+	assert.Equal(t, result.ID, revision.Reverts)
+
+	// revisions are only found with their todo
+	for _, id := range []string{idutil.New(), "invalid"} {
+		_, err = repo.FindRevision(ctx, todoID, id)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	}
+	_, err = repo.FindRevision(ctx, idutil.New(), result.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+}
+
+func testFindRevisions(t *testing.T, repo todorepository.RevisionRepository) {
+	ctx := context.Background()
+	todoID := idutil.New()
+	otherID := idutil.New()
+
+	for version := int64(1); version <= 3; version++ {
+		_, err := repo.StoreRevision(ctx, &models.Revision{TodoID: todoID, Version: version, Action: models.ActionUpdate})
+		require.NoError(t, err)
+	}
+	_, err := repo.StoreRevision(ctx, &models.Revision{TodoID: otherID, Version: 1, Action: models.ActionCreate})
+	require.NoError(t, err)
+
+	results, err := repo.FindRevisions(ctx, todoID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2, 1}, revisionVersions(results))
+
+	results, err = repo.FindRevisions(ctx, todoID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2, 1}, revisionVersions(results))
+
+	results, err = repo.FindRevisions(ctx, todoID, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, revisionVersions(results))
+
+	results, err = repo.FindRevisions(ctx, todoID, 10, 5)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	total, err := repo.CountRevisions(ctx, todoID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	total, err = repo.CountRevisions(ctx, idutil.New())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func revisionVersions(revisions []*models.Revision) []int64 {
+	results := []int64{}
+	for _, revision := range revisions {
+		results = append(results, revision.Version)
+	}
+
+	return results
+}
+
+func testTenantRevisions(t *testing.T, repo todorepository.RevisionRepository) {
+	acme, globex := tenantContexts()
+	todoID := idutil.New()
+
+	result, err := repo.StoreRevision(acme, &models.Revision{TodoID: todoID, Version: 1, Action: models.ActionCreate, Changes: []*models.FieldChange{}})
+	require.NoError(t, err)
+
+	for _, ctx := range []context.Context{globex, context.Background()} {
+		_, err = repo.FindRevision(ctx, todoID, result.ID)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		results, err := repo.FindRevisions(ctx, todoID, 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		total, err := repo.CountRevisions(ctx, todoID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+	}
+
+	total, err := repo.CountRevisions(acme, todoID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
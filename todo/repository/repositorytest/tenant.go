@@ -0,0 +1,147 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+// RunTenants - run the tenant isolation suite, a tenant can not read nor change the todo of another tenant
+func RunTenants(t *testing.T, newRepository NewRepository) {
+	t.Run("read", func(t *testing.T) { testTenantRead(t, newRepository(t)) })
+	t.Run("write", func(t *testing.T) { testTenantWrite(t, newRepository(t)) })
+	t.Run("trash", func(t *testing.T) { testTenantTrash(t, newRepository(t)) })
+}
+
+// tenantContexts - contexts of the acme and globex tenants
+func tenantContexts() (context.Context, context.Context) {
+	acme := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "acme"})
+	globex := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "globex"})
+
+	return acme, globex
+}
+
+func testTenantRead(t *testing.T, repo todorepository.Repository) {
+	acme, globex := tenantContexts()
+
+	stored, err := repo.Store(acme, &models.Todo{Title: "acme plan", Description: "secret", Status: models.StatusPending, Tags: []string{"work"}})
+	require.NoError(t, err)
+	_, err = repo.Store(globex, &models.Todo{Title: "globex plan", Description: "public", Status: models.StatusPending, Tags: []string{"home"}})
+	require.NoError(t, err)
+
+	// the default tenant is a tenant of its own
+	for _, ctx := range []context.Context{globex, context.Background()} {
+		_, err = repo.FindById(ctx, stored.ID)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		_, err = repo.CountFindByID(ctx, stored.ID)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+		results, err := repo.FindByIDs(ctx, []string{stored.ID})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		for _, filter := range []*models.TodoFilter{{}, {Search: "plan"}, {Tags: []string{"work"}}} {
+			results, err = repo.FindAll(ctx, filter, 0, 0)
+			require.NoError(t, err)
+			for _, item := range results {
+				assert.NotEqual(t, stored.ID, item.ID)
+			}
+
+			tags, err := repo.CountTags(ctx, filter)
+			require.NoError(t, err)
+			for _, item := range tags {
+				assert.NotEqual(t, "work", item.Tag)
+			}
+		}
+	}
+
+	total, err := repo.CountFindAll(globex, &models.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	total, err = repo.CountFindAll(context.Background(), &models.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	result, err := repo.FindById(acme, stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "acme plan", result.Title)
+}
+
+func testTenantWrite(t *testing.T, repo todorepository.Repository) {
+	acme, globex := tenantContexts()
+
+	stored, err := repo.Store(acme, &models.Todo{Title: "a", Description: "a", Status: models.StatusPending, Tags: []string{"work"}})
+	require.NoError(t, err)
+
+	_, err = repo.Update(globex, stored.ID, &models.Todo{Title: "b", Description: "b", Status: models.StatusPending})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.Patch(globex, stored.ID, &models.TodoPatch{Fields: []string{"title"}, Todo: &models.Todo{Title: "b"}})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.UpdateStatus(globex, stored.ID, models.StatusDone, nil, 0)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.AddTags(globex, stored.ID, []string{"home"})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	_, err = repo.RemoveTags(globex, stored.ID, []string{"work"})
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	updated, err := repo.UpdateMany(globex, []*models.Todo{{ID: stored.ID, Title: "b", Description: "b", Status: models.StatusPending}})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.ErrorIs(t, updated[0].Error, errorsutil.ErrNotFound)
+
+	err = repo.Delete(globex, stored.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	deleted, err := repo.DeleteMany(globex, []string{stored.ID})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.ErrorIs(t, deleted[0].Error, errorsutil.ErrNotFound)
+
+	result, err := repo.FindById(acme, stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.Title)
+	assert.Equal(t, models.StatusPending, result.Status)
+	assert.Equal(t, []string{"work"}, result.Tags)
+	assert.Equal(t, int64(1), result.Version)
+}
+
+func testTenantTrash(t *testing.T, repo todorepository.Repository) {
+	acme, globex := tenantContexts()
+
+	stored, err := repo.Store(acme, &models.Todo{Title: "a", Description: "a", Status: models.StatusPending})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(acme, stored.ID))
+
+	trash, err := repo.FindAll(globex, &models.TodoFilter{Deleted: true}, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	_, err = repo.Restore(globex, stored.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	err = repo.Purge(globex, stored.ID)
+	assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+
+	ids, err := repo.PurgeDeleted(globex, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	trash, err = repo.FindAll(acme, &models.TodoFilter{Deleted: true}, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, stored.ID, trash[0].ID)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-grpc/pkg/config"
+	models "go-clean-grpc/todo/models/http"
+	errorsutil "go-clean-grpc/utils/errors"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// RevisionRepository - append-only store of todo revisions
+type RevisionRepository interface {
+	StoreRevision(ctx context.Context, value *models.Revision) (*models.Revision, error)
+	FindRevisions(ctx context.Context, todoID string, limit int, offset int) ([]*models.Revision, error)
+	CountRevisions(ctx context.Context, todoID string) (int, error)
+	FindRevision(ctx context.Context, todoID string, id string) (*models.Revision, error)
+}
+
+// revisionDocument - revision as stored in mongo, keyed by object id
+type revisionDocument struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	models.Revision `bson:",inline"`
+}
+
+type RevisionRepositoryImpl struct {
+	client  *mongo.Client
+	timeout time.Duration
+}
+
+// NewRevisionRepository will create an object that represent the RevisionRepository interface
+func NewRevisionRepository(client *mongo.Client) RevisionRepository {
+	return &RevisionRepositoryImpl{
+		client:  client,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StoreRevision - store revision, the revision is never changed afterwards
+func (r *RevisionRepositoryImpl) StoreRevision(ctx context.Context, value *models.Revision) (*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_revision")
+
+	document := &revisionDocument{Revision: *value}
+	document.CreatedAt = timeutil.GetTimeNow()
+
+	res, err := collection.InsertOne(ctx, document)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	result := document.Revision
+	result.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	return &result, nil
+}
+
+// FindRevisions - find revisions of todo, latest first
+func (r *RevisionRepositoryImpl) FindRevisions(ctx context.Context, todoID string, limit int, offset int) ([]*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_revision")
+
+	findOptions := options.Find()
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+	findOptions.SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cur, err := collection.Find(ctx, bson.M{"todoId": todoID}, findOptions)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.Revision{}
+	for cur.Next(ctx) {
+		var elem revisionDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		results = append(results, elem.revision())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// CountRevisions - count revisions of todo
+func (r *RevisionRepositoryImpl) CountRevisions(ctx context.Context, todoID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo_revision")
+
+	total, err := collection.CountDocuments(ctx, bson.M{"todoId": todoID})
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return int(total), nil
+}
+
+// FindRevision - find revision of todo by id
+func (r *RevisionRepositoryImpl) FindRevision(ctx context.Context, todoID string, id string) (*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo_revision")
+
+	result := &revisionDocument{}
+	err = collection.FindOne(ctx, bson.M{"_id": docID, "todoId": todoID}).Decode(result)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return result.revision(), nil
+}
+
+// revision - revision of document, decoded change values are made JSON friendly like the other repositories
+func (d *revisionDocument) revision() *models.Revision {
+	value := d.Revision
+	value.ID = d.ID.Hex()
+	if value.Todo != nil {
+		value.Todo.ID = value.TodoID
+	}
+	for _, change := range value.Changes {
+		change.Before = plainValue(change.Before)
+		change.After = plainValue(change.After)
+	}
+
+	return &value
+}
+
+// plainValue - replace bson array and integer types with their plain Go types
+func plainValue(value interface{}) interface{} {
+	switch item := value.(type) {
+	case primitive.A:
+		results := []string{}
+		for _, element := range item {
+			text, _ := element.(string)
+			results = append(results, text)
+		}
+
+		return results
+	case int32:
+		return int(item)
+	case int64:
+		return int(item)
+	}
+
+	return value
+}
@@ -0,0 +1,201 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	"go-clean-grpc/pkg/config"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+const apiKeyColumns = "id, name, hash, prefix, user_id, tenant, scopes, created_by, expires_at, revoked_at, last_used_at, created_at"
+
+type APIKeyRepositoryImpl struct {
+	db      *sql.DB
+	dialect string
+	timeout time.Duration
+}
+
+// NewAPIKeyRepository will create a sql object that represent the APIKeyRepository interface
+func NewAPIKeyRepository(db *sql.DB, dialect string) todorepository.APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		db:      db,
+		dialect: dialect,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StoreKey - store new API key, the hash is unique
+func (r *APIKeyRepositoryImpl) StoreKey(ctx context.Context, value *pkgauth.APIKey) (*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := *value
+	result.ID = idutil.New()
+	result.RevokedAt = nil
+	result.LastUsedAt = nil
+	result.CreatedAt = timeutil.GetTimeNow().UTC().Truncate(time.Millisecond)
+	result.Scopes = append([]string{}, value.Scopes...)
+	if value.ExpiresAt != nil {
+		expiresAt := value.ExpiresAt.UTC().Truncate(time.Millisecond)
+		result.ExpiresAt = &expiresAt
+	}
+
+	scopes, err := json.Marshal(result.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		r.rebind("INSERT INTO api_key ("+apiKeyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		result.ID,
+		result.Name,
+		result.Hash,
+		result.Prefix,
+		result.UserID,
+		result.Tenant,
+		string(scopes),
+		result.CreatedBy,
+		dbValue(r.dialect, result.ExpiresAt),
+		nil,
+		nil,
+		dbValue(r.dialect, result.CreatedAt),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &result, nil
+}
+
+// FindKeyByHash - find API key by the hash of the key
+func (r *APIKeyRepositoryImpl) FindKeyByHash(ctx context.Context, hash string) (*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.queryOne(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE hash = ?", hash)
+}
+
+// FindKeys - find API keys of the user in the tenant of ctx, of every user when empty, latest created first
+func (r *APIKeyRepositoryImpl) FindKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tenant := tenantutil.ID(ctx)
+	if userID == "" {
+		return r.query(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE tenant = ? ORDER BY created_at DESC, id DESC", tenant)
+	}
+
+	return r.query(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE tenant = ? AND user_id = ? ORDER BY created_at DESC, id DESC", tenant, userID)
+}
+
+// TouchKey - set the last use of the API key by id
+func (r *APIKeyRepositoryImpl) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, r.rebind("UPDATE api_key SET last_used_at = ? WHERE id = ?"), dbValue(r.dialect, usedAt), id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+
+	if affected == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeKey - revoke the API key by id in the tenant of ctx, a revoked key keeps its first revocation time
+func (r *APIKeyRepositoryImpl) RevokeKey(ctx context.Context, id string, revokedAt time.Time) (*pkgauth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tenant := tenantutil.ID(ctx)
+	_, err := r.db.ExecContext(ctx, r.rebind("UPDATE api_key SET revoked_at = ? WHERE id = ? AND tenant = ? AND revoked_at IS NULL"), dbValue(r.dialect, revokedAt), id, tenant)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return r.queryOne(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE id = ? AND tenant = ?", id, tenant)
+}
+
+func (r *APIKeyRepositoryImpl) queryOne(ctx context.Context, query string, args ...interface{}) (*pkgauth.APIKey, error) {
+	results, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	return results[0], nil
+}
+
+func (r *APIKeyRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*pkgauth.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	results := []*pkgauth.APIKey{}
+	for rows.Next() {
+		item := &pkgauth.APIKey{}
+		var scopes string
+		var expiresAt, revokedAt, lastUsedAt sql.NullTime
+		err := rows.Scan(
+			&item.ID,
+			&item.Name,
+			&item.Hash,
+			&item.Prefix,
+			&item.UserID,
+			&item.Tenant,
+			&scopes,
+			&item.CreatedBy,
+			&expiresAt,
+			&revokedAt,
+			&lastUsedAt,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		err = json.Unmarshal([]byte(scopes), &item.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		item.ExpiresAt = nullTime(expiresAt)
+		item.RevokedAt = nullTime(revokedAt)
+		item.LastUsedAt = nullTime(lastUsedAt)
+		item.CreatedAt = item.CreatedAt.UTC()
+
+		results = append(results, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+func (r *APIKeyRepositoryImpl) rebind(query string) string {
+	return pkgsqldb.Rebind(r.dialect, query)
+}
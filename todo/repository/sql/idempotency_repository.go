@@ -0,0 +1,188 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-clean-grpc/pkg/config"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+type IdempotencyRepositoryImpl struct {
+	db      *pkgsqldb.Tenants
+	dialect string
+	timeout time.Duration
+}
+
+// NewIdempotencyRepository will create a sql object that represent the IdempotencyRepository interface
+func NewIdempotencyRepository(db *pkgsqldb.Tenants) todorepository.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		db:      db,
+		dialect: db.Dialect(),
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Reserve - store record unless the key has a record that did not expire, which is returned instead
+// expired records are deleted on the way
+func (r *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *idempotencyutil.Record) (*idempotencyutil.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.rebind("DELETE FROM idempotency_key WHERE expires_at <= ?"), dbValue(r.dialect, timeutil.GetTimeNow()))
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		r.rebind("INSERT INTO idempotency_key (id, fingerprint, created_at, expires_at) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO NOTHING"),
+		record.Key,
+		record.Fingerprint,
+		dbValue(r.dialect, record.CreatedAt),
+		dbValue(r.dialect, record.ExpiresAt),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	var existing *idempotencyutil.Record
+	if inserted == 0 {
+		existing, err = r.find(ctx, tx, record.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return existing, nil
+}
+
+// Complete - set the response and expiry of the record of the key
+func (r *IdempotencyRepositoryImpl) Complete(ctx context.Context, key string, response *idempotencyutil.Response, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	body := response.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	res, err := db.ExecContext(
+		ctx,
+		r.rebind("UPDATE idempotency_key SET status = ?, header = ?, body = ?, expires_at = ? WHERE id = ?"),
+		response.Status,
+		string(header),
+		body,
+		dbValue(r.dialect, expiresAt),
+		key,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+
+	if updated == 0 {
+		return mapError(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Release - delete the record of the key, so the request can be retried
+func (r *IdempotencyRepositoryImpl) Release(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, r.rebind("DELETE FROM idempotency_key WHERE id = ?"), key)
+
+	return mapError(err)
+}
+
+func (r *IdempotencyRepositoryImpl) find(ctx context.Context, tx *sql.Tx, key string) (*idempotencyutil.Record, error) {
+	var status sql.NullInt64
+	var header sql.NullString
+	var body []byte
+	result := &idempotencyutil.Record{}
+
+	err := tx.QueryRowContext(
+		ctx,
+		r.rebind("SELECT id, fingerprint, status, header, body, created_at, expires_at FROM idempotency_key WHERE id = ?"),
+		key,
+	).Scan(&result.Key, &result.Fingerprint, &status, &header, &body, &result.CreatedAt, &result.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorsutil.Wrap(errorsutil.KindUnavailable, "idempotency key was released, retry the request", err)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	result.CreatedAt = result.CreatedAt.UTC()
+	result.ExpiresAt = result.ExpiresAt.UTC()
+
+	if status.Valid {
+		result.Response = &idempotencyutil.Response{
+			Status: int(status.Int64),
+			Body:   body,
+		}
+
+		if header.Valid {
+			err := json.Unmarshal([]byte(header.String), &result.Response.Header)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (r *IdempotencyRepositoryImpl) rebind(query string) string {
+	return pkgsqldb.Rebind(r.dialect, query)
+}
@@ -0,0 +1,26 @@
+-- text columns use the "C" collation so ordering is by code point, like mongodb and sqlite
+CREATE TABLE todo (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	title TEXT COLLATE "C" NOT NULL,
+	description TEXT COLLATE "C" NOT NULL DEFAULT '',
+	status TEXT COLLATE "C" NOT NULL DEFAULT 'pending',
+	completed_at TIMESTAMPTZ NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	due_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	version BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX todo_updated_at ON todo (updated_at, id);
+CREATE INDEX todo_status ON todo (status);
+CREATE INDEX todo_due_at ON todo (due_at);
+
+CREATE TABLE todo_tag (
+	todo_id TEXT COLLATE "C" NOT NULL REFERENCES todo (id) ON DELETE CASCADE,
+	tag TEXT COLLATE "C" NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (todo_id, tag)
+);
+
+CREATE INDEX todo_tag_tag ON todo_tag (tag);
@@ -0,0 +1,3 @@
+ALTER TABLE todo ADD COLUMN deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX todo_deleted_at ON todo (deleted_at);
@@ -0,0 +1,15 @@
+-- revisions are kept when their todo is purged, so todo_id has no foreign key
+CREATE TABLE todo_revision (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	todo_id TEXT COLLATE "C" NOT NULL,
+	version BIGINT NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	transport TEXT NOT NULL,
+	changes TEXT NOT NULL,
+	todo TEXT NULL,
+	reverts TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX todo_revision_todo_id_created_at ON todo_revision (todo_id, created_at, id);
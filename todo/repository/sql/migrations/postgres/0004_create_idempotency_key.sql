@@ -0,0 +1,12 @@
+-- status, header and body are the response, NULL while the request is in progress
+CREATE TABLE idempotency_key (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	status INTEGER NULL,
+	header TEXT NULL,
+	body BYTEA NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idempotency_key_expires_at ON idempotency_key (expires_at);
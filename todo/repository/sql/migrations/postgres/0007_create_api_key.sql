@@ -0,0 +1,16 @@
+-- scopes is a JSON array, expires_at is NULL for the keys that never expire
+CREATE TABLE api_key (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	name TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE,
+	prefix TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	created_by TEXT NOT NULL DEFAULT '',
+	expires_at TIMESTAMPTZ NULL,
+	revoked_at TIMESTAMPTZ NULL,
+	last_used_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX api_key_user_id_created_at ON api_key (user_id, created_at);
@@ -0,0 +1,25 @@
+CREATE TABLE todo (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	completed_at TIMESTAMP NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	due_at TIMESTAMP NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	version BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX todo_updated_at ON todo (updated_at, id);
+CREATE INDEX todo_status ON todo (status);
+CREATE INDEX todo_due_at ON todo (due_at);
+
+CREATE TABLE todo_tag (
+	todo_id TEXT NOT NULL REFERENCES todo (id) ON DELETE CASCADE,
+	tag TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (todo_id, tag)
+);
+
+CREATE INDEX todo_tag_tag ON todo_tag (tag);
@@ -0,0 +1,12 @@
+-- status, header and body are the response, NULL while the request is in progress
+CREATE TABLE idempotency_key (
+	id TEXT PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	status INTEGER NULL,
+	header TEXT NULL,
+	body BLOB NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idempotency_key_expires_at ON idempotency_key (expires_at);
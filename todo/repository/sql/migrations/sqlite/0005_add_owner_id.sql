@@ -0,0 +1,4 @@
+ALTER TABLE todo ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE todo_revision ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX todo_owner_id ON todo (owner_id);
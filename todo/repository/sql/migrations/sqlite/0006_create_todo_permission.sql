@@ -0,0 +1,15 @@
+-- user_id and group_name are '' when the permission is granted to the other one
+CREATE TABLE todo_permission (
+	id TEXT PRIMARY KEY,
+	todo_id TEXT NOT NULL,
+	user_id TEXT NOT NULL DEFAULT '',
+	group_name TEXT NOT NULL DEFAULT '',
+	role TEXT NOT NULL,
+	granted_by TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	UNIQUE (todo_id, user_id, group_name)
+);
+
+CREATE INDEX todo_permission_user_id ON todo_permission (user_id);
+CREATE INDEX todo_permission_group_name ON todo_permission (group_name);
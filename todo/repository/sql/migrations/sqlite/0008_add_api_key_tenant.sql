@@ -0,0 +1,4 @@
+-- keys created before tenancy belong to the default tenant
+ALTER TABLE api_key ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX api_key_tenant_user_id_created_at ON api_key (tenant, user_id, created_at);
@@ -0,0 +1,199 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-clean-grpc/pkg/config"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+const permissionColumns = "id, todo_id, user_id, group_name, role, granted_by, created_at, updated_at"
+
+type PermissionRepositoryImpl struct {
+	db      *pkgsqldb.Tenants
+	dialect string
+	timeout time.Duration
+}
+
+// NewPermissionRepository will create a sql object that represent the PermissionRepository interface
+func NewPermissionRepository(db *pkgsqldb.Tenants) todorepository.PermissionRepository {
+	return &PermissionRepositoryImpl{
+		db:      db,
+		dialect: db.Dialect(),
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StorePermission - grant the role of value, replacing the role already granted to the user or group on the todo
+func (r *PermissionRepositoryImpl) StorePermission(ctx context.Context, value *models.Permission) (*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := timeutil.GetTimeNow().UTC().Truncate(time.Millisecond)
+
+	_, err = db.ExecContext(
+		ctx,
+		r.rebind("INSERT INTO todo_permission ("+permissionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?) "+
+			"ON CONFLICT (todo_id, user_id, group_name) DO UPDATE SET "+
+			"role = excluded.role, granted_by = excluded.granted_by, updated_at = excluded.updated_at"),
+		idutil.New(),
+		value.TodoID,
+		value.UserID,
+		value.Group,
+		value.Role,
+		value.GrantedBy,
+		dbValue(r.dialect, timeNow),
+		dbValue(r.dialect, timeNow),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	results, err := r.query(
+		ctx,
+		"SELECT "+permissionColumns+" FROM todo_permission WHERE todo_id = ? AND user_id = ? AND group_name = ?",
+		value.TodoID,
+		value.UserID,
+		value.Group,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, mapError(sql.ErrNoRows)
+	}
+
+	return results[0], nil
+}
+
+// FindPermissions - find permissions granted on todo, oldest first
+func (r *PermissionRepositoryImpl) FindPermissions(ctx context.Context, todoID string) ([]*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(ctx, "SELECT "+permissionColumns+" FROM todo_permission WHERE todo_id = ? ORDER BY created_at, id", todoID)
+}
+
+// FindGranted - find permissions granted to the user or to one of the groups
+func (r *PermissionRepositoryImpl) FindGranted(ctx context.Context, userID string, groups []string) ([]*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	principals := []string{}
+	args := []interface{}{}
+	if userID != "" {
+		principals = append(principals, "user_id = ?")
+		args = append(args, userID)
+	}
+	if len(groups) > 0 {
+		principals = append(principals, "group_name IN ("+placeholders(len(groups))+")")
+		args = append(args, stringArgs(groups)...)
+	}
+	if len(principals) == 0 {
+		return []*models.Permission{}, nil
+	}
+
+	return r.query(ctx, "SELECT "+permissionColumns+" FROM todo_permission WHERE "+strings.Join(principals, " OR ")+" ORDER BY created_at, id", args...)
+}
+
+// DeletePermission - revoke permission by id of todo
+func (r *PermissionRepositoryImpl) DeletePermission(ctx context.Context, todoID string, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.ExecContext(ctx, r.rebind("DELETE FROM todo_permission WHERE todo_id = ? AND id = ?"), todoID, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+
+	if affected == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeletePermissions - revoke every permission of todo
+func (r *PermissionRepositoryImpl) DeletePermissions(ctx context.Context, todoID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, r.rebind("DELETE FROM todo_permission WHERE todo_id = ?"), todoID)
+
+	return mapError(err)
+}
+
+func (r *PermissionRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*models.Permission, error) {
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	results := []*models.Permission{}
+	for rows.Next() {
+		item := &models.Permission{}
+		err := rows.Scan(
+			&item.ID,
+			&item.TodoID,
+			&item.UserID,
+			&item.Group,
+			&item.Role,
+			&item.GrantedBy,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		item.CreatedAt = item.CreatedAt.UTC()
+		item.UpdatedAt = item.UpdatedAt.UTC()
+
+		results = append(results, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+func (r *PermissionRepositoryImpl) rebind(query string) string {
+	return pkgsqldb.Rebind(r.dialect, query)
+}
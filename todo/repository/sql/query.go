@@ -0,0 +1,308 @@
+package sqlrepository
+
+import (
+	"strings"
+	"time"
+
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	models "go-clean-grpc/todo/models/http"
+	idutil "go-clean-grpc/utils/id"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+)
+
+// columns - todo column of document key of models.TodoSchema, tags are kept in the todo_tag table
+var columns = map[string]string{
+	"title":       "title",
+	"description": "description",
+	"status":      "status",
+	"priority":    "priority",
+	"dueAt":       "due_at",
+	"completedAt": "completed_at",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+	"deletedAt":   "deleted_at",
+}
+
+const tagsKey = "tags"
+
+// sqliteTimeLayout - fixed width UTC layout, so stored times compare as text
+const sqliteTimeLayout = "2006-01-02 15:04:05.000"
+
+// builder - conditions of a WHERE clause with their arguments
+type builder struct {
+	dialect    string
+	conditions []string
+	args       []interface{}
+}
+
+func (b *builder) add(condition string, args ...interface{}) {
+	b.conditions = append(b.conditions, condition)
+	for _, arg := range args {
+		b.args = append(b.args, dbValue(b.dialect, arg))
+	}
+}
+
+// where - WHERE clause of the conditions, empty without conditions
+func (b *builder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// buildFilter - build sql conditions from todo filter, mirrors buildFilter of the mongo repository
+func buildFilter(b *builder, filter *models.TodoFilter, now time.Time) {
+	// deleted todo are only listed in the trash
+	if filter.Deleted {
+		b.add("deleted_at IS NOT NULL")
+	} else {
+		b.add("deleted_at IS NULL")
+	}
+
+	switch {
+	case filter.OwnerID == "":
+	case len(filter.SharedIDs) > 0:
+		b.add("(owner_id = ? OR id IN ("+placeholders(len(filter.SharedIDs))+"))", append([]interface{}{filter.OwnerID}, stringArgs(filter.SharedIDs)...)...)
+	default:
+		b.add("owner_id = ?", filter.OwnerID)
+	}
+
+	if filter.IDs != nil {
+		if len(filter.IDs) == 0 {
+			b.add("1 = 0")
+		} else {
+			b.add("id IN ("+placeholders(len(filter.IDs))+")", stringArgs(filter.IDs)...)
+		}
+	}
+
+	if filter.Keyword != "" {
+		b.add(`LOWER(title) LIKE ? ESCAPE '\'`, likePattern(filter.Keyword))
+	}
+
+	if filter.Search != "" {
+		buildSearch(b, filter.Search)
+	}
+
+	if filter.Status != "" {
+		b.add("status = ?", filter.Status)
+	}
+
+	if filter.Overdue {
+		b.add("due_at < ? AND status NOT IN (?, ?)", now, models.StatusDone, models.StatusCancelled)
+	}
+
+	if filter.DueFrom != nil {
+		b.add("due_at >= ?", *filter.DueFrom)
+	}
+
+	if filter.DueTo != nil {
+		b.add("due_at <= ?", *filter.DueTo)
+	}
+
+	if len(filter.Tags) > 0 {
+		if filter.TagsMode == models.TagsModeAll {
+			for _, tag := range filter.Tags {
+				b.add(tagExists("tag = ?"), tag)
+			}
+		} else {
+			b.add(tagExists("tag IN ("+placeholders(len(filter.Tags))+")"), stringArgs(filter.Tags)...)
+		}
+	}
+
+	for _, condition := range filter.Conditions {
+		buildCondition(b, condition)
+	}
+}
+
+// buildSearch - match todo containing any searched word, the text score is computed on the results
+func buildSearch(b *builder, search string) {
+	matches := []string{}
+	args := []interface{}{}
+	for _, term := range queryutil.SearchTerms(search) {
+		if term.Negated {
+			continue
+		}
+
+		matches = append(matches, `LOWER(title) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\'`)
+		args = append(args, likePattern(term.Word), likePattern(term.Word))
+	}
+
+	// only negated words never match, like a mongo $text search
+	if len(matches) == 0 {
+		b.add("1 = 0")
+		return
+	}
+
+	b.add("("+strings.Join(matches, " OR ")+")", args...)
+}
+
+// buildCondition - build sql condition from filter spec condition, tags match when any tag matches
+func buildCondition(b *builder, condition queryutil.Condition) {
+	if condition.Field.Key == tagsKey {
+		if condition.Operator == queryutil.OpNe {
+			b.add("NOT "+tagExists("tag = ?"), condition.Values[0])
+			return
+		}
+
+		expression, args := operatorCondition("tag", condition)
+		b.add(tagExists(expression), args...)
+		return
+	}
+
+	column := columns[condition.Field.Key]
+	if condition.Operator == queryutil.OpNe {
+		b.add("("+column+" <> ? OR "+column+" IS NULL)", condition.Values[0])
+		return
+	}
+
+	expression, args := operatorCondition(column, condition)
+	b.add(expression, args...)
+}
+
+func operatorCondition(column string, condition queryutil.Condition) (string, []interface{}) {
+	value := condition.Values[0]
+
+	switch condition.Operator {
+	case queryutil.OpGt:
+		return column + " > ?", []interface{}{value}
+	case queryutil.OpGte:
+		return column + " >= ?", []interface{}{value}
+	case queryutil.OpLt:
+		return column + " < ?", []interface{}{value}
+	case queryutil.OpLte:
+		return column + " <= ?", []interface{}{value}
+	case queryutil.OpIn:
+		return column + " IN (" + placeholders(len(condition.Values)) + ")", condition.Values
+	case queryutil.OpContains:
+		return "LOWER(" + column + `) LIKE ? ESCAPE '\'`, []interface{}{likePattern(value.(string))}
+	}
+
+	return column + " = ?", []interface{}{value}
+}
+
+// buildOrderBy - build sql order from sort fields, unset values sort first ascending and last descending
+// relevance has no sql order, id keeps the order stable until results are scored
+func buildOrderBy(sortFields []queryutil.SortField) string {
+	order := []string{}
+	for _, field := range sortFields {
+		if field.Desc {
+			order = append(order, sortExpression(field)+" DESC NULLS LAST")
+		} else {
+			order = append(order, sortExpression(field)+" ASC NULLS FIRST")
+		}
+	}
+
+	return strings.Join(append(order, "id ASC"), ", ")
+}
+
+// sortExpression - sorted value of field, tags sort by their lowest tag ascending and highest descending
+func sortExpression(field queryutil.SortField) string {
+	if field.Field.Key != tagsKey {
+		return columns[field.Field.Key]
+	}
+
+	if field.Desc {
+		return "(SELECT MAX(tag) FROM todo_tag WHERE todo_tag.todo_id = todo.id)"
+	}
+
+	return "(SELECT MIN(tag) FROM todo_tag WHERE todo_tag.todo_id = todo.id)"
+}
+
+// buildAfter - keyset condition matching todo after the cursor in the sort order, id breaks ties
+func buildAfter(b *builder, sortFields []queryutil.SortField, cursor *paginationutil.Cursor) error {
+	if !idutil.IsValid(cursor.ID) {
+		return paginationutil.ErrInvalidPageToken
+	}
+
+	branches := []string{}
+	args := []interface{}{}
+	equals := []string{}
+	equalArgs := []interface{}{}
+	for i, field := range sortFields {
+		expression := sortExpression(field)
+		value := cursor.Values[i]
+
+		if after, afterArgs := afterCondition(expression, field.Desc, value); after != "" {
+			branches = append(branches, "("+strings.Join(append(append([]string{}, equals...), after), " AND ")+")")
+			args = append(append(args, equalArgs...), afterArgs...)
+		}
+
+		if value == nil {
+			equals = append(equals, expression+" IS NULL")
+		} else {
+			equals = append(equals, expression+" = ?")
+			equalArgs = append(equalArgs, value)
+		}
+	}
+	branches = append(branches, "("+strings.Join(append(equals, "id > ?"), " AND ")+")")
+	args = append(append(args, equalArgs...), cursor.ID)
+
+	b.add("("+strings.Join(branches, " OR ")+")", args...)
+
+	return nil
+}
+
+// afterCondition - condition of values after the given one, unset values sort before any other value
+func afterCondition(expression string, desc bool, value interface{}) (string, []interface{}) {
+	switch {
+	case !desc && value == nil:
+		return expression + " IS NOT NULL", nil
+	case !desc:
+		return expression + " > ?", []interface{}{value}
+	case value == nil:
+		return "", nil
+	}
+
+	return "(" + expression + " < ? OR " + expression + " IS NULL)", []interface{}{value}
+}
+
+func tagExists(condition string) string {
+	return "EXISTS (SELECT 1 FROM todo_tag WHERE todo_tag.todo_id = todo.id AND " + condition + ")"
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func stringArgs(values []string) []interface{} {
+	results := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		results = append(results, value)
+	}
+
+	return results
+}
+
+// likePattern - case-insensitive LIKE pattern containing value, wildcards in value match literally
+func likePattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(value))
+
+	return "%" + escaped + "%"
+}
+
+// dbValue - argument of value, times are stored in UTC with the millisecond precision of MongoDB
+func dbValue(dialect string, value interface{}) interface{} {
+	switch item := value.(type) {
+	case time.Time:
+		return dbTime(dialect, item)
+	case *time.Time:
+		if item == nil {
+			return nil
+		}
+
+		return dbTime(dialect, *item)
+	}
+
+	return value
+}
+
+func dbTime(dialect string, value time.Time) interface{} {
+	value = value.UTC().Truncate(time.Millisecond)
+	if dialect == pkgsqldb.DialectSQLite {
+		return value.Format(sqliteTimeLayout)
+	}
+
+	return value
+}
@@ -0,0 +1,240 @@
+package sqlrepository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"go-clean-grpc/pkg/config"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	idutil "go-clean-grpc/utils/id"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+const revisionColumns = "id, todo_id, owner_id, version, action, actor, transport, changes, todo, reverts, created_at"
+
+type RevisionRepositoryImpl struct {
+	db      *pkgsqldb.Tenants
+	dialect string
+	timeout time.Duration
+}
+
+// NewRevisionRepository will create a sql object that represent the RevisionRepository interface
+func NewRevisionRepository(db *pkgsqldb.Tenants) todorepository.RevisionRepository {
+	return &RevisionRepositoryImpl{
+		db:      db,
+		dialect: db.Dialect(),
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// StoreRevision - store revision, the revision is never changed afterwards
+func (r *RevisionRepositoryImpl) StoreRevision(ctx context.Context, value *models.Revision) (*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *value
+	result.ID = idutil.New()
+	result.CreatedAt = timeutil.GetTimeNow().UTC().Truncate(time.Millisecond)
+	if result.Changes == nil {
+		result.Changes = []*models.FieldChange{}
+	}
+
+	changes, err := json.Marshal(result.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var todo interface{}
+	if result.Todo != nil {
+		data, err := json.Marshal(result.Todo)
+		if err != nil {
+			return nil, err
+		}
+		todo = string(data)
+	}
+
+	_, err = db.ExecContext(
+		ctx,
+		r.rebind("INSERT INTO todo_revision ("+revisionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		result.ID,
+		result.TodoID,
+		result.OwnerID,
+		result.Version,
+		result.Action,
+		result.Actor,
+		result.Transport,
+		string(changes),
+		todo,
+		result.Reverts,
+		dbValue(r.dialect, result.CreatedAt),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &result, nil
+}
+
+// FindRevisions - find revisions of todo, latest first
+func (r *RevisionRepositoryImpl) FindRevisions(ctx context.Context, todoID string, limit int, offset int) ([]*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(
+		ctx,
+		"SELECT "+revisionColumns+" FROM todo_revision WHERE todo_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+		todoID,
+		sqlLimit(limit),
+		offset,
+	)
+}
+
+// CountRevisions - count revisions of todo
+func (r *RevisionRepositoryImpl) CountRevisions(ctx context.Context, todoID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	err = db.QueryRowContext(ctx, r.rebind("SELECT COUNT(*) FROM todo_revision WHERE todo_id = ?"), todoID).Scan(&total)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return total, nil
+}
+
+// FindRevision - find revision of todo by id
+func (r *RevisionRepositoryImpl) FindRevision(ctx context.Context, todoID string, id string) (*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	results, err := r.query(ctx, "SELECT "+revisionColumns+" FROM todo_revision WHERE todo_id = ? AND id = ?", todoID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, mapError(sql.ErrNoRows)
+	}
+
+	return results[0], nil
+}
+
+func (r *RevisionRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*models.Revision, error) {
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	results := []*models.Revision{}
+	for rows.Next() {
+		var changes string
+		var todo sql.NullString
+		item := &models.Revision{}
+		err := rows.Scan(
+			&item.ID,
+			&item.TodoID,
+			&item.OwnerID,
+			&item.Version,
+			&item.Action,
+			&item.Actor,
+			&item.Transport,
+			&changes,
+			&todo,
+			&item.Reverts,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		item.CreatedAt = item.CreatedAt.UTC()
+
+		item.Changes, err = decodeChanges(changes)
+		if err != nil {
+			return nil, err
+		}
+
+		if todo.Valid {
+			item.Todo = &models.Todo{}
+			err := json.Unmarshal([]byte(todo.String), item.Todo)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		results = append(results, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+func (r *RevisionRepositoryImpl) rebind(query string) string {
+	return pkgsqldb.Rebind(r.dialect, query)
+}
+
+// decodeChanges - decode JSON changes, numbers decode to int and lists to []string like they were stored
+func decodeChanges(data string) ([]*models.FieldChange, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+
+	results := []*models.FieldChange{}
+	err := decoder.Decode(&results)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range results {
+		change.Before = changeValue(change.Before)
+		change.After = changeValue(change.After)
+	}
+
+	return results, nil
+}
+
+func changeValue(value interface{}) interface{} {
+	switch item := value.(type) {
+	case json.Number:
+		number, err := item.Int64()
+		if err != nil {
+			return item.String()
+		}
+
+		return int(number)
+	case []interface{}:
+		results := []string{}
+		for _, element := range item {
+			text, _ := element.(string)
+			results = append(results, text)
+		}
+
+		return results
+	}
+
+	return value
+}
@@ -0,0 +1,1135 @@
+package sqlrepository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go-clean-grpc/pkg/config"
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	models "go-clean-grpc/todo/models/http"
+	todorepository "go-clean-grpc/todo/repository"
+	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+const todoColumns = "id, owner_id, title, description, status, completed_at, priority, due_at, created_at, updated_at, version, deleted_at"
+
+// batches keep the number of placeholders below the driver limits
+const (
+	idBatchSize     = 500
+	insertBatchSize = 50 // rows of todoColumns
+)
+
+// queryer - *sql.DB or *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type RepositoryImpl struct {
+	db      *pkgsqldb.Tenants
+	dialect string
+	timeout time.Duration
+}
+
+// New will create a sql object that represent the Repository interface, each tenant has its own connection of db
+func New(db *pkgsqldb.Tenants) todorepository.Repository {
+	return &RepositoryImpl{
+		db:      db,
+		dialect: db.Dialect(),
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Migrate - apply the todo schema migrations of dialect
+func Migrate(ctx context.Context, db *sql.DB, dialect string) error {
+	dialectMigrations, err := fs.Sub(migrations, "migrations/"+dialect)
+	if err != nil {
+		return err
+	}
+
+	return pkgsqldb.Migrate(ctx, db, dialect, dialectMigrations)
+}
+
+// FindAll - find all todo
+func (r *RepositoryImpl) FindAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	results, err := r.find(ctx, filter, true, limit, offset)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+
+	err = r.loadTags(ctx, db, results)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+
+	return results, nil
+}
+
+// CountFindAll - count find all todo
+func (r *RepositoryImpl) CountFindAll(ctx context.Context, filter *models.TodoFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	// search matches are only known once scored
+	if filter.Search != "" {
+		results, err := r.find(ctx, filter, false, 0, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		return len(results), nil
+	}
+
+	b := r.newBuilder()
+	buildFilter(b, filter, timeutil.GetTimeNow())
+
+	var total int
+	err = db.QueryRowContext(ctx, r.rebind("SELECT COUNT(*) FROM todo"+b.where()), b.args...).Scan(&total)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return total, nil
+}
+
+// FindById - find todo by id
+func (r *RepositoryImpl) FindById(ctx context.Context, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.findByID(ctx, db, id)
+}
+
+// CountFindByID - find count todo by id
+func (r *RepositoryImpl) CountFindByID(ctx context.Context, id string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := r.count(ctx, db, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if total <= 0 {
+		return 0, errorsutil.ErrNotFound
+	}
+
+	return total, nil
+}
+
+// Store - store todo
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return &models.Todo{}, mapError(err)
+	}
+	defer tx.Rollback()
+
+	id := idutil.New()
+	_, err = tx.ExecContext(ctx, r.rebind("INSERT INTO todo ("+todoColumns+") VALUES "+rowPlaceholders(1)), r.insertArgs(id, value, timeutil.GetTimeNow())...)
+	if err != nil {
+		return &models.Todo{}, mapError(err)
+	}
+
+	err = r.insertTags(ctx, tx, id, value.Tags)
+	if err != nil {
+		return &models.Todo{}, err
+	}
+
+	result, err := r.findByID(ctx, tx, id)
+	if err != nil {
+		return &models.Todo{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return &models.Todo{}, mapError(err)
+	}
+
+	return result, nil
+}
+
+// Update - update todo by id, value.Version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	return r.update(ctx, id, value.Version, r.updateChange(value))
+}
+
+// Patch - update only the patched fields of todo by id, patch.Version is the expected current version
+func (r *RepositoryImpl) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	change := &change{}
+	for _, name := range patch.Fields {
+		field, ok := models.TodoSchema.Lookup(name)
+		if !ok {
+			return nil, errorsutil.New(errorsutil.KindInvalidArgument, "field "+name+" can not be updated")
+		}
+
+		if field.Key == tagsKey {
+			change.tags = r.replaceTags(patch.Todo.Tags)
+			continue
+		}
+
+		change.set(columns[field.Key], patch.Todo.Value(field.Key))
+	}
+
+	return r.update(ctx, id, patch.Version, change)
+}
+
+// UpdateStatus - update status of todo by id, version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) UpdateStatus(ctx context.Context, id string, status string, completedAt *time.Time, version int64) (*models.Todo, error) {
+	change := &change{}
+	change.set("status", status)
+	change.set("completed_at", completedAt)
+
+	return r.update(ctx, id, version, change)
+}
+
+// AddTags - add tags to todo by id, existing tags are kept once
+func (r *RepositoryImpl) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.update(ctx, id, 0, &change{tags: func(ctx context.Context, tx *sql.Tx, id string) error {
+		return r.insertTags(ctx, tx, id, tags)
+	}})
+}
+
+// RemoveTags - remove tags from todo by id
+func (r *RepositoryImpl) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.update(ctx, id, 0, &change{tags: func(ctx context.Context, tx *sql.Tx, id string) error {
+		if len(tags) == 0 {
+			return nil
+		}
+
+		_, err := tx.ExecContext(ctx, r.rebind("DELETE FROM todo_tag WHERE todo_id = ? AND tag IN ("+placeholders(len(tags))+")"), append([]interface{}{id}, stringArgs(tags)...)...)
+
+		return mapError(err)
+	}})
+}
+
+// CountTags - count todo per tag, the most used tag first
+func (r *RepositoryImpl) CountTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	if filter.Search != "" {
+		return r.countSearchTags(ctx, filter)
+	}
+
+	b := r.newBuilder()
+	buildFilter(b, filter, timeutil.GetTimeNow())
+
+	rows, err := db.QueryContext(ctx, r.rebind("SELECT tag, COUNT(*) FROM todo_tag WHERE todo_id IN (SELECT id FROM todo"+b.where()+") GROUP BY tag ORDER BY COUNT(*) DESC, tag ASC"), b.args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	results := []*models.TagCount{}
+	for rows.Next() {
+		item := &models.TagCount{}
+		err := rows.Scan(&item.Tag, &item.Count)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		results = append(results, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// Delete - move todo by id to the trash
+func (r *RepositoryImpl) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	timeNow := r.value(timeutil.GetTimeNow())
+	result, err := db.ExecContext(ctx, r.rebind("UPDATE todo SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL"), timeNow, timeNow, id)
+
+	return affectedError(result, err)
+}
+
+// Restore - move todo by id out of the trash
+func (r *RepositoryImpl) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, r.rebind("UPDATE todo SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"), r.value(timeutil.GetTimeNow()), id)
+	err = affectedError(result, err)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := r.findByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return todo, nil
+}
+
+// Purge - permanently delete todo by id from the trash
+func (r *RepositoryImpl) Purge(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.rebind("DELETE FROM todo_tag WHERE todo_id IN (SELECT id FROM todo WHERE id = ? AND deleted_at IS NOT NULL)"), id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	result, err := tx.ExecContext(ctx, r.rebind("DELETE FROM todo WHERE id = ? AND deleted_at IS NOT NULL"), id)
+	err = affectedError(result, err)
+	if err != nil {
+		return err
+	}
+
+	return mapError(tx.Commit())
+}
+
+// PurgeDeleted - permanently delete todo moved to the trash before the given time, the ids of the purged todo
+func (r *RepositoryImpl) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.rebind("DELETE FROM todo_tag WHERE todo_id IN (SELECT id FROM todo WHERE deleted_at < ?)"), r.value(before))
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	deleted := map[string]bool{}
+	err = r.returningIDs(ctx, tx, "DELETE FROM todo WHERE deleted_at < ? RETURNING id", []interface{}{r.value(before)}, deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	ids := make([]string, 0, len(deleted))
+	for id := range deleted {
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// FindByIDs - find todo by ids, ids of todo that do not exist or are in the trash are left out
+func (r *RepositoryImpl) FindByIDs(ctx context.Context, ids []string) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+
+	return r.findByIDs(ctx, db, ids)
+}
+
+// StoreMany - store todo in one transaction with multi-row inserts, the batch fails as a whole
+func (r *RepositoryImpl) StoreMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	timeNow := timeutil.GetTimeNow()
+	ids := make([]string, 0, len(values))
+	for start := 0; start < len(values); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		args := []interface{}{}
+		for _, value := range values[start:end] {
+			id := idutil.New()
+			ids = append(ids, id)
+			args = append(args, r.insertArgs(id, value, timeNow)...)
+		}
+
+		_, err = tx.ExecContext(ctx, r.rebind("INSERT INTO todo ("+todoColumns+") VALUES "+rowPlaceholders(end-start)), args...)
+		if err != nil {
+			return nil, mapError(err)
+		}
+	}
+
+	for i, value := range values {
+		err = r.insertTags(ctx, tx, ids[i], value.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := r.findByIDs(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return batchResults(ids, stored), nil
+}
+
+// UpdateMany - update todo by value.ID in one transaction, value.Version is the expected current version and 0 skips the check
+// todo not found or changed since their version fail alone, database errors fail the batch
+func (r *RepositoryImpl) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	timeNow := timeutil.GetTimeNow()
+	ids := make([]string, 0, len(values))
+	failed := map[int]error{}
+	for i, value := range values {
+		ids = append(ids, value.ID)
+
+		err = r.applyChange(ctx, tx, value.ID, value.Version, r.updateChange(value), timeNow)
+		if errors.Is(err, errorsutil.ErrNotFound) || errors.Is(err, todorepository.ErrVersionConflict) {
+			failed[i] = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := r.findByIDs(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	results := batchResults(ids, updated)
+	for i, err := range failed {
+		results[i].Todo = nil
+		results[i].Error = err
+	}
+
+	return results, nil
+}
+
+// DeleteMany - move todo by ids to the trash in one transaction
+func (r *RepositoryImpl) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	timeNow := r.value(timeutil.GetTimeNow())
+	deleted := map[string]bool{}
+	for start := 0; start < len(ids); start += idBatchSize {
+		end := start + idBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		args := append([]interface{}{timeNow, timeNow}, stringArgs(ids[start:end])...)
+		err = r.returningIDs(ctx, tx, "UPDATE todo SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id IN ("+placeholders(end-start)+") AND deleted_at IS NULL RETURNING id", args, deleted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	results := make([]*models.BatchResult, 0, len(ids))
+	for _, id := range ids {
+		result := &models.BatchResult{ID: id}
+		if !deleted[id] {
+			result.Error = errorsutil.ErrNotFound
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// find - todo matching filter without tags, searches are scored, filtered and paged after the query
+func (r *RepositoryImpl) find(ctx context.Context, filter *models.TodoFilter, withAfter bool, limit int, offset int) ([]*models.Todo, error) {
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return []*models.Todo{}, err
+	}
+
+	sortFields := filter.SortFields()
+
+	b := r.newBuilder()
+	buildFilter(b, filter, timeutil.GetTimeNow())
+	if withAfter && filter.After != nil {
+		err := buildAfter(b, sortFields, filter.After)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := "SELECT " + todoColumns + " FROM todo" + b.where() + " ORDER BY " + buildOrderBy(sortFields)
+	args := b.args
+	if filter.Search == "" {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, sqlLimit(limit), offset)
+	}
+
+	results, err := r.query(ctx, db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Search == "" {
+		return results, nil
+	}
+
+	scored := []*models.Todo{}
+	for _, item := range results {
+		item.Score = item.TextScore(filter.Search)
+		if item.Score > 0 {
+			scored = append(scored, item)
+		}
+	}
+
+	if len(sortFields) == 0 {
+		sort.SliceStable(scored, func(i, j int) bool {
+			if scored[i].Score != scored[j].Score {
+				return scored[i].Score > scored[j].Score
+			}
+
+			return scored[i].ID < scored[j].ID
+		})
+	}
+
+	return page(scored, limit, offset), nil
+}
+
+// countSearchTags - count todo per tag of a search, counted on the scored results
+func (r *RepositoryImpl) countSearchTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := r.find(ctx, filter, false, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.loadTags(ctx, db, todos)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			counts[tag]++
+		}
+	}
+
+	results := []*models.TagCount{}
+	for tag, count := range counts {
+		results = append(results, &models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+
+		return results[i].Tag < results[j].Tag
+	})
+
+	return results, nil
+}
+
+func (r *RepositoryImpl) findByID(ctx context.Context, q queryer, id string) (*models.Todo, error) {
+	results, err := r.query(ctx, q, "SELECT "+todoColumns+" FROM todo WHERE id = ? AND deleted_at IS NULL", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	err = r.loadTags(ctx, q, results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+func (r *RepositoryImpl) count(ctx context.Context, q queryer, id string) (int, error) {
+	var total int
+	err := q.QueryRowContext(ctx, r.rebind("SELECT COUNT(*) FROM todo WHERE id = ? AND deleted_at IS NULL"), id).Scan(&total)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return total, nil
+}
+
+// query - todo of query selecting todoColumns
+func (r *RepositoryImpl) query(ctx context.Context, q queryer, query string, args ...interface{}) ([]*models.Todo, error) {
+	rows, err := q.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	results := []*models.Todo{}
+	for rows.Next() {
+		var completedAt, dueAt, deletedAt sql.NullTime
+		item := &models.Todo{Tags: []string{}}
+		err := rows.Scan(
+			&item.ID,
+			&item.OwnerID,
+			&item.Title,
+			&item.Description,
+			&item.Status,
+			&completedAt,
+			&item.Priority,
+			&dueAt,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Version,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		item.CompletedAt = nullTime(completedAt)
+		item.DueAt = nullTime(dueAt)
+		item.DeletedAt = nullTime(deletedAt)
+		item.CreatedAt = item.CreatedAt.UTC()
+		item.UpdatedAt = item.UpdatedAt.UTC()
+
+		results = append(results, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// loadTags - set tags of todo in the order they were added
+func (r *RepositoryImpl) loadTags(ctx context.Context, q queryer, todos []*models.Todo) error {
+	byID := map[string]*models.Todo{}
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	for start := 0; start < len(todos); start += idBatchSize {
+		end := start + idBatchSize
+		if end > len(todos) {
+			end = len(todos)
+		}
+
+		ids := []interface{}{}
+		for _, todo := range todos[start:end] {
+			ids = append(ids, todo.ID)
+		}
+
+		err := r.queryTags(ctx, q, ids, byID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RepositoryImpl) queryTags(ctx context.Context, q queryer, ids []interface{}, byID map[string]*models.Todo) error {
+	rows, err := q.QueryContext(ctx, r.rebind("SELECT todo_id, tag FROM todo_tag WHERE todo_id IN ("+placeholders(len(ids))+") ORDER BY todo_id, position"), ids...)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, tag string
+		err := rows.Scan(&id, &tag)
+		if err != nil {
+			return mapError(err)
+		}
+
+		byID[id].Tags = append(byID[id].Tags, tag)
+	}
+
+	return mapError(rows.Err())
+}
+
+// change - columns and tags written by an update
+type change struct {
+	columns []string
+	values  []interface{}
+	tags    func(ctx context.Context, tx *sql.Tx, id string) error
+}
+
+func (c *change) set(column string, value interface{}) {
+	c.columns = append(c.columns, column)
+	c.values = append(c.values, value)
+}
+
+// update - apply change to todo by id, bumping version and updated_at like the mongo repository
+func (r *RepositoryImpl) update(ctx context.Context, id string, version int64, change *change) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db, err := r.db.DB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+
+	err = r.applyChange(ctx, tx, id, version, change, timeutil.GetTimeNow())
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := r.findByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return todo, nil
+}
+
+// applyChange - apply change to todo by id in tx, a version mismatch fails with mismatchError
+func (r *RepositoryImpl) applyChange(ctx context.Context, tx *sql.Tx, id string, version int64, change *change, timeNow time.Time) error {
+	query := "UPDATE todo SET updated_at = ?, version = version + 1"
+	args := []interface{}{r.value(timeNow)}
+	for i, column := range change.columns {
+		query += ", " + column + " = ?"
+		args = append(args, r.value(change.values[i]))
+	}
+	query += " WHERE id = ? AND deleted_at IS NULL"
+	args = append(args, id)
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+
+	result, err := tx.ExecContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if updated == 0 {
+		return r.mismatchError(ctx, tx, id, version)
+	}
+
+	if change.tags != nil {
+		return change.tags(ctx, tx, id)
+	}
+
+	return nil
+}
+
+// updateChange - change of Update, tags and status are kept when unset
+func (r *RepositoryImpl) updateChange(value *models.Todo) *change {
+	change := &change{}
+	change.set("title", value.Title)
+	change.set("description", value.Description)
+	change.set("priority", value.Priority)
+	change.set("due_at", value.DueAt)
+	if value.Tags != nil {
+		change.tags = r.replaceTags(value.Tags)
+	}
+	if value.Status != "" {
+		change.set("status", value.Status)
+		change.set("completed_at", value.CompletedAt)
+	}
+
+	return change
+}
+
+// mismatchError - error of an update changing no row, a version conflict when the todo still exists
+func (r *RepositoryImpl) mismatchError(ctx context.Context, q queryer, id string, version int64) error {
+	if version == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	total, err := r.count(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return todorepository.ErrVersionConflict
+}
+
+// replaceTags - tags writer replacing all tags of todo
+func (r *RepositoryImpl) replaceTags(tags []string) func(ctx context.Context, tx *sql.Tx, id string) error {
+	return func(ctx context.Context, tx *sql.Tx, id string) error {
+		_, err := tx.ExecContext(ctx, r.rebind("DELETE FROM todo_tag WHERE todo_id = ?"), id)
+		if err != nil {
+			return mapError(err)
+		}
+
+		return r.insertTags(ctx, tx, id, tags)
+	}
+}
+
+// insertTags - append tags after the existing tags of todo, existing tags are kept once
+func (r *RepositoryImpl) insertTags(ctx context.Context, tx *sql.Tx, id string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var position int
+	err := tx.QueryRowContext(ctx, r.rebind("SELECT COALESCE(MAX(position), -1) + 1 FROM todo_tag WHERE todo_id = ?"), id).Scan(&position)
+	if err != nil {
+		return mapError(err)
+	}
+
+	for _, tag := range tags {
+		result, err := tx.ExecContext(ctx, r.rebind("INSERT INTO todo_tag (todo_id, tag, position) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"), id, tag, position)
+		if err != nil {
+			return mapError(err)
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return mapError(err)
+		}
+		position += int(inserted)
+	}
+
+	return nil
+}
+
+// findByIDs - todo by ids with their tags, in batches keeping the number of placeholders below the driver limits
+func (r *RepositoryImpl) findByIDs(ctx context.Context, q queryer, ids []string) ([]*models.Todo, error) {
+	results := []*models.Todo{}
+	for start := 0; start < len(ids); start += idBatchSize {
+		end := start + idBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		todos, err := r.query(ctx, q, "SELECT "+todoColumns+" FROM todo WHERE id IN ("+placeholders(end-start)+") AND deleted_at IS NULL", stringArgs(ids[start:end])...)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, todos...)
+	}
+
+	err := r.loadTags(ctx, q, results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// returningIDs - mark the ids returned by query in ids
+func (r *RepositoryImpl) returningIDs(ctx context.Context, tx *sql.Tx, query string, args []interface{}, ids map[string]bool) error {
+	rows, err := tx.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return mapError(err)
+		}
+
+		ids[id] = true
+	}
+
+	return mapError(rows.Err())
+}
+
+// insertArgs - values of todoColumns of a new todo
+func (r *RepositoryImpl) insertArgs(id string, value *models.Todo, timeNow time.Time) []interface{} {
+	status := value.Status
+	if status == "" {
+		status = models.StatusPending
+	}
+
+	return []interface{}{
+		id,
+		value.OwnerID,
+		value.Title,
+		value.Description,
+		status,
+		r.value(value.CompletedAt),
+		value.Priority,
+		r.value(value.DueAt),
+		r.value(timeNow),
+		r.value(timeNow),
+		1,
+		nil,
+	}
+}
+
+func (r *RepositoryImpl) newBuilder() *builder {
+	return &builder{dialect: r.dialect}
+}
+
+func (r *RepositoryImpl) rebind(query string) string {
+	return pkgsqldb.Rebind(r.dialect, query)
+}
+
+func (r *RepositoryImpl) value(value interface{}) interface{} {
+	return dbValue(r.dialect, value)
+}
+
+// sqlLimit - LIMIT of limit, 0 is no limit like mongo
+func sqlLimit(limit int) int64 {
+	if limit <= 0 {
+		return math.MaxInt64
+	}
+
+	return int64(limit)
+}
+
+// page - results after offset, at most limit when limit is set
+func page(results []*models.Todo, limit int, offset int) []*models.Todo {
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// batchResults - results of ids with the todo found by id, ids without todo fail as not found
+func batchResults(ids []string, todos []*models.Todo) []*models.BatchResult {
+	byID := map[string]*models.Todo{}
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	results := make([]*models.BatchResult, 0, len(ids))
+	for _, id := range ids {
+		result := &models.BatchResult{ID: id, Todo: byID[id]}
+		if result.Todo == nil {
+			result.Error = errorsutil.ErrNotFound
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// rowPlaceholders - placeholders of n rows of todoColumns
+func rowPlaceholders(n int) string {
+	row := "(" + placeholders(strings.Count(todoColumns, ",")+1) + ")"
+
+	return strings.TrimSuffix(strings.Repeat(row+", ", n), ", ")
+}
+
+// affectedError - error of a write by id, no affected row means the todo was not found
+func affectedError(result sql.Result, err error) error {
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if affected <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	result := value.Time.UTC()
+
+	return &result
+}
+
+// mapError - translate database/sql errors to domain errors
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errorsutil.ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorsutil.Wrap(errorsutil.KindDeadlineExceeded, "database timeout", err)
+	}
+
+	return err
+}
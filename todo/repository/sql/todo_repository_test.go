@@ -0,0 +1,128 @@
+package sqlrepository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pkgsqldb "go-clean-grpc/pkg/sqldb"
+	todorepository "go-clean-grpc/todo/repository"
+	"go-clean-grpc/todo/repository/repositorytest"
+	sqlrepository "go-clean-grpc/todo/repository/sql"
+)
+
+func TestRepositorySQLite(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) todorepository.Repository {
+		// each test runs against its own database file
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return newRepository(t, pkgsqldb.DialectSQLite)
+	})
+}
+
+func TestTenantsSQLite(t *testing.T) {
+	repositorytest.RunTenants(t, func(t *testing.T) todorepository.Repository {
+		// each tenant has its own database file next to DB_URL
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return newRepository(t, pkgsqldb.DialectSQLite)
+	})
+}
+
+func TestRevisionRepositorySQLite(t *testing.T) {
+	repositorytest.RunRevisions(t, func(t *testing.T) todorepository.RevisionRepository {
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return sqlrepository.NewRevisionRepository(newTenants(t, pkgsqldb.DialectSQLite))
+	})
+}
+
+func TestIdempotencyRepositorySQLite(t *testing.T) {
+	repositorytest.RunIdempotency(t, func(t *testing.T) todorepository.IdempotencyRepository {
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return sqlrepository.NewIdempotencyRepository(newTenants(t, pkgsqldb.DialectSQLite))
+	})
+}
+
+func TestPermissionRepositorySQLite(t *testing.T) {
+	repositorytest.RunPermissions(t, func(t *testing.T) todorepository.PermissionRepository {
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return sqlrepository.NewPermissionRepository(newTenants(t, pkgsqldb.DialectSQLite))
+	})
+}
+
+func TestAPIKeyRepositorySQLite(t *testing.T) {
+	repositorytest.RunAPIKeys(t, func(t *testing.T) todorepository.APIKeyRepository {
+		t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+		return sqlrepository.NewAPIKeyRepository(newDB(t, pkgsqldb.DialectSQLite), pkgsqldb.DialectSQLite)
+	})
+}
+
+func TestRepositoryPostgres(t *testing.T) {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL is not set")
+	}
+
+	repositorytest.Run(t, func(t *testing.T) todorepository.Repository {
+		t.Setenv("DB_URL", url)
+
+		db, err := sql.Open("postgres", url)
+		require.NoError(t, err)
+		defer db.Close()
+
+		// each test starts from an empty schema
+		_, err = db.Exec("DROP TABLE IF EXISTS api_key, todo_permission, idempotency_key, todo_revision, todo_tag, todo, schema_migrations")
+		require.NoError(t, err)
+
+		return newRepository(t, pkgsqldb.DialectPostgres)
+	})
+}
+
+func TestMigrateTwice(t *testing.T) {
+	t.Setenv("DB_URL", filepath.Join(t.TempDir(), "todo.db"))
+
+	db, err := pkgsqldb.InitSQLDB(pkgsqldb.DialectSQLite)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, sqlrepository.Migrate(context.Background(), db, pkgsqldb.DialectSQLite))
+	require.NoError(t, sqlrepository.Migrate(context.Background(), db, pkgsqldb.DialectSQLite))
+}
+
+func newRepository(t *testing.T, dialect string) todorepository.Repository {
+	return sqlrepository.New(newTenants(t, dialect))
+}
+
+// newTenants - migrated connections of the tenants next to DB_URL, closed when the test ends
+func newTenants(t *testing.T, dialect string) *pkgsqldb.Tenants {
+	tenants := pkgsqldb.NewTenants(newDB(t, dialect), dialect, func(ctx context.Context, db *sql.DB) error {
+		return sqlrepository.Migrate(ctx, db, dialect)
+	})
+	t.Cleanup(func() {
+		tenants.Close()
+	})
+
+	return tenants
+}
+
+// newDB - migrated database of DB_URL, closed when the test ends
+func newDB(t *testing.T, dialect string) *sql.DB {
+	db, err := pkgsqldb.InitSQLDB(dialect)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqlrepository.Migrate(context.Background(), db, dialect)
+	require.NoError(t, err)
+
+	return db
+}
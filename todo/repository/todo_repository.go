@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"os"
 	"time"
 
@@ -10,35 +11,62 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"go-clean-grpc/pkg/config"
 	models "go-clean-grpc/todo/models/http"
 	errorsutil "go-clean-grpc/utils/errors"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+	tenantutil "go-clean-grpc/utils/tenant"
 	timeutil "go-clean-grpc/utils/time"
 )
 
 type Repository interface {
-	FindAll(keyword string, limit int, offset int) ([]*models.Todo, error)
-	CountFindAll(keyword string) (int, error)
-	FindById(id string) (*models.Todo, error)
-	CountFindByID(id string) (int, error)
-	Store(value *models.Todo) (*models.Todo, error)
-	Update(id string, value *models.Todo) (*models.Todo, error)
-	Delete(id string) error
+	FindAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, error)
+	CountFindAll(ctx context.Context, filter *models.TodoFilter) (int, error)
+	FindById(ctx context.Context, id string) (*models.Todo, error)
+	CountFindByID(ctx context.Context, id string) (int, error)
+	Store(ctx context.Context, value *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error)
+	Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error)
+	UpdateStatus(ctx context.Context, id string, status string, completedAt *time.Time, version int64) (*models.Todo, error)
+	AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error)
+	RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error)
+	CountTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error)
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) (*models.Todo, error)
+	Purge(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, before time.Time) ([]string, error)
+	FindByIDs(ctx context.Context, ids []string) ([]*models.Todo, error)
+	StoreMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error)
+	UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error)
+	DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error)
+}
+
+// ErrVersionConflict - the todo was changed since the expected version was read
+var ErrVersionConflict error = errorsutil.New(errorsutil.KindAborted, "todo was modified, version does not match")
+
+// todoDocument - todo as stored in mongo, keyed by object id
+type todoDocument struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	models.Todo `bson:",inline"`
 }
 
 type RepositoryImpl struct {
-	client *mongo.Client
+	client  *mongo.Client
+	timeout time.Duration
 }
 
 // New will create an object that represent the Repository interface
 func New(client *mongo.Client) Repository {
 	return &RepositoryImpl{
-		client: client,
+		client:  client,
+		timeout: config.GetDuration("DB_TIMEOUT", 5*time.Second),
 	}
 }
 
 // FindAll - find all todo
-func (r *RepositoryImpl) FindAll(keyword string, limit int, offset int) ([]*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) FindAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var results []*models.Todo
@@ -47,56 +75,69 @@ func (r *RepositoryImpl) FindAll(keyword string, limit int, offset int) ([]*mode
 	findOptions := options.Find()
 	findOptions.SetLimit(int64(limit))
 	findOptions.SetSkip(int64(offset))
-	findOptions.SetSort(bson.D{{Key: "updatedAt", Value: -1}})
+	findOptions.SetSort(buildSort(filter))
+	if filter != nil && filter.Search != "" {
+		findOptions.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
-	cur, err := collection.Find(ctx, bson.M{"title": bson.M{"$regex": keyword, "$options": "i"}}, findOptions)
+	query := buildFilter(filter)
+	if filter != nil && filter.After != nil {
+		after, err := buildAfter(filter.SortFields(), filter.After)
+		if err != nil {
+			return []*models.Todo{}, err
+		}
+
+		query = bson.M{"$and": bson.A{query, after}}
+	}
+
+	collection := database(ctx, r.client).Collection("todo")
+	cur, err := collection.Find(ctx, query, findOptions)
 	if err != nil {
-		return []*models.Todo{}, err
+		return []*models.Todo{}, mapError(err)
 	}
 
 	// Finding multiple documents returns a cursor
 	// Iterating through the cursor allows us to decode documents one at a time
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 
 		// create a value into which the single document can be decoded
-		var elem models.Todo
+		var elem todoDocument
 		err := cur.Decode(&elem)
 		if err != nil {
-			return []*models.Todo{}, err
+			return []*models.Todo{}, mapError(err)
 		}
 
-		results = append(results, &elem)
+		results = append(results, elem.todo())
 	}
 
 	if err := cur.Err(); err != nil {
-		return []*models.Todo{}, err
+		return []*models.Todo{}, mapError(err)
 	}
 
 	// Close the cursor once finished
-	cur.Close(context.TODO())
+	cur.Close(ctx)
 
 	return results, nil
 }
 
 // CountFindAll - count find all todo
-func (r *RepositoryImpl) CountFindAll(keyword string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) CountFindAll(ctx context.Context, filter *models.TodoFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection := database(ctx, r.client).Collection("todo")
 
-	total, err := collection.CountDocuments(ctx, bson.M{"title": bson.M{"$regex": keyword, "$options": "i"}})
+	total, err := collection.CountDocuments(ctx, buildFilter(filter))
 	if err != nil {
-		return int(total), err
+		return int(total), mapError(err)
 	}
 
 	return int(total), nil
 }
 
 // FindById - find todo by id
-func (r *RepositoryImpl) FindById(id string) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) FindById(ctx context.Context, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -104,24 +145,20 @@ func (r *RepositoryImpl) FindById(id string) (*models.Todo, error) {
 		return nil, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection := database(ctx, r.client).Collection("todo")
 
-	result := &models.Todo{}
-	err = collection.FindOne(ctx, bson.M{"_id": docID}).Decode(&result)
+	result := &todoDocument{}
+	err = collection.FindOne(ctx, activeFilter(docID)).Decode(result)
 	if err != nil {
-		if err.Error() == "mongo: no documents in result" {
-			return result, errorsutil.ErrNotFound
-		}
-
-		return result, err
+		return &models.Todo{}, mapError(err)
 	}
 
-	return result, nil
+	return result.todo(), nil
 }
 
 // CountFindByID - find count todo by id
-func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) CountFindByID(ctx context.Context, id string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -129,10 +166,10 @@ func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
 		return 0, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
-	total, err := collection.CountDocuments(ctx, bson.M{"_id": docID})
+	collection := database(ctx, r.client).Collection("todo")
+	total, err := collection.CountDocuments(ctx, activeFilter(docID))
 	if err != nil {
-		return 0, err
+		return 0, mapError(err)
 	}
 
 	if total <= 0 {
@@ -143,37 +180,152 @@ func (r *RepositoryImpl) CountFindByID(id string) (int, error) {
 }
 
 // Store - store todo
-func (r *RepositoryImpl) Store(value *models.Todo) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) Store(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection := database(ctx, r.client).Collection("todo")
 
 	timeNow := timeutil.GetTimeNow()
-	res, err := collection.InsertOne(ctx, bson.M{
-		"title":       value.Title,
-		"description": value.Description,
-		"createdAt":   timeNow,
-		"updatedAt":   timeNow,
+	res, err := collection.InsertOne(ctx, newDocument(value, timeNow))
+	if err != nil {
+		return &models.Todo{}, mapError(err)
+	}
+
+	return storedTodo(res.InsertedID.(primitive.ObjectID), value, timeNow), nil
+}
+
+// Update - update todo by id, value.Version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo")
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &todoDocument{}
+	err = collection.FindOneAndUpdate(ctx, versionFilter(docID, value.Version), versionUpdate(updateValue(value, timeutil.GetTimeNow())), updateOptions).Decode(result)
+	if err != nil {
+		return nil, r.mismatchError(ctx, collection, docID, value.Version, err)
+	}
+
+	return result.todo(), nil
+}
+
+// Patch - update only the patched fields of todo by id, patch.Version is the expected current version
+func (r *RepositoryImpl) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	bsonValue := bson.D{}
+	for _, name := range patch.Fields {
+		field, ok := models.TodoSchema.Lookup(name)
+		if !ok {
+			return nil, errorsutil.New(errorsutil.KindInvalidArgument, "field "+name+" can not be updated")
+		}
+
+		bsonValue = append(bsonValue, bson.E{Key: field.Key, Value: patch.Todo.Value(field.Key)})
+	}
+	bsonValue = append(bsonValue, bson.E{Key: "updatedAt", Value: timeutil.GetTimeNow()})
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &todoDocument{}
+	err = collection.FindOneAndUpdate(ctx, versionFilter(docID, patch.Version), versionUpdate(bsonValue), updateOptions).Decode(result)
+	if err != nil {
+		return nil, r.mismatchError(ctx, collection, docID, patch.Version, err)
+	}
+
+	return result.todo(), nil
+}
+
+// UpdateStatus - update status of todo by id, version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) UpdateStatus(ctx context.Context, id string, status string, completedAt *time.Time, version int64) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	bsonValue := bson.D{
+		{Key: "status", Value: status},
+		{Key: "completedAt", Value: completedAt},
+		{Key: "updatedAt", Value: timeutil.GetTimeNow()},
+	}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &todoDocument{}
+	err = collection.FindOneAndUpdate(ctx, versionFilter(docID, version), versionUpdate(bsonValue), updateOptions).Decode(result)
+	if err != nil {
+		return nil, r.mismatchError(ctx, collection, docID, version, err)
+	}
+
+	return result.todo(), nil
+}
+
+// AddTags - add tags to todo by id, existing tags are kept once
+func (r *RepositoryImpl) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.updateTags(ctx, id, bson.D{
+		{Key: "$addToSet", Value: bson.M{"tags": bson.M{"$each": tags}}},
+		{Key: "$set", Value: bson.M{"updatedAt": timeutil.GetTimeNow()}},
+		{Key: "$inc", Value: bson.M{"version": 1}},
 	})
+}
+
+// RemoveTags - remove tags from todo by id
+func (r *RepositoryImpl) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	return r.updateTags(ctx, id, bson.D{
+		{Key: "$pullAll", Value: bson.M{"tags": tags}},
+		{Key: "$set", Value: bson.M{"updatedAt": timeutil.GetTimeNow()}},
+		{Key: "$inc", Value: bson.M{"version": 1}},
+	})
+}
+
+// CountTags - count todo per tag, the most used tag first
+func (r *RepositoryImpl) CountTags(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: buildFilter(filter)}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$tags"}, {Key: "count", Value: bson.M{"$sum": 1}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cur, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return &models.Todo{}, err
+		return nil, mapError(err)
 	}
+	defer cur.Close(ctx)
 
-	result := &models.Todo{
-		ID:          res.InsertedID.(primitive.ObjectID),
-		Title:       value.Title,
-		Description: value.Description,
-		CreatedAt:   timeNow,
-		UpdatedAt:   timeNow,
+	results := []*models.TagCount{}
+	err = cur.All(ctx, &results)
+	if err != nil {
+		return nil, mapError(err)
 	}
 
-	return result, nil
+	return results, nil
 }
 
-// Update - update todo by id
-func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RepositoryImpl) updateTags(ctx context.Context, id string, update bson.D) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	docID, err := primitive.ObjectIDFromHex(id)
@@ -181,41 +333,89 @@ func (r *RepositoryImpl) Update(id string, value *models.Todo) (*models.Todo, er
 		return nil, errorsutil.ErrNotFound
 	}
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection := database(ctx, r.client).Collection("todo")
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &todoDocument{}
+	err = collection.FindOneAndUpdate(ctx, activeFilter(docID), update, updateOptions).Decode(result)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return result.todo(), nil
+}
+
+// Delete - move todo by id to the trash
+func (r *RepositoryImpl) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errorsutil.ErrNotFound
+	}
 
 	timeNow := timeutil.GetTimeNow()
-	bsonValue := bson.D{
-		{Key: "title", Value: value.Title},
-		{Key: "description", Value: value.Description},
+	result, err := collection.UpdateOne(ctx, activeFilter(docID), versionUpdate(bson.D{
+		{Key: "deletedAt", Value: timeNow},
 		{Key: "updatedAt", Value: timeNow},
+	}))
+	if err != nil {
+		return mapError(err)
 	}
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": docID}, bson.D{{Key: "$set", Value: bsonValue}})
+
+	if result.MatchedCount <= 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// Restore - move todo by id out of the trash
+func (r *RepositoryImpl) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	docID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, errorsutil.ErrNotFound
+	}
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	update := bson.D{
+		{Key: "$unset", Value: bson.M{"deletedAt": ""}},
+		{Key: "$set", Value: bson.M{"updatedAt": timeutil.GetTimeNow()}},
+		{Key: "$inc", Value: bson.M{"version": 1}},
 	}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	result := &models.Todo{
-		ID: docID,
+	result := &todoDocument{}
+	err = collection.FindOneAndUpdate(ctx, deletedFilter(docID), update, updateOptions).Decode(result)
+	if err != nil {
+		return nil, mapError(err)
 	}
 
-	return result, nil
+	return result.todo(), nil
 }
 
-// Delete - delete todo by id
-func (r *RepositoryImpl) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Purge - permanently delete todo by id from the trash
+func (r *RepositoryImpl) Purge(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	collection := r.client.Database(os.Getenv("DB_NAME")).Collection("todo")
+	collection := database(ctx, r.client).Collection("todo")
 
 	docID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errorsutil.ErrNotFound
 	}
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": docID})
+	result, err := collection.DeleteOne(ctx, deletedFilter(docID))
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	if result.DeletedCount <= 0 {
@@ -224,3 +424,645 @@ func (r *RepositoryImpl) Delete(id string) error {
 
 	return nil
 }
+
+// PurgeDeleted - permanently delete todo moved to the trash before the given time, the ids of the purged todo
+func (r *RepositoryImpl) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	expired := bson.M{"deletedAt": bson.M{"$lt": before}}
+	docIDs, err := r.findIDs(ctx, collection, expired)
+	if err != nil {
+		return nil, err
+	}
+	if len(docIDs) == 0 {
+		return []string{}, nil
+	}
+
+	result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": docIDs}, "deletedAt": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	// the todo restored since they were found are kept
+	if int(result.DeletedCount) < len(docIDs) {
+		kept, err := r.findIDs(ctx, collection, bson.M{"_id": bson.M{"$in": docIDs}})
+		if err != nil {
+			return nil, err
+		}
+
+		docIDs = withoutIDs(docIDs, kept)
+	}
+
+	ids := make([]string, 0, len(docIDs))
+	for _, docID := range docIDs {
+		ids = append(ids, docID.Hex())
+	}
+
+	return ids, nil
+}
+
+// findIDs - ids of the todo matching filter
+func (r *RepositoryImpl) findIDs(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]primitive.ObjectID, error) {
+	cur, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	docIDs := []primitive.ObjectID{}
+	for cur.Next(ctx) {
+		var elem todoDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		docIDs = append(docIDs, elem.ID)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return docIDs, nil
+}
+
+// FindByIDs - find todo by ids, ids of todo that do not exist or are in the trash are left out
+func (r *RepositoryImpl) FindByIDs(ctx context.Context, ids []string) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	docIDs := objectIDs(ids)
+	cur, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": docIDs}, "deletedAt": nil})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	results := []*models.Todo{}
+	for cur.Next(ctx) {
+		var elem todoDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		results = append(results, elem.todo())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
+
+// StoreMany - store todo with one unordered insert, a failed item does not stop the others
+func (r *RepositoryImpl) StoreMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	// ids are set here so the results are known without reading the todo back
+	timeNow := timeutil.GetTimeNow()
+	documents := make([]interface{}, 0, len(values))
+	results := make([]*models.BatchResult, 0, len(values))
+	for _, value := range values {
+		docID := primitive.NewObjectID()
+
+		document := newDocument(value, timeNow)
+		document["_id"] = docID
+		documents = append(documents, document)
+
+		results = append(results, &models.BatchResult{
+			ID:   docID.Hex(),
+			Todo: storedTodo(docID, value, timeNow),
+		})
+	}
+	if len(documents) == 0 {
+		return results, nil
+	}
+
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	err = writeErrors(err, results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateMany - update todo by value.ID with one unordered bulk write, value.Version is the expected current version and 0 skips the check
+func (r *RepositoryImpl) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	// batchId tells the todo written by this batch apart
+	batchID := primitive.NewObjectID()
+	timeNow := timeutil.GetTimeNow()
+	writes := []mongo.WriteModel{}
+	results := make([]*models.BatchResult, 0, len(values))
+	for _, value := range values {
+		result := &models.BatchResult{ID: value.ID}
+		results = append(results, result)
+
+		docID, err := primitive.ObjectIDFromHex(value.ID)
+		if err != nil {
+			result.Error = errorsutil.ErrNotFound
+			continue
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(versionFilter(docID, value.Version)).
+			SetUpdate(versionUpdate(append(updateValue(value, timeNow), bson.E{Key: "batchId", Value: batchID}))))
+	}
+
+	err := r.bulkWrite(ctx, collection, writes, results)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.writtenBy(ctx, collection, results, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		todo, ok := updated[result.ID]
+		if !ok {
+			docID, _ := primitive.ObjectIDFromHex(result.ID)
+			result.Error = r.mismatchError(ctx, collection, docID, values[i].Version, mongo.ErrNoDocuments)
+			continue
+		}
+		result.Todo = todo
+	}
+
+	return results, nil
+}
+
+// DeleteMany - move todo by ids to the trash with one update
+func (r *RepositoryImpl) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := database(ctx, r.client).Collection("todo")
+
+	results := make([]*models.BatchResult, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, &models.BatchResult{ID: id})
+	}
+
+	// batchId tells the todo deleted by this batch apart
+	batchID := primitive.NewObjectID()
+	timeNow := timeutil.GetTimeNow()
+	docIDs := objectIDs(ids)
+	_, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": docIDs}, "deletedAt": nil}, versionUpdate(bson.D{
+		{Key: "deletedAt", Value: timeNow},
+		{Key: "updatedAt", Value: timeNow},
+		{Key: "batchId", Value: batchID},
+	}))
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	deleted, err := r.writtenBy(ctx, collection, results, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if _, ok := deleted[result.ID]; !ok {
+			result.Error = errorsutil.ErrNotFound
+		}
+	}
+
+	return results, nil
+}
+
+// bulkWrite - unordered bulk write, the write errors fail the item of the write
+// results of items without write must already have failed
+func (r *RepositoryImpl) bulkWrite(ctx context.Context, collection *mongo.Collection, writes []mongo.WriteModel, results []*models.BatchResult) error {
+	if len(writes) == 0 {
+		return nil
+	}
+
+	_, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil
+	}
+
+	// the write errors are indexed by write, skip the items failed before writing
+	pending := []*models.BatchResult{}
+	for _, result := range results {
+		if result.Error == nil {
+			pending = append(pending, result)
+		}
+	}
+
+	return writeErrors(err, pending)
+}
+
+// writtenBy - todo of results last written by the batch of batchID, keyed by id
+// the id of a batch is unique across the servers, unlike its write time
+func (r *RepositoryImpl) writtenBy(ctx context.Context, collection *mongo.Collection, results []*models.BatchResult, batchID primitive.ObjectID) (map[string]*models.Todo, error) {
+	ids := []string{}
+	for _, result := range results {
+		if result.Error == nil {
+			ids = append(ids, result.ID)
+		}
+	}
+
+	written := map[string]*models.Todo{}
+	if len(ids) == 0 {
+		return written, nil
+	}
+
+	docIDs := objectIDs(ids)
+	cur, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": docIDs}, "batchId": batchID})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var elem todoDocument
+		err := cur.Decode(&elem)
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		todo := elem.todo()
+		written[todo.ID] = todo
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return written, nil
+}
+
+// newDocument - document of a new todo
+func newDocument(value *models.Todo, timeNow time.Time) bson.M {
+	return bson.M{
+		"ownerId":     value.OwnerID,
+		"title":       value.Title,
+		"description": value.Description,
+		"status":      value.Status,
+		"completedAt": value.CompletedAt,
+		"priority":    value.Priority,
+		"dueAt":       value.DueAt,
+		"tags":        value.Tags,
+		"createdAt":   timeNow,
+		"updatedAt":   timeNow,
+		"version":     1,
+	}
+}
+
+// storedTodo - todo of a new document
+func storedTodo(docID primitive.ObjectID, value *models.Todo, timeNow time.Time) *models.Todo {
+	return &models.Todo{
+		ID:          docID.Hex(),
+		OwnerID:     value.OwnerID,
+		Title:       value.Title,
+		Description: value.Description,
+		Status:      value.Status,
+		CompletedAt: value.CompletedAt,
+		Priority:    value.Priority,
+		DueAt:       value.DueAt,
+		Tags:        value.Tags,
+		CreatedAt:   timeNow,
+		UpdatedAt:   timeNow,
+		Version:     1,
+	}
+}
+
+// updateValue - values set by Update, tags and status are kept when unset
+func updateValue(value *models.Todo, timeNow time.Time) bson.D {
+	bsonValue := bson.D{
+		{Key: "title", Value: value.Title},
+		{Key: "description", Value: value.Description},
+		{Key: "priority", Value: value.Priority},
+		{Key: "dueAt", Value: value.DueAt},
+		{Key: "updatedAt", Value: timeNow},
+	}
+	if value.Tags != nil {
+		bsonValue = append(bsonValue, bson.E{Key: "tags", Value: value.Tags})
+	}
+	if value.Status != "" {
+		bsonValue = append(bsonValue,
+			bson.E{Key: "status", Value: value.Status},
+			bson.E{Key: "completedAt", Value: value.CompletedAt},
+		)
+	}
+
+	return bsonValue
+}
+
+// writeErrors - fail the results of the write errors of an unordered write, other errors fail the whole write
+func writeErrors(err error, results []*models.BatchResult) error {
+	if err == nil {
+		return nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return mapError(err)
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		results[writeErr.Index].Todo = nil
+		results[writeErr.Index].Error = mapError(writeErr)
+	}
+
+	return nil
+}
+
+// objectIDs - object ids of ids, ids that are not object ids can not match any todo and are left out
+// withoutIDs - docIDs but the ids of removed
+func withoutIDs(docIDs []primitive.ObjectID, removed []primitive.ObjectID) []primitive.ObjectID {
+	results := []primitive.ObjectID{}
+	for _, docID := range docIDs {
+		found := false
+		for _, removedID := range removed {
+			if docID == removedID {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			results = append(results, docID)
+		}
+	}
+
+	return results
+}
+
+func objectIDs(ids []string) []primitive.ObjectID {
+	docIDs := []primitive.ObjectID{}
+	for _, id := range ids {
+		docID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+
+		docIDs = append(docIDs, docID)
+	}
+
+	return docIDs
+}
+
+// activeFilter - match todo by id unless it is in the trash
+func activeFilter(docID primitive.ObjectID) bson.M {
+	return bson.M{"_id": docID, "deletedAt": nil}
+}
+
+// deletedFilter - match todo by id in the trash
+func deletedFilter(docID primitive.ObjectID) bson.M {
+	return bson.M{"_id": docID, "deletedAt": bson.M{"$ne": nil}}
+}
+
+// versionFilter - match todo by id, and by version when an expected version is given
+func versionFilter(docID primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return activeFilter(docID)
+	}
+
+	return bson.M{"_id": docID, "deletedAt": nil, "version": version}
+}
+
+// versionUpdate - set the values and bump the version
+func versionUpdate(value bson.D) bson.D {
+	return bson.D{
+		{Key: "$set", Value: value},
+		{Key: "$inc", Value: bson.M{"version": 1}},
+	}
+}
+
+// mismatchError - error of a versioned write, no document means a version conflict when the todo still exists
+func (r *RepositoryImpl) mismatchError(ctx context.Context, collection *mongo.Collection, docID primitive.ObjectID, version int64, err error) error {
+	if version == 0 || !errors.Is(err, mongo.ErrNoDocuments) {
+		return mapError(err)
+	}
+
+	total, countErr := collection.CountDocuments(ctx, activeFilter(docID))
+	if countErr != nil {
+		return mapError(countErr)
+	}
+	if total == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return ErrVersionConflict
+}
+
+// buildFilter - build mongo filter from todo filter
+func buildFilter(filter *models.TodoFilter) bson.M {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	// deleted todo are only listed in the trash
+	conditions := bson.A{bson.M{"deletedAt": nil}}
+	if filter.Deleted {
+		conditions = bson.A{bson.M{"deletedAt": bson.M{"$ne": nil}}}
+	}
+
+	switch {
+	case filter.OwnerID == "":
+	case len(filter.SharedIDs) > 0:
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"ownerId": filter.OwnerID},
+			bson.M{"_id": bson.M{"$in": objectIDs(filter.SharedIDs)}},
+		}})
+	default:
+		conditions = append(conditions, bson.M{"ownerId": filter.OwnerID})
+	}
+
+	if filter.IDs != nil {
+		conditions = append(conditions, bson.M{"_id": bson.M{"$in": objectIDs(filter.IDs)}})
+	}
+
+	if filter.Keyword != "" {
+		conditions = append(conditions, bson.M{"title": bson.M{"$regex": queryutil.EscapeRegex(filter.Keyword), "$options": "i"}})
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, bson.M{"$text": bson.M{"$search": filter.Search}})
+	}
+
+	switch filter.Status {
+	case "":
+	case models.StatusPending:
+		// documents stored before status existed are pending
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": bson.A{models.StatusPending, nil}}})
+	default:
+		conditions = append(conditions, bson.M{"status": filter.Status})
+	}
+
+	if filter.Overdue {
+		conditions = append(conditions, bson.M{
+			"dueAt":  bson.M{"$lt": timeutil.GetTimeNow()},
+			"status": bson.M{"$nin": bson.A{models.StatusDone, models.StatusCancelled}},
+		})
+	}
+
+	if filter.DueFrom != nil {
+		conditions = append(conditions, bson.M{"dueAt": bson.M{"$gte": filter.DueFrom}})
+	}
+
+	if filter.DueTo != nil {
+		conditions = append(conditions, bson.M{"dueAt": bson.M{"$lte": filter.DueTo}})
+	}
+
+	if len(filter.Tags) > 0 {
+		if filter.TagsMode == models.TagsModeAll {
+			conditions = append(conditions, bson.M{"tags": bson.M{"$all": filter.Tags}})
+		} else {
+			conditions = append(conditions, bson.M{"tags": bson.M{"$in": filter.Tags}})
+		}
+	}
+
+	for _, condition := range filter.Conditions {
+		conditions = append(conditions, buildCondition(condition))
+	}
+
+	return bson.M{"$and": conditions}
+}
+
+// buildCondition - build mongo condition from filter spec condition
+func buildCondition(condition queryutil.Condition) bson.M {
+	key := condition.Field.Key
+
+	switch condition.Operator {
+	case queryutil.OpNe:
+		return bson.M{key: bson.M{"$ne": condition.Values[0]}}
+	case queryutil.OpGt:
+		return bson.M{key: bson.M{"$gt": condition.Values[0]}}
+	case queryutil.OpGte:
+		return bson.M{key: bson.M{"$gte": condition.Values[0]}}
+	case queryutil.OpLt:
+		return bson.M{key: bson.M{"$lt": condition.Values[0]}}
+	case queryutil.OpLte:
+		return bson.M{key: bson.M{"$lte": condition.Values[0]}}
+	case queryutil.OpIn:
+		return bson.M{key: bson.M{"$in": condition.Values}}
+	case queryutil.OpContains:
+		return bson.M{key: bson.M{"$regex": queryutil.EscapeRegex(condition.Values[0].(string)), "$options": "i"}}
+	}
+
+	return bson.M{key: condition.Values[0]}
+}
+
+// buildSort - build mongo sort from todo filter
+// the default is text relevance when searching, otherwise latest updated first
+func buildSort(filter *models.TodoFilter) bson.D {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+
+	sortFields := filter.SortFields()
+	if len(sortFields) == 0 {
+		return bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}
+	}
+
+	sort := bson.D{}
+	for _, field := range sortFields {
+		direction := 1
+		if field.Desc {
+			direction = -1
+		}
+
+		sort = append(sort, bson.E{Key: field.Field.Key, Value: direction})
+	}
+
+	return append(sort, bson.E{Key: "_id", Value: 1})
+}
+
+// buildAfter - keyset condition matching todo after the cursor in the sort order, _id breaks ties
+func buildAfter(sortFields []queryutil.SortField, cursor *paginationutil.Cursor) (bson.M, error) {
+	docID, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return nil, paginationutil.ErrInvalidPageToken
+	}
+
+	branches := bson.A{}
+	equals := bson.A{}
+	for i, field := range sortFields {
+		if after := afterValue(field, cursor.Values[i]); after != nil {
+			branches = append(branches, bson.M{"$and": append(append(bson.A{}, equals...), after)})
+		}
+
+		equals = append(equals, bson.M{field.Field.Key: cursor.Values[i]})
+	}
+	branches = append(branches, bson.M{"$and": append(equals, bson.M{"_id": bson.M{"$gt": docID}})})
+
+	return bson.M{"$or": branches}, nil
+}
+
+// afterValue - condition of values after the given one, unset values sort before any other value
+func afterValue(field queryutil.SortField, value interface{}) bson.M {
+	key := field.Field.Key
+
+	switch {
+	case !field.Desc && value == nil:
+		return bson.M{key: bson.M{"$ne": nil}}
+	case !field.Desc:
+		return bson.M{key: bson.M{"$gt": value}}
+	case value == nil:
+		return nil
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{key: bson.M{"$lt": value}},
+		bson.M{key: nil},
+	}}
+}
+
+// todo - todo of document, filling defaults of documents stored before the field existed
+func (d *todoDocument) todo() *models.Todo {
+	value := d.Todo
+	value.ID = d.ID.Hex()
+	if value.Status == "" {
+		value.Status = models.StatusPending
+	}
+	if value.Tags == nil {
+		value.Tags = []string{}
+	}
+
+	return &value
+}
+
+// database - database of the tenant of ctx, DB_NAME for the default tenant and DB_NAME_<tenant> for the others
+func database(ctx context.Context, client *mongo.Client) *mongo.Database {
+	return client.Database(tenantutil.Name(os.Getenv("DB_NAME"), tenantutil.ID(ctx)))
+}
+
+// mapError - translate mongo driver errors to domain errors
+func mapError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errorsutil.ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return errorsutil.Wrap(errorsutil.KindConflict, "duplicate key", err)
+	case mongo.IsTimeout(err):
+		return errorsutil.Wrap(errorsutil.KindDeadlineExceeded, "database timeout", err)
+	case mongo.IsNetworkError(err):
+		return errorsutil.Wrap(errorsutil.KindUnavailable, "database unavailable", err)
+	}
+
+	return err
+}
@@ -3,8 +3,11 @@ package repository_test
 import (
 	"context"
 	"flag"
+	pkgmongodb "go-clean-grpc/pkg/mongodb"
 	models "go-clean-grpc/todo/models/http"
 	"go-clean-grpc/todo/repository"
+	"go-clean-grpc/todo/repository/repositorytest"
+	tenantutil "go-clean-grpc/utils/tenant"
 	"log"
 	"os"
 	"testing"
@@ -12,6 +15,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -75,6 +79,190 @@ func TestTodoFindAll(t *testing.T) {
 		)
 		mt.AddMockResponses(find, getMore, killCursors)
 
-		repo.FindAll("", 10, 0)
+		repo.FindAll(ctx, &models.TodoFilter{}, 10, 0)
+	})
+}
+
+func TestRepository(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		// each test runs against its own database
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		err := pkgmongodb.MigrateUp(context.Background(), client.Database(dbName), repository.Migrations)
+		assert.NoError(t, err)
+
+		return repository.New(client)
+	})
+}
+
+func TestTenants(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.RunTenants(t, func(t *testing.T) repository.Repository {
+		// each tenant has its own database
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		for _, tenant := range []string{"", "acme", "globex"} {
+			err := pkgmongodb.MigrateUp(context.Background(), client.Database(tenantutil.Name(dbName, tenant)), repository.Migrations)
+			assert.NoError(t, err)
+		}
+
+		return repository.New(client)
+	})
+}
+
+func TestRevisionRepository(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.RunRevisions(t, func(t *testing.T) repository.RevisionRepository {
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		return repository.NewRevisionRepository(client)
+	})
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.RunIdempotency(t, func(t *testing.T) repository.IdempotencyRepository {
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		return repository.NewIdempotencyRepository(client)
+	})
+}
+
+func TestPermissionRepository(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.RunPermissions(t, func(t *testing.T) repository.PermissionRepository {
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		return repository.NewPermissionRepository(client)
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	repositorytest.RunAPIKeys(t, func(t *testing.T) repository.APIKeyRepository {
+		dbName := "todo_test_" + primitive.NewObjectID().Hex()
+		t.Setenv("DB_NAME", dbName)
+		dropDatabases(t, client, dbName)
+
+		return repository.NewAPIKeyRepository(client)
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mtest.ClusterURI()))
+	assert.NoError(t, err)
+	defer client.Disconnect(ctx)
+
+	db := client.Database("todo_test_" + primitive.NewObjectID().Hex())
+	defer db.Drop(ctx)
+
+	// stored before status, priority, tags and version existed
+	_, err = db.Collection("todo").InsertOne(ctx, bson.M{"title": "old", "description": "old"})
+	assert.NoError(t, err)
+
+	err = pkgmongodb.MigrateUp(ctx, db, repository.Migrations)
+	assert.NoError(t, err)
+
+	// applied migrations are skipped
+	err = pkgmongodb.MigrateUp(ctx, db, repository.Migrations)
+	assert.NoError(t, err)
+
+	statuses, err := pkgmongodb.MigrationStatuses(ctx, db, repository.Migrations)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, len(repository.Migrations))
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt)
+	}
+
+	var todo bson.M
+	err = db.Collection("todo").FindOne(ctx, bson.M{"title": "old"}).Decode(&todo)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, todo["status"])
+	assert.Equal(t, int32(models.PriorityNone), todo["priority"])
+	assert.Equal(t, bson.A{}, todo["tags"])
+	assert.Equal(t, int32(1), todo["version"])
+
+	indexes, err := db.Collection("todo").Indexes().ListSpecifications(ctx)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Subset(t, names, []string{"todo_text", "todo_updated_at", "todo_status_updated_at", "todo_tags", "todo_due_at", "todo_deleted_at", "todo_owner_id_updated_at"})
+
+	indexes, err = db.Collection("todo_revision").Indexes().ListSpecifications(ctx)
+	assert.NoError(t, err)
+	names = []string{}
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Contains(t, names, "todo_revision_todo_id_created_at")
+
+	indexes, err = db.Collection("idempotency_key").Indexes().ListSpecifications(ctx)
+	assert.NoError(t, err)
+	names = []string{}
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Contains(t, names, "idempotency_key_expires_at")
+
+	indexes, err = db.Collection("todo_permission").Indexes().ListSpecifications(ctx)
+	assert.NoError(t, err)
+	names = []string{}
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Subset(t, names, []string{"todo_permission_todo_id_principal", "todo_permission_user_id", "todo_permission_group"})
+
+	indexes, err = db.Collection("api_key").Indexes().ListSpecifications(ctx)
+	assert.NoError(t, err)
+	names = []string{}
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	assert.Subset(t, names, []string{"api_key_hash", "api_key_user_id_created_at", "api_key_tenant_user_id_created_at"})
+
+	// the backfill can not be rolled back
+	err = pkgmongodb.MigrateDown(ctx, db, repository.Migrations, 8)
+	assert.Error(t, err)
+}
+
+// dropDatabases - drop the database dbName and the databases of its tenants once the test ends
+func dropDatabases(t *testing.T, client *mongo.Client, dbName string) {
+	t.Cleanup(func() {
+		for _, tenant := range []string{"", "acme", "globex"} {
+			client.Database(tenantutil.Name(dbName, tenant)).Drop(context.Background())
+		}
 	})
 }
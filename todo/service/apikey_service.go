@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	models "go-clean-grpc/todo/models/http"
+	"go-clean-grpc/todo/policy"
+	todorepository "go-clean-grpc/todo/repository"
+	actorutil "go-clean-grpc/utils/actor"
+	errorsutil "go-clean-grpc/utils/errors"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// KeyService represent the API key service, only admins manage the keys
+type KeyService interface {
+	CreateKey(ctx context.Context, value *pkgauth.APIKey) (*models.CreatedAPIKey, error)
+	ListKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error)
+	RevokeKey(ctx context.Context, id string) (*pkgauth.APIKey, error)
+}
+
+var errAdminRequired = errorsutil.New(errorsutil.KindPermissionDenied, "admin role is required")
+
+type KeyServiceImpl struct {
+	keys todorepository.APIKeyRepository
+}
+
+// NewKeyService will create new an KeyServiceImpl object representation of KeyService interface
+func NewKeyService(keys todorepository.APIKeyRepository) KeyService {
+	return &KeyServiceImpl{
+		keys: keys,
+	}
+}
+
+// CreateKey - mint API key of value.UserID, the caller when empty, in the tenant of ctx service
+// the key is only returned here, the store keeps its hash
+func (s *KeyServiceImpl) CreateKey(ctx context.Context, value *pkgauth.APIKey) (*models.CreatedAPIKey, error) {
+	if !policy.Admin(ctx) {
+		return nil, errAdminRequired
+	}
+
+	userID := value.UserID
+	if userID == "" {
+		userID = pkgauth.UserID(ctx)
+	}
+	if userID == "" {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "user_id is required without authentication")
+	}
+
+	if value.ExpiresAt != nil && !value.ExpiresAt.After(timeutil.GetTimeNow()) {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "expires_at must be in the future")
+	}
+
+	key, hash, prefix, err := pkgauth.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.keys.StoreKey(ctx, &pkgauth.APIKey{
+		Name:      value.Name,
+		Hash:      hash,
+		Prefix:    prefix,
+		UserID:    userID,
+		Tenant:    tenantutil.ID(ctx),
+		Scopes:    normalizeTags(value.Scopes),
+		CreatedBy: actorutil.FromContext(ctx).ID,
+		ExpiresAt: value.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: result, Key: key}, nil
+}
+
+// ListKeys - get API keys of the user in the tenant of ctx, of every user when empty, service
+func (s *KeyServiceImpl) ListKeys(ctx context.Context, userID string) ([]*pkgauth.APIKey, error) {
+	if !policy.Admin(ctx) {
+		return nil, errAdminRequired
+	}
+
+	return s.keys.FindKeys(ctx, userID)
+}
+
+// RevokeKey - revoke API key by id service, the key is rejected from then on
+func (s *KeyServiceImpl) RevokeKey(ctx context.Context, id string) (*pkgauth.APIKey, error) {
+	if !policy.Admin(ctx) {
+		return nil, errAdminRequired
+	}
+
+	return s.keys.RevokeKey(ctx, id, timeutil.GetTimeNow())
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	mockrepository "go-clean-grpc/todo/mocks/repository"
+	"go-clean-grpc/todo/policy"
+	todoservice "go-clean-grpc/todo/service"
+	actorutil "go-clean-grpc/utils/actor"
+	errorsutil "go-clean-grpc/utils/errors"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+func TestKeyCreate(t *testing.T) {
+	admin := actorutil.NewContext(
+		pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "carol", Roles: []string{policy.RoleAdmin}}),
+		actorutil.Actor{ID: "carol", Transport: actorutil.TransportHTTP},
+	)
+
+	t.Run("success when admin creates key", func(t *testing.T) {
+		mockKeys := new(mockrepository.APIKeyRepository)
+		service := todoservice.NewKeyService(mockKeys)
+		expiresAt := time.Now().Add(time.Hour)
+
+		var stored *pkgauth.APIKey
+		mockKeys.On("StoreKey", mock.Anything, mock.AnythingOfType("*auth.APIKey")).Return(func(ctx context.Context, value *pkgauth.APIKey) *pkgauth.APIKey {
+			stored = value
+			result := *value
+			result.ID = "k1"
+			return &result
+		}, nil)
+
+		result, err := service.CreateKey(admin, &pkgauth.APIKey{Name: "ci", UserID: "robot", Scopes: []string{policy.ScopeRead, policy.ScopeRead}, ExpiresAt: &expiresAt})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "k1", result.ID)
+		assert.Equal(t, "robot", result.UserID)
+		assert.Equal(t, []string{policy.ScopeRead}, result.Scopes)
+		assert.Equal(t, "carol", result.CreatedBy)
+		assert.Equal(t, pkgauth.HashKey(result.Key), stored.Hash)
+		assert.Equal(t, result.Key[:len(stored.Prefix)], stored.Prefix)
+	})
+
+	t.Run("success when key of the caller", func(t *testing.T) {
+		mockKeys := new(mockrepository.APIKeyRepository)
+		service := todoservice.NewKeyService(mockKeys)
+
+		mockKeys.On("StoreKey", mock.Anything, mock.MatchedBy(func(value *pkgauth.APIKey) bool {
+			return value.UserID == "carol"
+		})).Return(&pkgauth.APIKey{ID: "k1", UserID: "carol"}, nil)
+
+		_, err := service.CreateKey(admin, &pkgauth.APIKey{Name: "ci", Scopes: []string{policy.ScopeRead}})
+
+		assert.NoError(t, err)
+		mockKeys.AssertExpectations(t)
+	})
+
+	t.Run("success when key of the tenant", func(t *testing.T) {
+		mockKeys := new(mockrepository.APIKeyRepository)
+		service := todoservice.NewKeyService(mockKeys)
+
+		mockKeys.On("StoreKey", mock.Anything, mock.MatchedBy(func(value *pkgauth.APIKey) bool {
+			return value.Tenant == "acme"
+		})).Return(&pkgauth.APIKey{ID: "k1", UserID: "carol", Tenant: "acme"}, nil)
+
+		_, err := service.CreateKey(tenantutil.NewContext(admin, tenantutil.Tenant{ID: "acme"}), &pkgauth.APIKey{Name: "ci", Scopes: []string{policy.ScopeRead}})
+
+		assert.NoError(t, err)
+		mockKeys.AssertExpectations(t)
+	})
+
+	t.Run("error when expired", func(t *testing.T) {
+		mockKeys := new(mockrepository.APIKeyRepository)
+		service := todoservice.NewKeyService(mockKeys)
+		expiresAt := time.Now().Add(-time.Hour)
+
+		_, err := service.CreateKey(admin, &pkgauth.APIKey{Name: "ci", Scopes: []string{policy.ScopeRead}, ExpiresAt: &expiresAt})
+
+		assert.Equal(t, errorsutil.KindInvalidArgument, errorsutil.KindOf(err))
+		mockKeys.AssertNotCalled(t, "StoreKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error permission denied when not admin", func(t *testing.T) {
+		mockKeys := new(mockrepository.APIKeyRepository)
+		service := todoservice.NewKeyService(mockKeys)
+
+		// keys have no roles, they can not mint other keys
+		for _, claims := range []*pkgauth.Claims{
+			{Subject: "alice"},
+			{Subject: "robot", KeyID: "k1", Scopes: []string{policy.ScopeRead, policy.ScopeWrite}},
+		} {
+			ctx := pkgauth.NewContext(context.Background(), claims)
+
+			_, err := service.CreateKey(ctx, &pkgauth.APIKey{Name: "ci", Scopes: []string{policy.ScopeRead}})
+			assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+			_, err = service.ListKeys(ctx, "")
+			assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+			_, err = service.RevokeKey(ctx, "k1")
+			assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+		}
+	})
+}
+
+func TestKeyRevoke(t *testing.T) {
+	mockKeys := new(mockrepository.APIKeyRepository)
+	service := todoservice.NewKeyService(mockKeys)
+	revokedAt := time.Now()
+
+	mockKeys.On("RevokeKey", mock.Anything, "k1", mock.AnythingOfType("time.Time")).Return(&pkgauth.APIKey{ID: "k1", RevokedAt: &revokedAt}, nil)
+	mockKeys.On("FindKeys", mock.Anything, "robot").Return([]*pkgauth.APIKey{{ID: "k1", RevokedAt: &revokedAt}}, nil)
+
+	result, err := service.RevokeKey(context.Background(), "k1")
+	assert.NoError(t, err)
+	assert.NotNil(t, result.RevokedAt)
+
+	results, err := service.ListKeys(context.Background(), "robot")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	mockKeys.AssertExpectations(t)
+}
@@ -1,49 +1,153 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	"go-clean-grpc/pkg/config"
+	"go-clean-grpc/pkg/logger"
 	models "go-clean-grpc/todo/models/http"
+	"go-clean-grpc/todo/policy"
 	todorepository "go-clean-grpc/todo/repository"
+	actorutil "go-clean-grpc/utils/actor"
+	errorsutil "go-clean-grpc/utils/errors"
+	paginationutil "go-clean-grpc/utils/pagination"
+	queryutil "go-clean-grpc/utils/query"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
 )
 
 // Service represent the todo service
 type Service interface {
-	GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error)
-	GetByID(id string) (*models.Todo, error)
-	Create(value *models.Todo) (*models.Todo, error)
-	Update(id string, value *models.Todo) (*models.Todo, error)
-	Delete(id string) error
+	GetAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error)
+	GetByID(ctx context.Context, id string) (*models.Todo, error)
+	Create(ctx context.Context, value *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error)
+	Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error)
+	Complete(ctx context.Context, id string) (*models.Todo, error)
+	Reopen(ctx context.Context, id string) (*models.Todo, error)
+	AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error)
+	RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error)
+	GetTagCounts(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error)
+	Delete(ctx context.Context, id string) error
+	GetTrash(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error)
+	Restore(ctx context.Context, id string) (*models.Todo, error)
+	Purge(ctx context.Context, id string) error
+	PurgeExpired(ctx context.Context, retention time.Duration) (int, error)
+	ListHistory(ctx context.Context, id string, limit int, offset int) ([]*models.Revision, int, error)
+	Revert(ctx context.Context, id string, revisionID string, version int64) (*models.Todo, error)
+	Watch(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(event *models.TodoEvent) error) error
+	CreateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error)
+	UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error)
+	DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error)
+	Export(ctx context.Context, filter *models.TodoFilter, send func(todo *models.Todo) error) error
+	Grant(ctx context.Context, id string, value *models.Permission) (*models.Permission, error)
+	Revoke(ctx context.Context, id string, permissionID string) error
+	ListPermissions(ctx context.Context, id string) ([]*models.Permission, error)
 }
 
 type ServiceImpl struct {
-	repository todorepository.Repository
+	repository  todorepository.Repository
+	revisions   todorepository.RevisionRepository
+	events      todorepository.EventRepository
+	permissions todorepository.PermissionRepository
+	policy      policy.Policy
+	batchSize   int
+}
+
+// transitions - allowed status transitions, keyed by current status
+var transitions = map[string][]string{
+	models.StatusPending:    {models.StatusInProgress, models.StatusDone, models.StatusCancelled},
+	models.StatusInProgress: {models.StatusPending, models.StatusDone, models.StatusCancelled},
+	models.StatusDone:       {models.StatusPending},
+	models.StatusCancelled:  {models.StatusPending},
+}
+
+// eventTypes - todo event type of revision action, actions without event type send no event
+var eventTypes = map[string]string{
+	models.ActionCreate:  models.EventCreated,
+	models.ActionRestore: models.EventCreated,
+	models.ActionUpdate:  models.EventUpdated,
+	models.ActionStatus:  models.EventUpdated,
+	models.ActionTags:    models.EventUpdated,
+	models.ActionRevert:  models.EventUpdated,
+	models.ActionDelete:  models.EventDeleted,
 }
 
+// exportPageSize - todo read at once by Export
+const exportPageSize = 500
+
+// revertFields - fields of todo set back by a revert
+var revertFields = []string{"title", "description", "status", "priority", "due_at", "tags", "completed_at"}
+
 // New will create new an ServiceImpl object representation of Service interface
-func New(repository todorepository.Repository) Service {
+func New(repository todorepository.Repository, revisions todorepository.RevisionRepository, events todorepository.EventRepository, permissions todorepository.PermissionRepository) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:  repository,
+		revisions:   revisions,
+		events:      events,
+		permissions: permissions,
+		policy:      policy.New(permissions),
+		batchSize:   config.GetInt("BATCH_MAX_SIZE", 1000),
 	}
 }
 
-// GetAll - get all todo service
-func (s *ServiceImpl) GetAll(keyword string, limit int, offset int) ([]*models.Todo, int, error) {
-	res, err := s.repository.FindAll(keyword, limit, offset)
+// GetAll - get all todo of the user and shared with them service, next page token is empty on the last page
+func (s *ServiceImpl) GetAll(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error) {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	err := s.policy.Scope(ctx, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	sort := filter.SortFields()
+	if filter.PageToken != "" {
+		after, err := decodePageToken(filter.PageToken, sort)
+		if err != nil {
+			return nil, 0, "", err
+		}
+
+		// keyset pagination continues after the cursor instead of skipping
+		filter.After = after
+		offset = 0
+	}
+
+	// Fetch one more item to know whether there is a next page
+	res, err := s.repository.FindAll(ctx, filter, limit+1, offset)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
+	}
+
+	nextPageToken := ""
+	if len(res) > limit {
+		res = res[:limit]
+
+		if len(sort) > 0 && limit > 0 {
+			nextPageToken, err = encodePageToken(res[limit-1], sort)
+			if err != nil {
+				return nil, 0, "", err
+			}
+		}
 	}
 
 	// Count total
-	total, err := s.repository.CountFindAll(keyword)
+	total, err := s.repository.CountFindAll(ctx, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
 
-	return res, total, nil
+	return res, total, nextPageToken, nil
 }
 
 // GetByID - get todo by id service
-func (s *ServiceImpl) GetByID(id string) (*models.Todo, error) {
-	res, err := s.repository.FindById(id)
+func (s *ServiceImpl) GetByID(ctx context.Context, id string) (*models.Todo, error) {
+	res, err := s.findByID(ctx, id, policy.ActionRead)
 	if err != nil {
 		return nil, err
 	}
@@ -51,43 +155,997 @@ func (s *ServiceImpl) GetByID(id string) (*models.Todo, error) {
 	return res, nil
 }
 
-// Create - creating todo service
-func (r *ServiceImpl) Create(value *models.Todo) (*models.Todo, error) {
-	res, err := r.repository.Store(&models.Todo{
-		Title:       value.Title,
-		Description: value.Description,
+// Create - creating todo of the user service
+func (r *ServiceImpl) Create(ctx context.Context, value *models.Todo) (*models.Todo, error) {
+	err := r.policy.Allow(ctx, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.checkQuota(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.Store(ctx, newTodo(value, pkgauth.UserID(ctx)))
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, &models.Revision{Action: models.ActionCreate}, nil, res)
+
+	return res, nil
+}
+
+// Update - update todo service, value.Version is the expected current version and 0 skips the check
+func (r *ServiceImpl) Update(ctx context.Context, id string, value *models.Todo) (*models.Todo, error) {
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := updatedTodo(current, value)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.Update(ctx, id, todo)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, &models.Revision{Action: models.ActionUpdate}, current, res)
+
+	return res, nil
+}
+
+// Patch - partially update todo service, fields not in the patch are left untouched
+func (r *ServiceImpl) Patch(ctx context.Context, id string, patch *models.TodoPatch) (*models.Todo, error) {
+	if len(patch.Fields) == 0 {
+		res, err := r.findByID(ctx, id, policy.ActionRead)
+		if err != nil {
+			return nil, err
+		}
+		if patch.Version != 0 && patch.Version != res.Version {
+			return nil, todorepository.ErrVersionConflict
+		}
+
+		return res, nil
+	}
+
+	todo := *patch.Todo
+	fields := patch.Fields
+	if patch.Has("tags") {
+		todo.Tags = normalizeTags(todo.Tags)
+	}
+
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Has("status") {
+		if current.Status == todo.Status {
+			todo.CompletedAt = current.CompletedAt
+		} else {
+			err = checkTransition(current.Status, todo.Status)
+			if err != nil {
+				return nil, err
+			}
+
+			todo.CompletedAt = completedAt(todo.Status)
+		}
+		fields = append(append([]string{}, fields...), "completed_at")
+	}
+
+	res, err := r.repository.Patch(ctx, id, &models.TodoPatch{
+		Fields:  fields,
+		Todo:    &todo,
+		Version: patch.Version,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	r.record(ctx, &models.Revision{Action: models.ActionUpdate}, current, res)
+
 	return res, nil
 }
 
-// Update - update todo service
-func (r *ServiceImpl) Update(id string, value *models.Todo) (*models.Todo, error) {
-	_, err := r.repository.CountFindByID(id)
+// Complete - mark todo as done service
+func (r *ServiceImpl) Complete(ctx context.Context, id string) (*models.Todo, error) {
+	return r.changeStatus(ctx, id, models.StatusDone)
+}
+
+// Reopen - move done or cancelled todo back to pending service
+func (r *ServiceImpl) Reopen(ctx context.Context, id string) (*models.Todo, error) {
+	return r.changeStatus(ctx, id, models.StatusPending)
+}
+
+// AddTags - add tags to todo service
+func (r *ServiceImpl) AddTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "tags is required")
+	}
+
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := r.repository.Update(id, &models.Todo{
-		Title:       value.Title,
-		Description: value.Description,
+	res, err := r.repository.AddTags(ctx, id, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, &models.Revision{Action: models.ActionTags}, current, res)
+
+	return res, nil
+}
+
+// RemoveTags - remove tags from todo service
+func (r *ServiceImpl) RemoveTags(ctx context.Context, id string, tags []string) (*models.Todo, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "tags is required")
+	}
+
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.RemoveTags(ctx, id, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, &models.Revision{Action: models.ActionTags}, current, res)
+
+	return res, nil
+}
+
+// GetTagCounts - count todo per tag service
+func (s *ServiceImpl) GetTagCounts(ctx context.Context, filter *models.TodoFilter) ([]*models.TagCount, error) {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	err := s.policy.Scope(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.CountTags(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Delete - move todo to the trash service
+func (r *ServiceImpl) Delete(ctx context.Context, id string) error {
+	current, err := r.findByID(ctx, id, policy.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	// stored times have millisecond precision
+	deletedAt := timeutil.GetTimeNow().UTC().Truncate(time.Millisecond)
+	err = r.repository.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.recordDelete(ctx, current, deletedAt)
+
+	return nil
+}
+
+// GetTrash - get deleted todo service, latest deleted first unless sorted
+func (s *ServiceImpl) GetTrash(ctx context.Context, filter *models.TodoFilter, limit int, offset int) ([]*models.Todo, int, string, error) {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+	filter.Deleted = true
+
+	return s.GetAll(ctx, filter, limit, offset)
+}
+
+// Restore - move todo out of the trash service
+func (r *ServiceImpl) Restore(ctx context.Context, id string) (*models.Todo, error) {
+	err := r.checkOwner(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.checkQuota(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// deleted todo can not be found, the deletion time is the one of the delete revision
+	deleted := *res
+	deleted.DeletedAt = r.deletedAt(ctx, id)
+	r.record(ctx, &models.Revision{Action: models.ActionRestore}, &deleted, res)
+
+	return res, nil
+}
+
+// Purge - permanently delete todo in the trash service
+func (r *ServiceImpl) Purge(ctx context.Context, id string) error {
+	err := r.checkOwner(ctx, id, true)
+	if err != nil {
+		return err
+	}
+
+	err = r.repository.Purge(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// the permissions are not kept with the history, a todo created again with the id is not shared
+	err = r.permissions.DeletePermissions(ctx, id)
+	if err != nil {
+		logger.Error(fmt.Errorf("delete permissions of todo %s: %w", id, err))
+	}
+
+	revision := &models.Revision{TodoID: id, Action: models.ActionPurge}
+	if last := r.lastRevision(ctx, id); last != nil {
+		revision.Version = last.Version
+		revision.OwnerID = last.OwnerID
+	}
+	r.record(ctx, revision, nil, nil)
+
+	return nil
+}
+
+// PurgeExpired - permanently delete todo in the trash for longer than retention service
+// the bulk purge records no revision, the history of purged todo ends with their delete revision
+func (r *ServiceImpl) PurgeExpired(ctx context.Context, retention time.Duration) (int, error) {
+	ids, err := r.repository.PurgeDeleted(ctx, timeutil.GetTimeNow().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	// the permissions are deleted like with Purge
+	for _, id := range ids {
+		err = r.permissions.DeletePermissions(ctx, id)
+		if err != nil {
+			logger.Error(fmt.Errorf("delete permissions of todo %s: %w", id, err))
+		}
+	}
+
+	return len(ids), nil
+}
+
+// ListHistory - get revisions of todo service, latest first
+// the history of purged todo is kept, todo without any revision must still exist
+// the history is only listed to the users that can read the todo of the owner of the latest revision
+func (s *ServiceImpl) ListHistory(ctx context.Context, id string, limit int, offset int) ([]*models.Revision, int, error) {
+	total, err := s.revisions.CountRevisions(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		_, err := s.repository.CountFindByID(ctx, id)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if !s.policy.Unrestricted(ctx) {
+			_, err = s.findByID(ctx, id, policy.ActionRead)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		return []*models.Revision{}, 0, nil
+	}
+
+	if !s.policy.Unrestricted(ctx) {
+		last := s.lastRevision(ctx, id)
+		if last == nil {
+			return nil, 0, errorsutil.ErrNotFound
+		}
+
+		err = s.policy.Authorize(ctx, policy.ActionRead, &models.Todo{ID: id, OwnerID: last.OwnerID})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	res, err := s.revisions.FindRevisions(ctx, id, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+
+// Revert - set todo back to its state after a revision service, version is the expected current version and 0 skips the check
+func (r *ServiceImpl) Revert(ctx context.Context, id string, revisionID string, version int64) (*models.Todo, error) {
+	revision, err := r.revisions.FindRevision(ctx, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// revisions of the todo of another user are not found unless it is shared with the user
+	err = r.policy.Authorize(ctx, policy.ActionWrite, &models.Todo{ID: id, OwnerID: revision.OwnerID})
+	if err != nil {
+		return nil, err
+	}
+
+	if revision.Todo == nil {
+		return nil, errorsutil.New(errorsutil.KindFailedPrecondition, fmt.Sprintf("cannot revert to a %s revision", revision.Action))
+	}
+
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.repository.Patch(ctx, id, &models.TodoPatch{
+		Fields:  revertFields,
+		Todo:    revision.Todo,
+		Version: version,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	r.record(ctx, &models.Revision{Action: models.ActionRevert, Reverts: revision.ID}, current, res)
+
+	return res, nil
+}
+
+func (r *ServiceImpl) changeStatus(ctx context.Context, id string, status string) (*models.Todo, error) {
+	current, err := r.findByID(ctx, id, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTransition(current.Status, status)
+	if err != nil {
+		return nil, err
+	}
+
+	// the status is only changed from the status checked above, a concurrent change is a version conflict
+	res, err := r.repository.UpdateStatus(ctx, id, status, completedAt(status), current.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, &models.Revision{Action: models.ActionStatus}, current, res)
+
 	return res, nil
 }
 
-// Delete - delete todo service
-func (r *ServiceImpl) Delete(id string) error {
-	err := r.repository.Delete(id)
+// CreateMany - create todo of a batch service, each item succeeds or fails on its own
+func (r *ServiceImpl) CreateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	err := r.checkBatchSize(len(values))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.policy.Allow(ctx, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.checkQuota(ctx, len(values))
+	if err != nil {
+		return nil, err
+	}
+
+	owner := pkgauth.UserID(ctx)
+	todos := make([]*models.Todo, 0, len(values))
+	for _, value := range values {
+		todos = append(todos, newTodo(value, owner))
+	}
+
+	results, err := r.repository.StoreMany(ctx, todos)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Error == nil {
+			r.record(ctx, &models.Revision{Action: models.ActionCreate}, nil, result.Todo)
+		}
+	}
+
+	return results, nil
+}
+
+// UpdateMany - update todo of a batch by value.ID service, value.Version is the expected current version and 0 skips the check
+// each item succeeds or fails on its own
+func (r *ServiceImpl) UpdateMany(ctx context.Context, values []*models.Todo) ([]*models.BatchResult, error) {
+	err := r.checkBatchSize(len(values))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.ID)
+	}
+
+	batch, err := r.newBatch(ctx, ids, policy.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := []*models.Todo{}
+	for i, value := range values {
+		current, ok := batch.currents[i]
+		if !ok {
+			continue
+		}
+
+		todo, err := updatedTodo(current, value)
+		if err != nil {
+			batch.results[i].Error = err
+			continue
+		}
+
+		todo.ID = value.ID
+		batch.pending = append(batch.pending, i)
+		todos = append(todos, todo)
+	}
+
+	results, err := r.repository.UpdateMany(ctx, todos)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range batch.merge(results) {
+		if result.Error == nil && result.Todo != nil {
+			r.record(ctx, &models.Revision{Action: models.ActionUpdate}, batch.currents[i], result.Todo)
+		}
+	}
+
+	return batch.results, nil
+}
+
+// DeleteMany - move todo of a batch to the trash service, each item succeeds or fails on its own
+func (r *ServiceImpl) DeleteMany(ctx context.Context, ids []string) ([]*models.BatchResult, error) {
+	err := r.checkBatchSize(len(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := r.newBatch(ctx, ids, policy.ActionDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []string{}
+	for i, id := range ids {
+		if _, ok := batch.currents[i]; ok {
+			batch.pending = append(batch.pending, i)
+			pending = append(pending, id)
+		}
+	}
+
+	// stored times have millisecond precision
+	deletedAt := timeutil.GetTimeNow().UTC().Truncate(time.Millisecond)
+	results, err := r.repository.DeleteMany(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range batch.merge(results) {
+		if result.Error == nil {
+			r.recordDelete(ctx, batch.currents[i], deletedAt)
+		}
+	}
+
+	return batch.results, nil
+}
+
+// Export - send all todo matching filter in the list order, reading them a page at a time
+// the paging params of filter are ignored, search results are read at once as they are ranked together
+func (s *ServiceImpl) Export(ctx context.Context, filter *models.TodoFilter, send func(todo *models.Todo) error) error {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	err := s.policy.Scope(ctx, filter)
+	if err != nil {
+		return err
+	}
+	filter.PageToken = ""
+	filter.After = nil
+
+	sort := filter.SortFields()
+	limit := exportPageSize
+	if len(sort) == 0 {
+		limit = 0
+	}
+
+	for {
+		results, err := s.repository.FindAll(ctx, filter, limit, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, todo := range results {
+			err = send(todo)
+			if err != nil {
+				return err
+			}
+		}
+
+		if limit == 0 || len(results) < limit {
+			return nil
+		}
+
+		// keyset pagination continues after the last todo sent
+		filter.After = pageCursor(results[len(results)-1], sort)
+	}
+}
+
+// Watch - send created, updated and deleted events of todo of the user matching the filter until ctx is done
+// updated todo are sent while they match the filter, deleted events are always sent
+// todo shared with the user while watching are only watched by the next call
+func (s *ServiceImpl) Watch(ctx context.Context, filter *models.TodoFilter, resumeToken string, send func(event *models.TodoEvent) error) error {
+	if filter == nil {
+		filter = &models.TodoFilter{}
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	err := s.policy.Scope(ctx, filter)
 	if err != nil {
 		return err
 	}
 
+	return s.events.Watch(ctx, resumeToken, func(event *models.TodoEvent) error {
+		if !filter.MatchOwner(event.OwnerID, event.TodoID) {
+			return nil
+		}
+
+		if event.Todo != nil && !filter.Match(event.Todo, timeutil.GetTimeNow()) {
+			return nil
+		}
+
+		return send(event)
+	})
+}
+
+// Grant - grant role on todo to a user or a group service, granting again to them replaces the role
+func (r *ServiceImpl) Grant(ctx context.Context, id string, value *models.Permission) (*models.Permission, error) {
+	err := checkPermission(value)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := r.findByID(ctx, id, policy.ActionShare)
+	if err != nil {
+		return nil, err
+	}
+
+	if value.UserID != "" && value.UserID == current.OwnerID {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "todo can not be shared with its owner")
+	}
+
+	permission := *value
+	permission.TodoID = id
+	permission.GrantedBy = actorutil.FromContext(ctx).ID
+
+	res, err := r.permissions.StorePermission(ctx, &permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Revoke - revoke permission on todo service
+func (r *ServiceImpl) Revoke(ctx context.Context, id string, permissionID string) error {
+	_, err := r.findByID(ctx, id, policy.ActionShare)
+	if err != nil {
+		return err
+	}
+
+	return r.permissions.DeletePermission(ctx, id, permissionID)
+}
+
+// ListPermissions - get permissions granted on todo service, oldest first
+func (s *ServiceImpl) ListPermissions(ctx context.Context, id string) ([]*models.Permission, error) {
+	_, err := s.findByID(ctx, id, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.permissions.FindPermissions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// record - store revision and publish event of a change made by the actor of ctx, after is nil when the todo is gone
+// the change is already made, a revision that can not be stored is logged and does not fail it
+func (r *ServiceImpl) record(ctx context.Context, revision *models.Revision, before *models.Todo, after *models.Todo) {
+	actor := actorutil.FromContext(ctx)
+	revision.Actor = actor.ID
+	revision.Transport = actor.Transport
+	if revision.OwnerID == "" {
+		revision.OwnerID = ownerOf(ctx, before, after)
+	}
+
+	if after != nil {
+		revision.TodoID = after.ID
+		revision.Version = after.Version
+		revision.Changes = models.Diff(before, after)
+		revision.Todo = after
+	}
+	if revision.Changes == nil {
+		revision.Changes = []*models.FieldChange{}
+	}
+
+	_, err := r.revisions.StoreRevision(ctx, revision)
+	if err != nil {
+		logger.Error(fmt.Errorf("store %s revision of todo %s: %w", revision.Action, revision.TodoID, err))
+	}
+
+	eventType, ok := eventTypes[revision.Action]
+	if !ok {
+		return
+	}
+
+	err = r.events.Publish(ctx, &models.TodoEvent{Type: eventType, TodoID: revision.TodoID, Todo: after, OwnerID: revision.OwnerID})
+	if err != nil {
+		logger.Error(fmt.Errorf("publish %s event of todo %s: %w", eventType, revision.TodoID, err))
+	}
+}
+
+// recordDelete - record the move of current to the trash
+func (r *ServiceImpl) recordDelete(ctx context.Context, current *models.Todo, deletedAt time.Time) {
+	// the deleted todo is only in the trash, the revision keeps no snapshot of it
+	deleted := *current
+	deleted.DeletedAt = &deletedAt
+	r.record(ctx, &models.Revision{
+		TodoID:  current.ID,
+		OwnerID: current.OwnerID,
+		Action:  models.ActionDelete,
+		Version: current.Version + 1,
+		Changes: models.Diff(current, &deleted),
+	}, nil, nil)
+}
+
+// checkBatchSize - check that a batch has at most BATCH_MAX_SIZE items
+func (r *ServiceImpl) checkBatchSize(size int) error {
+	if size > r.batchSize {
+		return errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf("batch has %d items, at most %d are allowed", size, r.batchSize))
+	}
+
+	return nil
+}
+
+// checkQuota - the tenant of ctx can have count more todo out of the trash, the quota is not checked when unlimited
+// concurrent requests may both pass the check, the quota can be exceeded by the todo they create
+func (r *ServiceImpl) checkQuota(ctx context.Context, count int) error {
+	maxTodos := tenantutil.FromContext(ctx).MaxTodos
+	if maxTodos <= 0 {
+		return nil
+	}
+
+	total, err := r.repository.CountFindAll(ctx, &models.TodoFilter{})
+	if err != nil {
+		return err
+	}
+
+	if total+count > maxTodos {
+		return errorsutil.New(errorsutil.KindResourceExhausted, fmt.Sprintf("tenant has %d todo, at most %d are allowed", total, maxTodos))
+	}
+
 	return nil
 }
+
+// newBatch - batch of todo by ids with their current state to do action on
+// items of todo not found, not shared with the user, not allowed to the user or given twice fail
+func (r *ServiceImpl) newBatch(ctx context.Context, ids []string, action policy.Action) (*batch, error) {
+	err := r.policy.Allow(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := r.repository.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := r.policy.Grants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*models.Todo{}
+	for _, todo := range found {
+		byID[todo.ID] = todo
+	}
+
+	b := &batch{
+		results:  make([]*models.BatchResult, 0, len(ids)),
+		currents: map[int]*models.Todo{},
+	}
+	seen := map[string]bool{}
+	for i, id := range ids {
+		b.results = append(b.results, &models.BatchResult{ID: id})
+
+		current, ok := byID[id]
+		switch {
+		case seen[id]:
+			b.results[i].Error = errorsutil.New(errorsutil.KindInvalidArgument, "todo "+id+" is more than once in the batch")
+		case !ok:
+			b.results[i].Error = errorsutil.ErrNotFound
+		default:
+			b.results[i].Error = grants.Authorize(action, current)
+			if b.results[i].Error == nil {
+				b.currents[i] = current
+			}
+		}
+		seen[id] = true
+	}
+
+	return b, nil
+}
+
+// batch - results of a batch by item index, currents has the todo of the items that did not fail yet
+type batch struct {
+	results  []*models.BatchResult
+	currents map[int]*models.Todo
+	pending  []int // indexes of the items sent to the repository, in order
+}
+
+// merge - set the repository results of the pending items, returned by item index
+func (b *batch) merge(results []*models.BatchResult) map[int]*models.BatchResult {
+	merged := map[int]*models.BatchResult{}
+	for j, result := range results {
+		i := b.pending[j]
+		b.results[i] = result
+		merged[i] = result
+	}
+
+	return merged
+}
+
+// findByID - todo by id to do action on, todo of another user are not found unless they are shared with the user
+func (r *ServiceImpl) findByID(ctx context.Context, id string, action policy.Action) (*models.Todo, error) {
+	res, err := r.repository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.policy.Authorize(ctx, action, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// checkOwner - fail with not found unless todo by id, in the trash when deleted, is of the user of ctx
+// the trash is only handled by the owners, shared todo in the trash are not found
+func (r *ServiceImpl) checkOwner(ctx context.Context, id string, deleted bool) error {
+	err := r.policy.Allow(ctx, policy.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	if r.policy.Unrestricted(ctx) {
+		return nil
+	}
+	owner := pkgauth.UserID(ctx)
+
+	total, err := r.repository.CountFindAll(ctx, &models.TodoFilter{OwnerID: owner, IDs: []string{id}, Deleted: deleted})
+	if err != nil {
+		return err
+	}
+
+	if total == 0 {
+		return errorsutil.ErrNotFound
+	}
+
+	return nil
+}
+
+// lastRevision - latest revision of todo, nil when there is none or it can not be read
+func (r *ServiceImpl) lastRevision(ctx context.Context, id string) *models.Revision {
+	results, err := r.revisions.FindRevisions(ctx, id, 1, 0)
+	if err != nil || len(results) == 0 {
+		return nil
+	}
+
+	return results[0]
+}
+
+// deletedAt - deletion time recorded by the latest revision when it is a delete revision
+func (r *ServiceImpl) deletedAt(ctx context.Context, id string) *time.Time {
+	last := r.lastRevision(ctx, id)
+	if last == nil || last.Action != models.ActionDelete {
+		return nil
+	}
+
+	for _, change := range last.Changes {
+		value, ok := change.After.(string)
+		if change.Field != "deleted_at" || !ok {
+			continue
+		}
+
+		result, err := time.Parse(time.RFC3339Nano, value)
+		if err == nil {
+			return &result
+		}
+	}
+
+	return nil
+}
+
+// checkPermission - check that permission grants a role to exactly one user or group
+func checkPermission(value *models.Permission) error {
+	if (value.UserID == "") == (value.Group == "") {
+		return errorsutil.New(errorsutil.KindInvalidArgument, "exactly one of user_id and group is required")
+	}
+
+	if value.Role != models.RoleViewer && value.Role != models.RoleEditor {
+		return errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf("role must be %s or %s", models.RoleViewer, models.RoleEditor))
+	}
+
+	return nil
+}
+
+// newTodo - todo of owner stored by Create, pending unless the status is set
+func newTodo(value *models.Todo, owner string) *models.Todo {
+	status := value.Status
+	if status == "" {
+		status = models.StatusPending
+	}
+
+	return &models.Todo{
+		OwnerID:     owner,
+		Title:       value.Title,
+		Description: value.Description,
+		Status:      status,
+		CompletedAt: completedAt(status),
+		Priority:    value.Priority,
+		DueAt:       value.DueAt,
+		Tags:        normalizeTags(value.Tags),
+	}
+}
+
+// ownerOf - owner of the changed todo, the user of ctx when the todo is gone
+func ownerOf(ctx context.Context, before *models.Todo, after *models.Todo) string {
+	switch {
+	case after != nil:
+		return after.OwnerID
+	case before != nil:
+		return before.OwnerID
+	}
+
+	return pkgauth.UserID(ctx)
+}
+
+// updatedTodo - todo written by Update of current, the status can only change along the transitions
+func updatedTodo(current *models.Todo, value *models.Todo) (*models.Todo, error) {
+	todo := &models.Todo{
+		Title:       value.Title,
+		Description: value.Description,
+		Priority:    value.Priority,
+		DueAt:       value.DueAt,
+		Version:     value.Version,
+	}
+	if value.Tags != nil {
+		todo.Tags = normalizeTags(value.Tags)
+	}
+
+	if value.Status != "" {
+		if current.Status == value.Status {
+			todo.Status = current.Status
+			todo.CompletedAt = current.CompletedAt
+		} else {
+			err := checkTransition(current.Status, value.Status)
+			if err != nil {
+				return nil, err
+			}
+
+			todo.Status = value.Status
+			todo.CompletedAt = completedAt(value.Status)
+		}
+	}
+
+	return todo, nil
+}
+
+// checkTransition - check whether todo can move from status to another status
+func checkTransition(from string, to string) error {
+	for _, status := range transitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+
+	return errorsutil.New(errorsutil.KindFailedPrecondition, fmt.Sprintf("cannot change status from %s to %s", from, to))
+}
+
+// completedAt - completion time for the status, only done todo is completed
+func completedAt(status string) *time.Time {
+	if status != models.StatusDone {
+		return nil
+	}
+
+	timeNow := timeutil.GetTimeNow()
+
+	return &timeNow
+}
+
+// normalizeTags - trim and lowercase tags, drop empty and duplicated tags
+func normalizeTags(tags []string) []string {
+	results := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		results = append(results, tag)
+	}
+
+	return results
+}
+
+// encodePageToken - make page token pointing after the todo in the given sort
+func encodePageToken(todo *models.Todo, sort []queryutil.SortField) (string, error) {
+	return paginationutil.EncodeCursor(pageCursor(todo, sort))
+}
+
+// pageCursor - cursor pointing after the todo in the given sort
+func pageCursor(todo *models.Todo, sort []queryutil.SortField) *paginationutil.Cursor {
+	values := make([]interface{}, 0, len(sort))
+	for _, item := range sort {
+		values = append(values, todo.Value(item.Field.Key))
+	}
+
+	return &paginationutil.Cursor{
+		Sort:   queryutil.FormatSort(sort),
+		Values: values,
+		ID:     todo.ID,
+	}
+}
+
+// decodePageToken - decode page token, it is only valid for the sort it was made with
+func decodePageToken(token string, sort []queryutil.SortField) (*paginationutil.Cursor, error) {
+	if len(sort) == 0 {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "page token is not supported when sorted by search relevance")
+	}
+
+	cursor, err := paginationutil.DecodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor.Sort != queryutil.FormatSort(sort) || len(cursor.Values) != len(sort) {
+		return nil, errorsutil.New(errorsutil.KindInvalidArgument, "page token does not match the sort")
+	}
+
+	for i, item := range sort {
+		cursor.Values[i], err = queryutil.Coerce(item.Field, cursor.Values[i])
+		if err != nil {
+			return nil, paginationutil.ErrInvalidPageToken
+		}
+	}
+
+	return cursor, nil
+}
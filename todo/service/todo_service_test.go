@@ -1,11 +1,20 @@
 package service_test
 
 import (
+	"context"
+	pkgauth "go-clean-grpc/pkg/auth"
 	mockrepository "go-clean-grpc/todo/mocks/repository"
 	models "go-clean-grpc/todo/models/http"
+	"go-clean-grpc/todo/policy"
+	todorepository "go-clean-grpc/todo/repository"
 	todoservice "go-clean-grpc/todo/service"
+	actorutil "go-clean-grpc/utils/actor"
 	errorsutil "go-clean-grpc/utils/errors"
+	idutil "go-clean-grpc/utils/id"
+	paginationutil "go-clean-grpc/utils/pagination"
+	tenantutil "go-clean-grpc/utils/tenant"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,12 +28,12 @@ func TestTodoGetAll(t *testing.T) {
 		mockList = append(mockList, &models.Todo{})
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockList, nil)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, nil)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(mockList, nil)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(10, nil)
 
-		results, count, err := service.GetAll("keyword", 10, 0)
+		results, count, _, err := service.GetAll(context.Background(), &models.TodoFilter{Keyword: "keyword"}, 10, 0)
 
 		assert.NoError(t, err)
 		assert.Equal(t, count, 10)
@@ -33,11 +42,11 @@ func TestTodoGetAll(t *testing.T) {
 
 	t.Run("error when find all", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, errorsutil.ErrDefault)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, nil)
-		results, count, err := service.GetAll("keyword", 10, 0)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(10, nil)
+		results, count, _, err := service.GetAll(context.Background(), &models.TodoFilter{Keyword: "keyword"}, 10, 0)
 
 		assert.Nil(t, results)
 		assert.Equal(t, 0, count)
@@ -46,17 +55,65 @@ func TestTodoGetAll(t *testing.T) {
 
 	t.Run("error when count find all", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("FindAll", mock.AnythingOfType("string"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, nil)
-		mockRepository.On("CountFindAll", mock.AnythingOfType("string")).Return(10, errorsutil.ErrDefault)
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, nil)
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(10, errorsutil.ErrDefault)
 
-		results, count, err := service.GetAll("keyword", 10, 0)
+		results, count, _, err := service.GetAll(context.Background(), &models.TodoFilter{Keyword: "keyword"}, 10, 0)
 
 		assert.Nil(t, results)
 		assert.Equal(t, 0, count)
 		assert.Error(t, err)
 	})
+
+	t.Run("success when next page token", func(t *testing.T) {
+		updatedAt := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+		mockList := []*models.Todo{
+			{ID: idutil.New(), UpdatedAt: updatedAt},
+			{ID: idutil.New(), UpdatedAt: updatedAt},
+		}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter"), 2, 0).Return(mockList, nil).Once()
+		mockRepository.On("CountFindAll", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(2, nil)
+
+		results, _, nextPageToken, err := service.GetAll(context.Background(), &models.TodoFilter{}, 1, 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.NotEmpty(t, nextPageToken)
+
+		mockRepository.On("FindAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.After != nil && filter.After.ID == mockList[0].ID && filter.After.Values[0] == updatedAt
+		}), 2, 0).Return(mockList[1:], nil).Once()
+
+		results, _, nextPageToken, err = service.GetAll(context.Background(), &models.TodoFilter{PageToken: nextPageToken}, 1, 20)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockList[1:], results)
+		assert.Empty(t, nextPageToken)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when invalid page token", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		_, _, _, err := service.GetAll(context.Background(), &models.TodoFilter{PageToken: "invalid"}, 10, 0)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+
+		token, err := paginationutil.EncodeCursor(&paginationutil.Cursor{Sort: "-priority", Values: []interface{}{float64(1)}, ID: DefaultID})
+		assert.NoError(t, err)
+
+		_, _, _, err = service.GetAll(context.Background(), &models.TodoFilter{PageToken: token}, 10, 0)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+
+		_, _, _, err = service.GetAll(context.Background(), &models.TodoFilter{PageToken: token, Search: "report"}, 10, 0)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+	})
 }
 
 func TestTodoGetByID(t *testing.T) {
@@ -64,11 +121,11 @@ func TestTodoGetByID(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("FindById", mock.AnythingOfType("string")).Return(mockTodo, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(mockTodo, nil)
 
-		result, err := service.GetByID(DefaultID)
+		result, err := service.GetByID(context.Background(), DefaultID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTodo, result)
@@ -76,10 +133,10 @@ func TestTodoGetByID(t *testing.T) {
 
 	t.Run("error when find by id", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("FindById", mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
-		result, err := service.GetByID(DefaultID)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		result, err := service.GetByID(context.Background(), DefaultID)
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -91,11 +148,28 @@ func TestTodoCreate(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+
+		result, err := service.Create(context.Background(), &models.Todo{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("success when create with priority and due date", func(t *testing.T) {
+		var mockTodo = &models.Todo{}
+		dueAt := time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC)
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("Store", mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockRepository.On("Store", mock.Anything, mock.MatchedBy(func(value *models.Todo) bool {
+			return value.Status == models.StatusPending && value.Priority == models.PriorityHigh && value.DueAt.Equal(dueAt)
+		})).Return(mockTodo, nil)
 
-		result, err := service.Create(&models.Todo{})
+		result, err := service.Create(context.Background(), &models.Todo{Priority: models.PriorityHigh, DueAt: &dueAt})
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTodo, result)
@@ -103,10 +177,10 @@ func TestTodoCreate(t *testing.T) {
 
 	t.Run("error when create", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("Store", mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
-		result, err := service.Create(&models.Todo{})
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		result, err := service.Create(context.Background(), &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -118,25 +192,25 @@ func TestTodoUpdate(t *testing.T) {
 		var mockTodo = &models.Todo{}
 
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(10, nil)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(context.Background(), DefaultID, &models.Todo{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTodo, result)
 	})
 
-	t.Run("error when count find by id", func(t *testing.T) {
+	t.Run("error when find by id", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(0, errorsutil.ErrDefault)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, nil)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(context.Background(), DefaultID, &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -144,38 +218,1309 @@ func TestTodoUpdate(t *testing.T) {
 
 	t.Run("error when update", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("CountFindByID", mock.AnythingOfType("string")).Return(10, nil)
-		mockRepository.On("Update", mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Todo")).Return(nil, errorsutil.ErrDefault)
 
-		result, err := service.Update(DefaultID, &models.Todo{})
+		result, err := service.Update(context.Background(), DefaultID, &models.Todo{})
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
 	})
 }
 
+func TestTodoPatch(t *testing.T) {
+	t.Run("success when patch without status", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{}, nil)
+		mockRepository.On("Patch", mock.Anything, DefaultID, mock.MatchedBy(func(patch *models.TodoPatch) bool {
+			return assert.ObjectsAreEqual([]string{"title", "tags"}, patch.Fields) &&
+				assert.ObjectsAreEqual([]string{"home"}, patch.Todo.Tags)
+		})).Return(&models.Todo{}, nil)
+
+		_, err := service.Patch(context.Background(), DefaultID, &models.TodoPatch{
+			Fields: []string{"title", "tags"},
+			Todo:   &models.Todo{Title: "a", Tags: []string{" Home ", "home"}},
+		})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when patch status", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{Status: models.StatusPending}, nil)
+		mockRepository.On("Patch", mock.Anything, DefaultID, mock.MatchedBy(func(patch *models.TodoPatch) bool {
+			return assert.ObjectsAreEqual([]string{"status", "completed_at"}, patch.Fields) && patch.Todo.CompletedAt != nil
+		})).Return(&models.Todo{}, nil)
+
+		_, err := service.Patch(context.Background(), DefaultID, &models.TodoPatch{
+			Fields: []string{"status"},
+			Todo:   &models.Todo{Status: models.StatusDone},
+		})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when invalid status transition", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{Status: models.StatusDone}, nil)
+
+		_, err := service.Patch(context.Background(), DefaultID, &models.TodoPatch{
+			Fields: []string{"status"},
+			Todo:   &models.Todo{Status: models.StatusInProgress},
+		})
+
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+		mockRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when empty patch", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{Title: "a"}, nil)
+
+		result, err := service.Patch(context.Background(), DefaultID, &models.TodoPatch{Todo: &models.Todo{}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "a", result.Title)
+		mockRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when empty patch of another version", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{Version: 3}, nil)
+
+		_, err := service.Patch(context.Background(), DefaultID, &models.TodoPatch{Todo: &models.Todo{}, Version: 2})
+
+		assert.ErrorIs(t, err, errorsutil.ErrAborted)
+	})
+}
+
 func TestTodoDelete(t *testing.T) {
 	t.Run("success when delete", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("Delete", mock.AnythingOfType("string")).Return(nil)
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
 
-		err := service.Delete(DefaultID)
+		err := service.Delete(context.Background(), DefaultID)
 
 		assert.NoError(t, err)
 	})
 
 	t.Run("error when delete", func(t *testing.T) {
 		mockRepository := new(mockrepository.Repository)
-		service := todoservice.New(mockRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+
+		err := service.Delete(context.Background(), DefaultID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTodoGetTrash(t *testing.T) {
+	t.Run("success when get trash", func(t *testing.T) {
+		mockList := []*models.Todo{{}}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		isTrash := mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.Deleted
+		})
+		mockRepository.On("FindAll", mock.Anything, isTrash, 11, 0).Return(mockList, nil)
+		mockRepository.On("CountFindAll", mock.Anything, isTrash).Return(1, nil)
+
+		results, count, _, err := service.GetTrash(context.Background(), nil, 10, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockList, results)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestTodoRestore(t *testing.T) {
+	t.Run("success when restore", func(t *testing.T) {
+		mockTodo := &models.Todo{}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Restore", mock.Anything, DefaultID).Return(mockTodo, nil)
+
+		result, err := service.Restore(context.Background(), DefaultID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("error when restore", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Restore", mock.Anything, DefaultID).Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Restore(context.Background(), DefaultID)
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestTodoPurge(t *testing.T) {
+	t.Run("success when purge", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Purge", mock.Anything, DefaultID).Return(nil)
+
+		err := service.Purge(context.Background(), DefaultID)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("success when purge expired", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockPermissions := newMockPermissionRepository()
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), mockPermissions)
+
+		mockRepository.On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) > 23*time.Hour && time.Since(before) < 25*time.Hour
+		})).Return([]string{"1", "2", "3"}, nil)
+
+		total, err := service.PurgeExpired(context.Background(), 24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, total)
+
+		// the permissions of the purged todo are deleted
+		mockPermissions.AssertNumberOfCalls(t, "DeletePermissions", 3)
+		for _, id := range []string{"1", "2", "3"} {
+			mockPermissions.AssertCalled(t, "DeletePermissions", mock.Anything, id)
+		}
+	})
+}
+
+func TestTodoUpdateStatus(t *testing.T) {
+	t.Run("success when update with valid transition", func(t *testing.T) {
+		var mockTodo = &models.Todo{}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusPending}, nil)
+		mockRepository.On("Update", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(value *models.Todo) bool {
+			return value.Status == models.StatusInProgress && value.CompletedAt == nil
+		})).Return(mockTodo, nil)
+
+		result, err := service.Update(context.Background(), DefaultID, &models.Todo{Status: models.StatusInProgress})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("error when update with invalid transition", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusDone}, nil)
+
+		result, err := service.Update(context.Background(), DefaultID, &models.Todo{Status: models.StatusInProgress})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+		mockRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoComplete(t *testing.T) {
+	t.Run("success when complete", func(t *testing.T) {
+		var mockTodo = &models.Todo{Status: models.StatusDone}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusInProgress, Version: 3}, nil)
+		mockRepository.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusDone, mock.MatchedBy(func(completedAt *time.Time) bool {
+			return completedAt != nil
+		}), int64(3)).Return(mockTodo, nil)
+
+		result, err := service.Complete(context.Background(), DefaultID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("error when already done", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusDone}, nil)
+
+		result, err := service.Complete(context.Background(), DefaultID)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+	})
+
+	t.Run("error when the status changed since it was read", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusInProgress, Version: 3}, nil)
+		mockRepository.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusDone, mock.Anything, int64(3)).Return(nil, todorepository.ErrVersionConflict)
+
+		result, err := service.Complete(context.Background(), DefaultID)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, todorepository.ErrVersionConflict)
+	})
+
+	t.Run("error when find by id", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(nil, errorsutil.ErrNotFound)
+
+		result, err := service.Complete(context.Background(), DefaultID)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestTodoReopen(t *testing.T) {
+	t.Run("success when reopen", func(t *testing.T) {
+		var mockTodo = &models.Todo{Status: models.StatusPending}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusCancelled}, nil)
+		mockRepository.On("UpdateStatus", mock.Anything, mock.AnythingOfType("string"), models.StatusPending, (*time.Time)(nil), int64(0)).Return(mockTodo, nil)
+
+		result, err := service.Reopen(context.Background(), DefaultID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("error when still pending", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{Status: models.StatusPending}, nil)
+
+		result, err := service.Reopen(context.Background(), DefaultID)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+	})
+}
+
+func TestTodoAddTags(t *testing.T) {
+	t.Run("success when add tags", func(t *testing.T) {
+		var mockTodo = &models.Todo{Tags: []string{"work", "home"}}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("AddTags", mock.Anything, mock.AnythingOfType("string"), []string{"work", "home"}).Return(mockTodo, nil)
+
+		result, err := service.AddTags(context.Background(), DefaultID, []string{" Work", "home", "work", ""})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+
+	t.Run("error when tags empty", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		result, err := service.AddTags(context.Background(), DefaultID, []string{" "})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+	})
+
+	t.Run("error when add tags", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("AddTags", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]string")).Return(nil, errorsutil.ErrNotFound)
+
+		result, err := service.AddTags(context.Background(), DefaultID, []string{"work"})
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+	})
+}
+
+func TestTodoRemoveTags(t *testing.T) {
+	t.Run("success when remove tags", func(t *testing.T) {
+		var mockTodo = &models.Todo{Tags: []string{}}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, mock.AnythingOfType("string")).Return(&models.Todo{}, nil)
+		mockRepository.On("RemoveTags", mock.Anything, mock.AnythingOfType("string"), []string{"work"}).Return(mockTodo, nil)
+
+		result, err := service.RemoveTags(context.Background(), DefaultID, []string{"WORK"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+}
+
+func TestTodoGetTagCounts(t *testing.T) {
+	t.Run("success when count tags", func(t *testing.T) {
+		mockList := []*models.TagCount{{Tag: "work", Count: 2}}
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("CountTags", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(mockList, nil)
+
+		results, err := service.GetTagCounts(context.Background(), &models.TodoFilter{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockList, results)
+	})
+
+	t.Run("error when count tags", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
 
-		mockRepository.On("Delete", mock.AnythingOfType("string")).Return(errorsutil.ErrDefault)
+		mockRepository.On("CountTags", mock.Anything, mock.AnythingOfType("*models.TodoFilter")).Return(nil, errorsutil.ErrDefault)
 
-		err := service.Delete(DefaultID)
+		results, err := service.GetTagCounts(context.Background(), &models.TodoFilter{})
 
+		assert.Nil(t, results)
 		assert.Error(t, err)
 	})
 }
+
+func TestTodoRevisions(t *testing.T) {
+	ctx := actorutil.NewContext(context.Background(), actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP})
+
+	t.Run("success when record update revision", func(t *testing.T) {
+		after := &models.Todo{ID: DefaultID, Title: "b", Version: 2}
+
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{ID: DefaultID, Title: "a", Version: 1}, nil)
+		mockRepository.On("Update", mock.Anything, DefaultID, mock.AnythingOfType("*models.Todo")).Return(after, nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.MatchedBy(func(revision *models.Revision) bool {
+			return revision.TodoID == DefaultID && revision.Action == models.ActionUpdate && revision.Version == 2 &&
+				revision.Actor == "alice" && revision.Transport == actorutil.TransportHTTP && revision.Todo == after &&
+				assert.ObjectsAreEqual([]*models.FieldChange{{Field: "title", Before: "a", After: "b"}}, revision.Changes)
+		})).Return(&models.Revision{}, nil)
+
+		_, err := service.Update(ctx, DefaultID, &models.Todo{Title: "b"})
+
+		assert.NoError(t, err)
+		mockRevisions.AssertExpectations(t)
+	})
+
+	t.Run("success when record delete revision", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{ID: DefaultID, Version: 3}, nil)
+		mockRepository.On("Delete", mock.Anything, DefaultID).Return(nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.MatchedBy(func(revision *models.Revision) bool {
+			return revision.TodoID == DefaultID && revision.Action == models.ActionDelete && revision.Version == 4 &&
+				revision.Todo == nil && len(revision.Changes) == 1 && revision.Changes[0].Field == "deleted_at" &&
+				revision.Changes[0].Before == nil && revision.Changes[0].After != nil
+		})).Return(&models.Revision{}, nil)
+
+		err := service.Delete(ctx, DefaultID)
+
+		assert.NoError(t, err)
+		mockRevisions.AssertExpectations(t)
+	})
+
+	t.Run("success when record restore revision", func(t *testing.T) {
+		deletedAt := "2022-11-30T10:00:00Z"
+
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Restore", mock.Anything, DefaultID).Return(&models.Todo{ID: DefaultID, Version: 5}, nil)
+		mockRevisions.On("FindRevisions", mock.Anything, DefaultID, 1, 0).Return([]*models.Revision{{
+			Action:  models.ActionDelete,
+			Changes: []*models.FieldChange{{Field: "deleted_at", After: deletedAt}},
+		}}, nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.MatchedBy(func(revision *models.Revision) bool {
+			return revision.Action == models.ActionRestore && revision.Version == 5 &&
+				assert.ObjectsAreEqual([]*models.FieldChange{{Field: "deleted_at", Before: deletedAt, After: nil}}, revision.Changes)
+		})).Return(&models.Revision{}, nil)
+
+		_, err := service.Restore(ctx, DefaultID)
+
+		assert.NoError(t, err)
+		mockRevisions.AssertExpectations(t)
+	})
+
+	t.Run("success when store revision fails", func(t *testing.T) {
+		mockTodo := &models.Todo{ID: DefaultID}
+
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.AnythingOfType("*models.Revision")).Return(nil, errorsutil.ErrDefault)
+
+		result, err := service.Create(ctx, &models.Todo{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+	})
+}
+
+func TestTodoListHistory(t *testing.T) {
+	t.Run("success when list history", func(t *testing.T) {
+		mockList := []*models.Revision{{ID: "2"}, {ID: "1"}}
+
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("CountRevisions", mock.Anything, DefaultID).Return(2, nil)
+		mockRevisions.On("FindRevisions", mock.Anything, DefaultID, 10, 0).Return(mockList, nil)
+
+		results, total, err := service.ListHistory(context.Background(), DefaultID, 10, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, mockList, results)
+	})
+
+	t.Run("success when todo has no history", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("CountRevisions", mock.Anything, DefaultID).Return(0, nil)
+		mockRepository.On("CountFindByID", mock.Anything, DefaultID).Return(1, nil)
+
+		results, total, err := service.ListHistory(context.Background(), DefaultID, 10, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, results)
+	})
+
+	t.Run("error when todo not found", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("CountRevisions", mock.Anything, DefaultID).Return(0, nil)
+		mockRepository.On("CountFindByID", mock.Anything, DefaultID).Return(0, errorsutil.ErrNotFound)
+
+		_, _, err := service.ListHistory(context.Background(), DefaultID, 10, 0)
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestTodoRevert(t *testing.T) {
+	t.Run("success when revert", func(t *testing.T) {
+		snapshot := &models.Todo{ID: DefaultID, Title: "a", Status: models.StatusPending, Tags: []string{"work"}}
+		mockTodo := &models.Todo{ID: DefaultID, Title: "a", Version: 4}
+
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("FindRevision", mock.Anything, DefaultID, "r1").Return(&models.Revision{ID: "r1", Action: models.ActionCreate, Todo: snapshot}, nil)
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{ID: DefaultID, Title: "b", Version: 3}, nil)
+		mockRepository.On("Patch", mock.Anything, DefaultID, mock.MatchedBy(func(patch *models.TodoPatch) bool {
+			return patch.Todo == snapshot && patch.Version == 3 &&
+				assert.ObjectsAreEqual([]string{"title", "description", "status", "priority", "due_at", "tags", "completed_at"}, patch.Fields)
+		})).Return(mockTodo, nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.MatchedBy(func(revision *models.Revision) bool {
+			return revision.Action == models.ActionRevert && revision.Reverts == "r1" && revision.Version == 4
+		})).Return(&models.Revision{}, nil)
+
+		result, err := service.Revert(context.Background(), DefaultID, "r1", 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockTodo, result)
+		mockRevisions.AssertExpectations(t)
+	})
+
+	t.Run("error when revision has no snapshot", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("FindRevision", mock.Anything, DefaultID, "r1").Return(&models.Revision{ID: "r1", Action: models.ActionDelete}, nil)
+
+		_, err := service.Revert(context.Background(), DefaultID, "r1", 0)
+
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+		mockRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when revision not found", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRevisions.On("FindRevision", mock.Anything, DefaultID, "r1").Return(nil, errorsutil.ErrNotFound)
+
+		_, err := service.Revert(context.Background(), DefaultID, "r1", 0)
+
+		assert.ErrorIs(t, err, errorsutil.ErrNotFound)
+	})
+}
+
+func TestTodoCreateMany(t *testing.T) {
+	t.Run("success when create many", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		mockResults := []*models.BatchResult{
+			{ID: "a", Todo: &models.Todo{ID: "a"}},
+			{ID: "b", Error: errorsutil.ErrDefault},
+		}
+		mockRepository.On("StoreMany", mock.Anything, mock.MatchedBy(func(values []*models.Todo) bool {
+			return len(values) == 2 && values[0].Status == models.StatusPending && values[1].Status == models.StatusDone &&
+				values[1].CompletedAt != nil && assert.ObjectsAreEqual([]string{"work"}, values[0].Tags)
+		})).Return(mockResults, nil)
+		mockEvents.On("Publish", mock.Anything, mock.MatchedBy(func(event *models.TodoEvent) bool {
+			return event.Type == models.EventCreated && event.TodoID == "a"
+		})).Return(nil).Once()
+
+		results, err := service.CreateMany(context.Background(), []*models.Todo{
+			{Title: "a", Tags: []string{" Work ", "work"}},
+			{Title: "b", Status: models.StatusDone},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockResults, results)
+		mockEvents.AssertExpectations(t)
+	})
+
+	t.Run("error when batch too large", func(t *testing.T) {
+		t.Setenv("BATCH_MAX_SIZE", "1")
+
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		results, err := service.CreateMany(context.Background(), []*models.Todo{{}, {}})
+
+		assert.Nil(t, results)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+		mockRepository.AssertNotCalled(t, "StoreMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error when store many", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("StoreMany", mock.Anything, mock.Anything).Return(nil, errorsutil.ErrDefault)
+
+		results, err := service.CreateMany(context.Background(), []*models.Todo{{}})
+
+		assert.Nil(t, results)
+		assert.Error(t, err)
+	})
+}
+
+func TestTodoUpdateMany(t *testing.T) {
+	t.Run("success when update many", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(mockRepository, mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindByIDs", mock.Anything, []string{"a", "b", "c", "a", "d"}).Return([]*models.Todo{
+			{ID: "a", Title: "a", Status: models.StatusPending},
+			{ID: "b", Title: "b", Status: models.StatusDone},
+			{ID: "c", Title: "c", Status: models.StatusPending},
+		}, nil)
+		mockRepository.On("UpdateMany", mock.Anything, mock.MatchedBy(func(values []*models.Todo) bool {
+			return len(values) == 2 && values[0].ID == "a" && values[0].Status == models.StatusDone && values[0].CompletedAt != nil &&
+				values[1].ID == "c" && values[1].Version == 3
+		})).Return([]*models.BatchResult{
+			{ID: "a", Todo: &models.Todo{ID: "a", Title: "a2", Status: models.StatusDone, Version: 2}},
+			{ID: "c", Error: todorepository.ErrVersionConflict},
+		}, nil)
+		mockRevisions.On("StoreRevision", mock.Anything, mock.MatchedBy(func(revision *models.Revision) bool {
+			return revision.TodoID == "a" && revision.Action == models.ActionUpdate && revision.Version == 2 && len(revision.Changes) > 0
+		})).Return(&models.Revision{}, nil).Once()
+
+		results, err := service.UpdateMany(context.Background(), []*models.Todo{
+			{ID: "a", Title: "a2", Status: models.StatusDone},
+			{ID: "b", Title: "b2", Status: models.StatusInProgress},
+			{ID: "c", Title: "c2", Version: 3},
+			{ID: "a", Title: "a3"},
+			{ID: "d", Title: "d"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 5)
+		assert.NoError(t, results[0].Error)
+		assert.Equal(t, "a2", results[0].Todo.Title)
+		assert.ErrorIs(t, results[1].Error, errorsutil.ErrFailedPrecondition)
+		assert.ErrorIs(t, results[2].Error, errorsutil.ErrAborted)
+		assert.ErrorIs(t, results[3].Error, errorsutil.ErrInvalidArgument)
+		assert.Equal(t, "d", results[4].ID)
+		assert.ErrorIs(t, results[4].Error, errorsutil.ErrNotFound)
+		mockRevisions.AssertExpectations(t)
+	})
+
+	t.Run("error when find by ids", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindByIDs", mock.Anything, mock.Anything).Return(nil, errorsutil.ErrDefault)
+
+		results, err := service.UpdateMany(context.Background(), []*models.Todo{{ID: "a"}})
+
+		assert.Nil(t, results)
+		assert.Error(t, err)
+		mockRepository.AssertNotCalled(t, "UpdateMany", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoDeleteMany(t *testing.T) {
+	t.Run("success when delete many", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		mockRepository.On("FindByIDs", mock.Anything, []string{"a", "b", "c"}).Return([]*models.Todo{
+			{ID: "a", Version: 1},
+			{ID: "c", Version: 4},
+		}, nil)
+		mockRepository.On("DeleteMany", mock.Anything, []string{"a", "c"}).Return([]*models.BatchResult{
+			{ID: "a"},
+			{ID: "c", Error: errorsutil.ErrNotFound},
+		}, nil)
+		mockEvents.On("Publish", mock.Anything, mock.MatchedBy(func(event *models.TodoEvent) bool {
+			return event.Type == models.EventDeleted && event.TodoID == "a"
+		})).Return(nil).Once()
+
+		results, err := service.DeleteMany(context.Background(), []string{"a", "b", "c"})
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.NoError(t, results[0].Error)
+		assert.ErrorIs(t, results[1].Error, errorsutil.ErrNotFound)
+		assert.ErrorIs(t, results[2].Error, errorsutil.ErrNotFound)
+		mockEvents.AssertExpectations(t)
+	})
+
+	t.Run("error when delete many", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindByIDs", mock.Anything, mock.Anything).Return([]*models.Todo{{ID: "a"}}, nil)
+		mockRepository.On("DeleteMany", mock.Anything, mock.Anything).Return(nil, errorsutil.ErrDefault)
+
+		results, err := service.DeleteMany(context.Background(), []string{"a"})
+
+		assert.Nil(t, results)
+		assert.Error(t, err)
+	})
+}
+
+func TestTodoExport(t *testing.T) {
+	t.Run("success when export pages", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		firstPage := make([]*models.Todo, 0, 500)
+		for i := 0; i < 500; i++ {
+			firstPage = append(firstPage, &models.Todo{ID: idutil.New(), UpdatedAt: time.Now()})
+		}
+		last := &models.Todo{ID: "last"}
+
+		mockRepository.On("FindAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.After == nil
+		}), 500, 0).Return(firstPage, nil).Once()
+		mockRepository.On("FindAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.After != nil && filter.After.ID == firstPage[499].ID
+		}), 500, 0).Return([]*models.Todo{last}, nil).Once()
+
+		results := []*models.Todo{}
+		err := service.Export(context.Background(), &models.TodoFilter{PageToken: "ignored"}, func(todo *models.Todo) error {
+			results = append(results, todo)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 501)
+		assert.Equal(t, last, results[500])
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when export search at once", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindAll", mock.Anything, mock.Anything, 0, 0).Return([]*models.Todo{{ID: "a"}}, nil).Once()
+
+		err := service.Export(context.Background(), &models.TodoFilter{Search: "report"}, func(todo *models.Todo) error {
+			return nil
+		})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("error when send", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindAll", mock.Anything, mock.Anything, 500, 0).Return([]*models.Todo{{ID: "a"}, {ID: "b"}}, nil)
+
+		err := service.Export(context.Background(), nil, func(todo *models.Todo) error {
+			return errorsutil.ErrDefault
+		})
+
+		assert.ErrorIs(t, err, errorsutil.ErrDefault)
+	})
+}
+
+func TestTodoWatch(t *testing.T) {
+	t.Run("success when send matching events", func(t *testing.T) {
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(new(mockrepository.Repository), newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		events := []*models.TodoEvent{
+			{Type: models.EventCreated, TodoID: "a", Todo: &models.Todo{ID: "a", Status: models.StatusDone}},
+			{Type: models.EventUpdated, TodoID: "b", Todo: &models.Todo{ID: "b", Status: models.StatusPending}},
+			{Type: models.EventDeleted, TodoID: "c"},
+		}
+		mockEvents.On("Watch", mock.Anything, "token", mock.Anything).Return(func(ctx context.Context, resumeToken string, send func(*models.TodoEvent) error) error {
+			for _, event := range events {
+				err := send(event)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		results := []string{}
+		err := service.Watch(context.Background(), &models.TodoFilter{Status: models.StatusDone}, "token", func(event *models.TodoEvent) error {
+			results = append(results, event.TodoID)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "c"}, results)
+	})
+
+	t.Run("error when resume token expired", func(t *testing.T) {
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(new(mockrepository.Repository), newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		mockEvents.On("Watch", mock.Anything, "expired", mock.Anything).Return(todorepository.ErrResumeTokenExpired)
+
+		err := service.Watch(context.Background(), nil, "expired", func(event *models.TodoEvent) error {
+			return nil
+		})
+
+		assert.ErrorIs(t, err, errorsutil.ErrFailedPrecondition)
+	})
+
+	t.Run("success when publish event of change", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		mockTodo := &models.Todo{ID: DefaultID}
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(mockTodo, nil)
+		mockEvents.On("Publish", mock.Anything, mock.MatchedBy(func(event *models.TodoEvent) bool {
+			return event.Type == models.EventCreated && event.TodoID == DefaultID && event.Todo == mockTodo
+		})).Return(nil)
+
+		_, err := service.Create(context.Background(), &models.Todo{})
+
+		assert.NoError(t, err)
+		mockEvents.AssertExpectations(t)
+	})
+}
+
+func TestTodoOwner(t *testing.T) {
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+
+	t.Run("success when list todo of the user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		ofAlice := mock.MatchedBy(func(filter *models.TodoFilter) bool { return filter.OwnerID == "alice" })
+		mockRepository.On("FindAll", mock.Anything, ofAlice, 11, 0).Return([]*models.Todo{}, nil)
+		mockRepository.On("CountFindAll", mock.Anything, ofAlice).Return(0, nil)
+		mockRepository.On("CountTags", mock.Anything, ofAlice).Return([]*models.TagCount{}, nil)
+
+		// the owner of the filter can not be chosen by the caller
+		_, _, _, err := service.GetAll(ctx, &models.TodoFilter{OwnerID: "bob"}, 10, 0)
+		assert.NoError(t, err)
+
+		_, err = service.GetTagCounts(ctx, nil)
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when create todo of the user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Store", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.OwnerID == "alice"
+		})).Return(&models.Todo{ID: DefaultID, OwnerID: "alice"}, nil)
+
+		result, err := service.Create(ctx, &models.Todo{OwnerID: "bob", Title: "title"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", result.OwnerID)
+	})
+
+	t.Run("error not found when todo of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, DefaultID).Return(&models.Todo{ID: DefaultID, OwnerID: "bob"}, nil)
+
+		_, err := service.GetByID(ctx, DefaultID)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+
+		_, err = service.Update(ctx, DefaultID, &models.Todo{Title: "title"})
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+
+		err = service.Delete(ctx, DefaultID)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+		mockRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+		// every todo is found without authentication
+		_, err = service.GetByID(context.Background(), DefaultID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error not found when trash of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("CountFindAll", mock.Anything, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.Deleted && filter.OwnerID == "alice" && len(filter.IDs) == 1 && filter.IDs[0] == DefaultID
+		})).Return(0, nil)
+
+		_, err := service.Restore(ctx, DefaultID)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+
+		err = service.Purge(ctx, DefaultID)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+		mockRepository.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error not found when history of another user", func(t *testing.T) {
+		mockRevisions := new(mockrepository.RevisionRepository)
+		service := todoservice.New(new(mockrepository.Repository), mockRevisions, newMockEventRepository(), newMockPermissionRepository())
+
+		revision := &models.Revision{ID: idutil.New(), TodoID: DefaultID, OwnerID: "bob", Todo: &models.Todo{ID: DefaultID}}
+		mockRevisions.On("CountRevisions", mock.Anything, DefaultID).Return(1, nil)
+		mockRevisions.On("FindRevisions", mock.Anything, DefaultID, 1, 0).Return([]*models.Revision{revision}, nil)
+		mockRevisions.On("FindRevision", mock.Anything, DefaultID, revision.ID).Return(revision, nil)
+
+		_, _, err := service.ListHistory(ctx, DefaultID, 10, 0)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+
+		_, err = service.Revert(ctx, DefaultID, revision.ID, 0)
+		assert.Equal(t, errorsutil.ErrNotFound, err)
+	})
+
+	t.Run("error not found when batch item of another user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mine := &models.Todo{ID: idutil.New(), OwnerID: "alice"}
+		other := &models.Todo{ID: idutil.New(), OwnerID: "bob"}
+		mockRepository.On("FindByIDs", mock.Anything, []string{mine.ID, other.ID}).Return([]*models.Todo{mine, other}, nil)
+		mockRepository.On("DeleteMany", mock.Anything, []string{mine.ID}).Return([]*models.BatchResult{{ID: mine.ID}}, nil)
+
+		results, err := service.DeleteMany(ctx, []string{mine.ID, other.ID})
+
+		assert.NoError(t, err)
+		assert.NoError(t, results[0].Error)
+		assert.Equal(t, errorsutil.ErrNotFound, results[1].Error)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("success when watch events of the user", func(t *testing.T) {
+		mockEvents := new(mockrepository.EventRepository)
+		service := todoservice.New(new(mockrepository.Repository), newMockRevisionRepository(), mockEvents, newMockPermissionRepository())
+
+		events := []*models.TodoEvent{
+			{Type: models.EventCreated, TodoID: "a", Todo: &models.Todo{ID: "a", OwnerID: "alice"}, OwnerID: "alice"},
+			{Type: models.EventCreated, TodoID: "b", Todo: &models.Todo{ID: "b", OwnerID: "bob"}, OwnerID: "bob"},
+			{Type: models.EventDeleted, TodoID: "c", OwnerID: "bob"},
+			{Type: models.EventDeleted, TodoID: "d", OwnerID: "alice"},
+		}
+		mockEvents.On("Watch", mock.Anything, "", mock.Anything).Return(func(ctx context.Context, resumeToken string, send func(*models.TodoEvent) error) error {
+			for _, event := range events {
+				err := send(event)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		results := []string{}
+		err := service.Watch(ctx, nil, "", func(event *models.TodoEvent) error {
+			results = append(results, event.TodoID)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "d"}, results)
+	})
+}
+
+func TestTodoSharing(t *testing.T) {
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice", Groups: []string{"team"}})
+	todo := &models.Todo{ID: idutil.New(), OwnerID: "bob", Status: models.StatusPending}
+
+	newMockPermissions := func(role string) *mockrepository.PermissionRepository {
+		mockPermissions := new(mockrepository.PermissionRepository)
+		mockPermissions.On("FindGranted", mock.Anything, "alice", []string{"team"}).Return([]*models.Permission{
+			{TodoID: todo.ID, Group: "team", Role: models.RoleViewer},
+			{TodoID: todo.ID, UserID: "alice", Role: role},
+		}, nil)
+
+		return mockPermissions
+	}
+
+	t.Run("success when read todo shared with a viewer", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissions(models.RoleViewer))
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+
+		result, err := service.GetByID(ctx, todo.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, todo, result)
+
+		_, err = service.Update(ctx, todo.ID, &models.Todo{Title: "title"})
+		assert.Equal(t, policy.ErrPermissionDenied, err)
+
+		_, err = service.Complete(ctx, todo.ID)
+		assert.Equal(t, policy.ErrPermissionDenied, err)
+
+		err = service.Delete(ctx, todo.ID)
+		assert.Equal(t, policy.ErrPermissionDenied, err)
+		mockRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when update todo shared with an editor", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissions(models.RoleEditor))
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+		mockRepository.On("Update", mock.Anything, todo.ID, mock.AnythingOfType("*models.Todo")).Return(todo, nil)
+
+		_, err := service.Update(ctx, todo.ID, &models.Todo{Title: "title"})
+		assert.NoError(t, err)
+
+		// only the owner can delete or share the todo
+		err = service.Delete(ctx, todo.ID)
+		assert.Equal(t, policy.ErrPermissionDenied, err)
+
+		_, err = service.Grant(ctx, todo.ID, &models.Permission{UserID: "carol", Role: models.RoleViewer})
+		assert.Equal(t, policy.ErrPermissionDenied, err)
+	})
+
+	t.Run("success when list todo shared with the user", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissions(models.RoleViewer))
+
+		shared := mock.MatchedBy(func(filter *models.TodoFilter) bool {
+			return filter.OwnerID == "alice" && len(filter.SharedIDs) == 1 && filter.SharedIDs[0] == todo.ID
+		})
+		mockRepository.On("FindAll", mock.Anything, shared, 11, 0).Return([]*models.Todo{todo}, nil)
+		mockRepository.On("CountFindAll", mock.Anything, shared).Return(1, nil)
+
+		// the shared todo can not be chosen by the caller
+		results, total, _, err := service.GetAll(ctx, &models.TodoFilter{SharedIDs: []string{idutil.New()}}, 10, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []*models.Todo{todo}, results)
+	})
+
+	t.Run("error permission denied when batch item shared with a viewer", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissions(models.RoleViewer))
+
+		other := &models.Todo{ID: idutil.New(), OwnerID: "bob"}
+		mockRepository.On("FindByIDs", mock.Anything, []string{todo.ID, other.ID}).Return([]*models.Todo{todo, other}, nil)
+		mockRepository.On("UpdateMany", mock.Anything, []*models.Todo{}).Return([]*models.BatchResult{}, nil)
+
+		results, err := service.UpdateMany(ctx, []*models.Todo{{ID: todo.ID, Title: "a"}, {ID: other.ID, Title: "b"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, policy.ErrPermissionDenied, results[0].Error)
+		assert.Equal(t, errorsutil.ErrNotFound, results[1].Error)
+	})
+
+	t.Run("success when admin", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockPermissions := new(mockrepository.PermissionRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), mockPermissions)
+		admin := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "carol", Roles: []string{policy.RoleAdmin}})
+
+		all := mock.MatchedBy(func(filter *models.TodoFilter) bool { return filter.OwnerID == "" && filter.SharedIDs == nil })
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+		mockRepository.On("Delete", mock.Anything, todo.ID).Return(nil)
+		mockRepository.On("FindAll", mock.Anything, all, 11, 0).Return([]*models.Todo{todo}, nil)
+		mockRepository.On("CountFindAll", mock.Anything, all).Return(1, nil)
+
+		_, err := service.GetByID(admin, todo.ID)
+		assert.NoError(t, err)
+
+		err = service.Delete(admin, todo.ID)
+		assert.NoError(t, err)
+
+		_, _, _, err = service.GetAll(admin, &models.TodoFilter{OwnerID: "bob"}, 10, 0)
+		assert.NoError(t, err)
+		mockPermissions.AssertNotCalled(t, "FindGranted", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoGrant(t *testing.T) {
+	ctx := actorutil.NewContext(pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"}), actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP})
+	todo := &models.Todo{ID: idutil.New(), OwnerID: "alice"}
+
+	t.Run("success when grant", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockPermissions := new(mockrepository.PermissionRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), mockPermissions)
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+		mockPermissions.On("StorePermission", mock.Anything, &models.Permission{TodoID: todo.ID, UserID: "bob", Role: models.RoleEditor, GrantedBy: "alice"}).
+			Return(&models.Permission{ID: "1", TodoID: todo.ID, UserID: "bob", Role: models.RoleEditor, GrantedBy: "alice"}, nil)
+
+		result, err := service.Grant(ctx, todo.ID, &models.Permission{UserID: "bob", Role: models.RoleEditor})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1", result.ID)
+		mockPermissions.AssertExpectations(t)
+	})
+
+	t.Run("error when invalid permission", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+
+		for _, value := range []*models.Permission{
+			{UserID: "bob", Group: "team", Role: models.RoleViewer},
+			{Role: models.RoleViewer},
+			{UserID: "bob", Role: policy.RoleAdmin},
+			{UserID: "alice", Role: models.RoleViewer},
+		} {
+			_, err := service.Grant(ctx, todo.ID, value)
+			assert.Equal(t, errorsutil.KindInvalidArgument, errorsutil.KindOf(err))
+		}
+	})
+
+	t.Run("success when revoke and list permissions", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockPermissions := new(mockrepository.PermissionRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), mockPermissions)
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+		mockPermissions.On("DeletePermission", mock.Anything, todo.ID, "1").Return(nil)
+		mockPermissions.On("FindPermissions", mock.Anything, todo.ID).Return([]*models.Permission{}, nil)
+
+		err := service.Revoke(ctx, todo.ID, "1")
+		assert.NoError(t, err)
+
+		results, err := service.ListPermissions(ctx, todo.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+		mockPermissions.AssertExpectations(t)
+	})
+
+	t.Run("success when purge revokes permissions", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		mockPermissions := new(mockrepository.PermissionRepository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), mockPermissions)
+
+		mockRepository.On("Purge", mock.Anything, todo.ID).Return(nil)
+		mockPermissions.On("DeletePermissions", mock.Anything, todo.ID).Return(nil)
+
+		err := service.Purge(context.Background(), todo.ID)
+
+		assert.NoError(t, err)
+		mockPermissions.AssertExpectations(t)
+	})
+}
+
+func TestTodoKeyScopes(t *testing.T) {
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "robot", KeyID: "k1", Scopes: []string{policy.ScopeRead}})
+	todo := &models.Todo{ID: idutil.New(), OwnerID: "robot"}
+
+	t.Run("success when read with todo:read scope", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+
+		result, err := service.GetByID(ctx, todo.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, todo.ID, result.ID)
+	})
+
+	t.Run("error permission denied when write without todo:write scope", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("FindById", mock.Anything, todo.ID).Return(todo, nil)
+
+		_, err := service.Create(ctx, &models.Todo{Title: "title"})
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+		_, err = service.Complete(ctx, todo.ID)
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+		_, err = service.DeleteMany(ctx, []string{todo.ID})
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+		err = service.Purge(ctx, todo.ID)
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+
+		mockRepository.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "FindByIDs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error permission denied when list without todo:read scope", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+		ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "robot", KeyID: "k1", Scopes: []string{policy.ScopeWrite}})
+
+		_, _, _, err := service.GetAll(ctx, &models.TodoFilter{}, 10, 0)
+
+		assert.Equal(t, errorsutil.KindPermissionDenied, errorsutil.KindOf(err))
+		mockRepository.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoQuota(t *testing.T) {
+	ctx := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "acme", MaxTodos: 3})
+
+	t.Run("success when create below the quota", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("CountFindAll", mock.Anything, &models.TodoFilter{}).Return(2, nil)
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+
+		_, err := service.Create(ctx, &models.Todo{Title: "title"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("error resource exhausted when the quota is reached", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("CountFindAll", mock.Anything, &models.TodoFilter{}).Return(3, nil)
+
+		_, err := service.Create(ctx, &models.Todo{Title: "title"})
+		assert.Equal(t, errorsutil.KindResourceExhausted, errorsutil.KindOf(err))
+		assert.Equal(t, "tenant has 3 todo, at most 3 are allowed", errorsutil.Message(err))
+
+		_, err = service.Restore(ctx, DefaultID)
+		assert.Equal(t, errorsutil.KindResourceExhausted, errorsutil.KindOf(err))
+
+		mockRepository.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		mockRepository.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error resource exhausted when the batch exceeds the quota", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("CountFindAll", mock.Anything, &models.TodoFilter{}).Return(1, nil)
+
+		_, err := service.CreateMany(ctx, []*models.Todo{{Title: "a"}, {Title: "b"}, {Title: "c"}})
+
+		assert.Equal(t, errorsutil.KindResourceExhausted, errorsutil.KindOf(err))
+		mockRepository.AssertNotCalled(t, "StoreMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success when unlimited", func(t *testing.T) {
+		mockRepository := new(mockrepository.Repository)
+		service := todoservice.New(mockRepository, newMockRevisionRepository(), newMockEventRepository(), newMockPermissionRepository())
+
+		mockRepository.On("Store", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{}, nil)
+
+		_, err := service.Create(tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "acme"}), &models.Todo{Title: "title"})
+
+		assert.NoError(t, err)
+		mockRepository.AssertNotCalled(t, "CountFindAll", mock.Anything, mock.Anything)
+	})
+}
+
+// newMockRevisionRepository - revision repository accepting any revision, without history
+func newMockRevisionRepository() *mockrepository.RevisionRepository {
+	mockRevisions := new(mockrepository.RevisionRepository)
+	mockRevisions.On("StoreRevision", mock.Anything, mock.AnythingOfType("*models.Revision")).Return(&models.Revision{}, nil).Maybe()
+	mockRevisions.On("FindRevisions", mock.Anything, mock.AnythingOfType("string"), 1, 0).Return([]*models.Revision{}, nil).Maybe()
+
+	return mockRevisions
+}
+
+// newMockPermissionRepository - permission repository without any permission
+func newMockPermissionRepository() *mockrepository.PermissionRepository {
+	mockPermissions := new(mockrepository.PermissionRepository)
+	mockPermissions.On("FindGranted", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return([]*models.Permission{}, nil).Maybe()
+	mockPermissions.On("DeletePermissions", mock.Anything, mock.AnythingOfType("string")).Return(nil).Maybe()
+
+	return mockPermissions
+}
+
+// newMockEventRepository - event repository accepting any event
+func newMockEventRepository() *mockrepository.EventRepository {
+	mockEvents := new(mockrepository.EventRepository)
+	mockEvents.On("Publish", mock.Anything, mock.AnythingOfType("*models.TodoEvent")).Return(nil).Maybe()
+
+	return mockEvents
+}
@@ -0,0 +1,103 @@
+package actorutil
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+)
+
+// Transports a change can be made through
+const (
+	TransportHTTP   = "http"
+	TransportGRPC   = "grpc"
+	TransportSystem = "system"
+)
+
+// Anonymous - actor ID when the caller did not identify itself
+const Anonymous = "anonymous"
+
+// Header - HTTP header and gRPC metadata key naming the actor
+const Header = "X-Actor"
+
+// Actor - who made a change and through which transport
+type Actor struct {
+	ID        string
+	Transport string
+}
+
+type contextKey struct{}
+
+// NewContext - context carrying the actor
+func NewContext(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext - actor of context, anonymous and system when none was set
+func FromContext(ctx context.Context) Actor {
+	actor, ok := ctx.Value(contextKey{}).(Actor)
+	if !ok {
+		return Actor{ID: Anonymous, Transport: TransportSystem}
+	}
+
+	return actor
+}
+
+// Middleware - set the actor of HTTP requests to the authenticated user, from the X-Actor header without authentication
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), Actor{ID: actorID(r.Context(), r.Header.Get(Header)), Transport: TransportHTTP})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor - set the actor of gRPC calls to the authenticated user, from the x-actor metadata without authentication
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(callContext(ctx), req)
+}
+
+// StreamServerInterceptor - set the actor of gRPC streams like UnaryServerInterceptor
+func StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: stream, ctx: callContext(stream.Context())})
+}
+
+// callContext - context of a gRPC call carrying its actor
+func callContext(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(Header); len(values) > 0 {
+			id = values[0]
+		}
+	}
+
+	return NewContext(ctx, Actor{ID: actorID(ctx, id), Transport: TransportGRPC})
+}
+
+// serverStream - server stream with the context carrying the actor
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// actorID - the authenticated user can not act as someone else, value names the actor otherwise
+func actorID(ctx context.Context, value string) string {
+	if userID := pkgauth.UserID(ctx); userID != "" {
+		return userID
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Anonymous
+	}
+
+	return value
+}
@@ -0,0 +1,78 @@
+package actorutil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	actorutil "go-clean-grpc/utils/actor"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, actorutil.Actor{ID: actorutil.Anonymous, Transport: actorutil.TransportSystem}, actorutil.FromContext(context.Background()))
+
+	ctx := actorutil.NewContext(context.Background(), actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP})
+	assert.Equal(t, actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP}, actorutil.FromContext(ctx))
+}
+
+func TestMiddleware(t *testing.T) {
+	var actor actorutil.Actor
+	handler := actorutil.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = actorutil.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(actorutil.Header, " alice ")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP}, actor)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todo", nil))
+	assert.Equal(t, actorutil.Actor{ID: actorutil.Anonymous, Transport: actorutil.TransportHTTP}, actor)
+
+	// the authenticated user can not act as someone else
+	req = httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(actorutil.Header, "bob")
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(pkgauth.NewContext(req.Context(), &pkgauth.Claims{Subject: "alice"})))
+	assert.Equal(t, actorutil.Actor{ID: "alice", Transport: actorutil.TransportHTTP}, actor)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-actor", "bob"))
+
+	result, err := actorutil.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return actorutil.FromContext(ctx), nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, actorutil.Actor{ID: "bob", Transport: actorutil.TransportGRPC}, result)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-actor", "bob"))
+	ctx = pkgauth.NewContext(ctx, &pkgauth.Claims{Subject: "alice"})
+
+	var actor actorutil.Actor
+	err := actorutil.StreamServerInterceptor(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		actor = actorutil.FromContext(stream.Context())
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, actorutil.Actor{ID: "alice", Transport: actorutil.TransportGRPC}, actor)
+}
+
+// serverStream - server stream of a context, without messages
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
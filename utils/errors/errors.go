@@ -1,6 +1,108 @@
 package errorsutil
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
+
+// Kind - category of a domain error, each transport translates it to its own status code
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalidArgument
+	KindNotFound
+	KindConflict
+	KindFailedPrecondition
+	KindUnauthenticated
+	KindPermissionDenied
+	KindResourceExhausted
+	KindUnavailable
+	KindDeadlineExceeded
+	KindCanceled
+	KindAborted // concurrent modification, e.g. version mismatch
+)
+
+// Error - typed domain error
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
 
 var ErrDefault error = errors.New("error")
-var ErrNotFound error = errors.New("not found")
+var ErrNotFound error = New(KindNotFound, "not found")
+var ErrInvalidArgument error = New(KindInvalidArgument, "invalid argument")
+var ErrConflict error = New(KindConflict, "conflict")
+var ErrFailedPrecondition error = New(KindFailedPrecondition, "failed precondition")
+var ErrUnauthenticated error = New(KindUnauthenticated, "unauthenticated")
+var ErrPermissionDenied error = New(KindPermissionDenied, "permission denied")
+var ErrResourceExhausted error = New(KindResourceExhausted, "resource exhausted")
+var ErrUnavailable error = New(KindUnavailable, "unavailable")
+var ErrAborted error = New(KindAborted, "aborted")
+
+// New - make domain error of the given kind
+func New(kind Kind, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+// Wrap - make domain error of the given kind wrapping the cause
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is - domain errors are matched by kind, so errors.Is(err, ErrNotFound) holds for every not found error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	return e.Kind == t.Kind
+}
+
+// KindOf - get kind of error, errors outside the catalog are internal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	}
+
+	return KindInternal
+}
+
+// Message - get client facing message of error, without the wrapped cause
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,45 @@
+package errorsutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	errorsutil "go-clean-grpc/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("find todo: %w", errorsutil.New(errorsutil.KindNotFound, "todo not found"))
+	assert.True(t, errors.Is(err, errorsutil.ErrNotFound))
+	assert.False(t, errors.Is(err, errorsutil.ErrConflict))
+
+	cause := errors.New("connection reset")
+	err = errorsutil.Wrap(errorsutil.KindUnavailable, "database unavailable", cause)
+	assert.True(t, errors.Is(err, errorsutil.ErrUnavailable))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, "database unavailable: connection reset", err.Error())
+}
+
+func TestErrorAs(t *testing.T) {
+	err := fmt.Errorf("update todo: %w", errorsutil.New(errorsutil.KindFailedPrecondition, "invalid transition"))
+
+	var target *errorsutil.Error
+	assert.True(t, errors.As(err, &target))
+	assert.Equal(t, errorsutil.KindFailedPrecondition, target.Kind)
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, errorsutil.KindNotFound, errorsutil.KindOf(errorsutil.ErrNotFound))
+	assert.Equal(t, errorsutil.KindInternal, errorsutil.KindOf(errorsutil.ErrDefault))
+	assert.Equal(t, errorsutil.KindDeadlineExceeded, errorsutil.KindOf(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.Equal(t, errorsutil.KindCanceled, errorsutil.KindOf(context.Canceled))
+}
+
+func TestMessage(t *testing.T) {
+	err := errorsutil.Wrap(errorsutil.KindConflict, "duplicate key", errors.New("E11000"))
+	assert.Equal(t, "duplicate key", errorsutil.Message(err))
+	assert.Equal(t, "error", errorsutil.Message(errorsutil.ErrDefault))
+}
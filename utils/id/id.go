@@ -0,0 +1,52 @@
+package idutil
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+)
+
+var processUnique = newProcessUnique()
+var counter = newCounter()
+
+// New - make unique 24 hex characters id in the ObjectID layout, ids made later sort after earlier ones
+func New() string {
+	var b [12]byte
+
+	binary.BigEndian.PutUint32(b[0:4], uint32(time.Now().Unix()))
+	copy(b[4:9], processUnique[:])
+
+	next := atomic.AddUint32(&counter, 1)
+	b[9] = byte(next >> 16)
+	b[10] = byte(next >> 8)
+	b[11] = byte(next)
+
+	return hex.EncodeToString(b[:])
+}
+
+// IsValid - check whether value has the id layout
+func IsValid(value string) bool {
+	if len(value) != 24 {
+		return false
+	}
+
+	_, err := hex.DecodeString(value)
+
+	return err == nil
+}
+
+func newProcessUnique() [5]byte {
+	var b [5]byte
+	rand.Read(b[:])
+
+	return b
+}
+
+func newCounter() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+
+	return binary.BigEndian.Uint32(b[:]) & 0x00ffffff
+}
@@ -0,0 +1,19 @@
+package idutil_test
+
+import (
+	"testing"
+
+	idutil "go-clean-grpc/utils/id"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	first := idutil.New()
+	second := idutil.New()
+
+	assert.Len(t, first, 24)
+	assert.NotEqual(t, first, second)
+	assert.True(t, idutil.IsValid(first))
+	assert.False(t, idutil.IsValid("invalid"))
+}
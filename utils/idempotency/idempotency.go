@@ -0,0 +1,424 @@
+package idempotencyutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"go-clean-grpc/pkg/config"
+	"go-clean-grpc/pkg/logger"
+	actorutil "go-clean-grpc/utils/actor"
+	errorsutil "go-clean-grpc/utils/errors"
+	responseutil "go-clean-grpc/utils/response"
+	tenantutil "go-clean-grpc/utils/tenant"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// Header - HTTP header and gRPC metadata key of the idempotency key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader - HTTP header and gRPC metadata key set on replayed responses
+const ReplayedHeader = "Idempotent-Replayed"
+
+const (
+	maxKeyLength = 255
+	maxBodySize  = 1 << 20
+)
+
+// Record - idempotency key with the fingerprint of its request and the response once it is done
+type Record struct {
+	Key         string
+	Fingerprint string
+	Response    *Response // nil while the request is in progress
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Response - response sent for an idempotency key, the status is the HTTP status or gRPC code
+type Response struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
+
+// Store - store of idempotency keys
+type Store interface {
+	// Reserve - store record unless the key has a record that did not expire, which is returned instead
+	Reserve(ctx context.Context, record *Record) (*Record, error)
+	// Complete - set the response and expiry of the record of the key
+	Complete(ctx context.Context, key string, response *Response, expiresAt time.Time) error
+	// Release - delete the record of the key, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+var (
+	errKeyTooLong = errorsutil.New(errorsutil.KindInvalidArgument, "Idempotency-Key is longer than 255 characters")
+	errBodyTooBig = errorsutil.New(errorsutil.KindInvalidArgument, "request body is larger than 1 MiB, it cannot be sent with an Idempotency-Key")
+	errMismatch   = errorsutil.New(errorsutil.KindInvalidArgument, "Idempotency-Key was already used with another request")
+	errInProgress = errorsutil.New(errorsutil.KindConflict, "a request with the same Idempotency-Key is in progress")
+)
+
+// Middleware - replay the response of POST, PUT, PATCH and DELETE requests sent again with the same Idempotency-Key
+// responses are kept for IDEMPOTENCY_TTL (default 24h), server errors are not kept so the request can be retried
+// responses with Cache-Control: no-store, e.g. a minted API key, are never kept, the request runs again when it is retried
+func Middleware(store Store) func(http.Handler) http.Handler {
+	ttl, lockTimeout := durations()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(Header))
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxKeyLength {
+				responseutil.ResponseError(w, r, errKeyTooLong)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+			if err != nil {
+				responseutil.ResponseError(w, r, errorsutil.Wrap(errorsutil.KindInvalidArgument, "request body could not be read", err))
+				return
+			}
+			if len(body) > maxBodySize {
+				responseutil.ResponseError(w, r, errBodyTooBig)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = scopedKey(r.Context(), key)
+			record, err := reserve(r.Context(), store, &Record{
+				Key:         key,
+				Fingerprint: fingerprint(r.Method, r.URL.RequestURI(), body),
+			}, lockTimeout)
+			if err != nil {
+				responseutil.ResponseError(w, r, err)
+				return
+			}
+			if record != nil {
+				replay(w, record.Response)
+				return
+			}
+
+			completed := false
+			defer func() {
+				// the handler panicked, the request did not complete
+				if !completed {
+					release(r.Context(), store, key)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			completed = true
+
+			response := recorder.done()
+			if response.Status >= http.StatusInternalServerError || response.Status == http.StatusTooManyRequests || isNoStore(response.Header) {
+				release(r.Context(), store, key)
+				return
+			}
+
+			complete(r.Context(), store, key, response, ttl)
+		})
+	}
+}
+
+// UnaryServerInterceptor - replay the response of gRPC calls sent again with the same idempotency-key metadata
+// responses are kept for IDEMPOTENCY_TTL (default 24h), errors the same call can succeed on are not kept
+func UnaryServerInterceptor(store Store) grpc.UnaryServerInterceptor {
+	ttl, lockTimeout := durations()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(Header); len(values) > 0 {
+				key = strings.TrimSpace(values[0])
+			}
+		}
+
+		message, ok := req.(proto.Message)
+		if key == "" || !ok {
+			return handler(ctx, req)
+		}
+
+		if len(key) > maxKeyLength {
+			return nil, status.Error(codes.InvalidArgument, errorsutil.Message(errKeyTooLong))
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		key = scopedKey(ctx, key)
+		record, err := reserve(ctx, store, &Record{
+			Key:         key,
+			Fingerprint: fingerprint(info.FullMethod, "", body),
+		}, lockTimeout)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		if record != nil {
+			grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true"))
+
+			return replayCall(record.Response)
+		}
+
+		completed := false
+		defer func() {
+			// the handler panicked, the call did not complete
+			if !completed {
+				release(ctx, store, key)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		completed = true
+
+		response, keep := callResponse(resp, err)
+		if !keep {
+			release(ctx, store, key)
+			return resp, err
+		}
+
+		complete(ctx, store, key, response, ttl)
+
+		return resp, err
+	}
+}
+
+// reserve - reserve the key of record for the request, the previous record of the key when it was already used
+func reserve(ctx context.Context, store Store, record *Record, lockTimeout time.Duration) (*Record, error) {
+	record.CreatedAt = timeutil.GetTimeNow()
+	record.ExpiresAt = record.CreatedAt.Add(lockTimeout)
+
+	existing, err := store.Reserve(ctx, record)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		return nil, errMismatch
+	case existing.Response == nil:
+		return nil, errInProgress
+	}
+
+	return existing, nil
+}
+
+// complete - keep the response of the key for ttl, the client gets a new response on retry when it could not be kept
+func complete(ctx context.Context, store Store, key string, response *Response, ttl time.Duration) {
+	err := store.Complete(detach(ctx), key, response, timeutil.GetTimeNow().Add(ttl))
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// release - forget the key, it is released even when the request was canceled
+func release(ctx context.Context, store Store, key string) {
+	err := store.Release(detach(ctx), key)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// detach - context of the tenant of the request ctx that is not canceled with the request
+func detach(ctx context.Context) context.Context {
+	return tenantutil.NewContext(context.Background(), tenantutil.FromContext(ctx))
+}
+
+// replay - send the kept response of an HTTP request
+func replay(w http.ResponseWriter, response *Response) {
+	for name, values := range response.Header {
+		w.Header()[name] = append([]string{}, values...)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// replayCall - result of the kept response of a gRPC call
+func replayCall(response *Response) (interface{}, error) {
+	if codes.Code(response.Status) != codes.OK {
+		result := &spb.Status{}
+		err := proto.Unmarshal(response.Body, result)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "There is something error")
+		}
+
+		return nil, status.ErrorProto(result)
+	}
+
+	result := &anypb.Any{}
+	err := proto.Unmarshal(response.Body, result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "There is something error")
+	}
+
+	message, err := result.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "There is something error")
+	}
+
+	return message, nil
+}
+
+// callResponse - response of a gRPC call to keep, false when the same call can succeed on retry
+func callResponse(resp interface{}, err error) (*Response, bool) {
+	if err != nil {
+		result := status.Convert(err)
+		if isRetryable(result.Code()) {
+			return nil, false
+		}
+
+		body, err := proto.Marshal(result.Proto())
+		if err != nil {
+			return nil, false
+		}
+
+		return &Response{Status: int(result.Code()), Body: body}, true
+	}
+
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+
+	result, err := anypb.New(message)
+	if err != nil {
+		return nil, false
+	}
+
+	body, err := proto.Marshal(result)
+	if err != nil {
+		return nil, false
+	}
+
+	return &Response{Status: int(codes.OK), Body: body}, true
+}
+
+// isRetryable - gRPC codes of errors the same call can succeed on
+func isRetryable(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+
+	return false
+}
+
+// isNoStore - whether the response header forbids to store the response, it holds a secret
+func isNoStore(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// scopedKey - key of the actor and transport, the same key sent by another actor is another request
+func scopedKey(ctx context.Context, key string) string {
+	actor := actorutil.FromContext(ctx)
+
+	return actor.Transport + "/" + actor.ID + "/" + key
+}
+
+// fingerprint - hash of the request the key is sent with
+func fingerprint(method string, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func durations() (time.Duration, time.Duration) {
+	return config.GetDuration("IDEMPOTENCY_TTL", 24*time.Hour), config.GetDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute)
+}
+
+// statusError - translate store errors to gRPC status errors
+func statusError(err error) error {
+	switch errorsutil.KindOf(err) {
+	case errorsutil.KindInvalidArgument:
+		return status.Error(codes.InvalidArgument, errorsutil.Message(err))
+	case errorsutil.KindConflict:
+		return status.Error(codes.AlreadyExists, errorsutil.Message(err))
+	case errorsutil.KindDeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, errorsutil.Message(err))
+	case errorsutil.KindUnavailable:
+		return status.Error(codes.Unavailable, errorsutil.Message(err))
+	}
+
+	logger.Error(err)
+
+	return status.Error(codes.Internal, "There is something error")
+}
+
+// responseRecorder - response writer keeping a copy of the response, but the body of a response that must not be stored
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	header      http.Header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.wroteHeader = true
+	r.status = status
+	r.header = r.ResponseWriter.Header().Clone()
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if !isNoStore(r.header) {
+		r.body.Write(data)
+	}
+
+	return r.ResponseWriter.Write(data)
+}
+
+// done - response sent by the handler
+func (r *responseRecorder) done() *Response {
+	r.WriteHeader(http.StatusOK)
+
+	return &Response{
+		Status: r.status,
+		Header: r.header,
+		Body:   r.body.Bytes(),
+	}
+}
@@ -0,0 +1,234 @@
+package idempotencyutil_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	memoryrepository "go-clean-grpc/todo/repository/memory"
+	actorutil "go-clean-grpc/utils/actor"
+	idempotencyutil "go-clean-grpc/utils/idempotency"
+)
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	store := &recordingStore{Store: memoryrepository.NewIdempotencyRepository()}
+	var handler http.Handler
+	handler = actorutil.Middleware(idempotencyutil.Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		body, _ := io.ReadAll(r.Body)
+		switch string(body) {
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "secret":
+			w.Header().Set("Cache-Control", "private, no-store")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key":"tk_secret"}`))
+			return
+		case "nested":
+			// the same key while the first request is in progress
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, r.URL.Path, strings.NewReader("nested"))
+			req.Header.Set(idempotencyutil.Header, r.Header.Get(idempotencyutil.Header))
+			handler.ServeHTTP(rr, req)
+			w.WriteHeader(rr.Code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"` + string(body) + `"}`))
+	})))
+
+	send := func(method string, key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/todo", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyutil.Header, key)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("replay the response of the key", func(t *testing.T) {
+		calls = 0
+
+		rr := send(http.MethodPost, "key-1", "a")
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, `{"id":"a"}`, rr.Body.String())
+		assert.Empty(t, rr.Header().Get(idempotencyutil.ReplayedHeader))
+
+		rr = send(http.MethodPost, "key-1", "a")
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, `{"id":"a"}`, rr.Body.String())
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "true", rr.Header().Get(idempotencyutil.ReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("reject another request with the key", func(t *testing.T) {
+		calls = 0
+
+		send(http.MethodPost, "key-2", "a")
+
+		rr := send(http.MethodPost, "key-2", "b")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Idempotency-Key was already used with another request")
+
+		rr = send(http.MethodPut, "key-2", "a")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("reject the key while in progress", func(t *testing.T) {
+		rr := send(http.MethodPost, "key-3", "nested")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+	t.Run("retry server errors", func(t *testing.T) {
+		calls = 0
+
+		send(http.MethodPost, "key-4", "fail")
+		rr := send(http.MethodPost, "key-4", "fail")
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("responses that must not be stored", func(t *testing.T) {
+		calls = 0
+		store.completed = nil
+
+		rr := send(http.MethodPost, "key-7", "secret")
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, `{"key":"tk_secret"}`, rr.Body.String())
+		assert.Empty(t, store.completed)
+
+		rr = send(http.MethodPost, "key-7", "secret")
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Empty(t, rr.Header().Get(idempotencyutil.ReplayedHeader))
+		assert.Empty(t, store.completed)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("keys are scoped by actor", func(t *testing.T) {
+		calls = 0
+
+		send(http.MethodPost, "key-5", "a")
+
+		req := httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader("b"))
+		req.Header.Set(idempotencyutil.Header, "key-5")
+		req.Header.Set(actorutil.Header, "alice")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("without key or read requests", func(t *testing.T) {
+		calls = 0
+
+		send(http.MethodPost, "", "a")
+		send(http.MethodPost, "", "a")
+		send(http.MethodGet, "key-6", "")
+		send(http.MethodGet, "key-6", "")
+		assert.Equal(t, 4, calls)
+	})
+	t.Run("key too long", func(t *testing.T) {
+		rr := send(http.MethodPost, strings.Repeat("k", 256), "a")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := idempotencyutil.UnaryServerInterceptor(memoryrepository.NewIdempotencyRepository())
+	info := &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/Create"}
+
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+
+		value := req.(*wrapperspb.StringValue).Value
+		switch value {
+		case "missing":
+			return nil, status.Error(codes.NotFound, "todo not found")
+		case "fail":
+			return nil, status.Error(codes.Unavailable, "database unavailable")
+		}
+
+		return wrapperspb.String("created " + value), nil
+	}
+
+	call := func(key string, value string) (interface{}, error) {
+		ctx := context.Background()
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("idempotency-key", key))
+		}
+
+		return interceptor(ctx, wrapperspb.String(value), info, handler)
+	}
+
+	t.Run("replay the response of the key", func(t *testing.T) {
+		calls = 0
+
+		first, err := call("key-1", "a")
+		require.NoError(t, err)
+
+		replayed, err := call("key-1", "a")
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(first.(proto.Message), replayed.(proto.Message)))
+		assert.Equal(t, 1, calls)
+
+		_, err = call("key-1", "b")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("replay errors", func(t *testing.T) {
+		calls = 0
+
+		_, err := call("key-2", "missing")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = call("key-2", "missing")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "todo not found", status.Convert(err).Message())
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("retry errors", func(t *testing.T) {
+		calls = 0
+
+		call("key-3", "fail")
+		_, err := call("key-3", "fail")
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("without key", func(t *testing.T) {
+		calls = 0
+
+		call("", "a")
+		call("", "a")
+		assert.Equal(t, 2, calls)
+	})
+}
+
+// recordingStore - store keeping the responses it completed
+type recordingStore struct {
+	idempotencyutil.Store
+	completed []*idempotencyutil.Response
+}
+
+func (s *recordingStore) Complete(ctx context.Context, key string, response *idempotencyutil.Response, expiresAt time.Time) error {
+	s.completed = append(s.completed, response)
+
+	return s.Store.Complete(ctx, key, response, expiresAt)
+}
@@ -1,7 +1,17 @@
 package paginationutil
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"math"
+	"os"
+	"strings"
+	"sync"
+
+	errorsutil "go-clean-grpc/utils/errors"
 )
 
 // PerPage - get per_page, the default value is 10
@@ -41,3 +51,64 @@ func Offset(currentPage int, perPage int) int {
 
 	return result
 }
+
+// Cursor - position after the last item of a page, encoded as signed page token
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     string        `json:"id"`
+}
+
+var ErrInvalidPageToken error = errorsutil.New(errorsutil.KindInvalidArgument, "invalid page token")
+
+var tokenSecret []byte
+var tokenSecretOnce sync.Once
+
+// EncodeCursor - encode cursor into opaque page token signed with PAGE_TOKEN_SECRET
+func EncodeCursor(cursor *Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + sign(encoded), nil
+}
+
+// DecodeCursor - decode page token, tokens with invalid signature are rejected
+func DecodeCursor(token string) (*Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(sign(parts[0])), []byte(parts[1])) {
+		return nil, ErrInvalidPageToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	cursor := &Cursor{}
+	err = json.Unmarshal(payload, cursor)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	return cursor, nil
+}
+
+func sign(value string) string {
+	tokenSecretOnce.Do(func() {
+		tokenSecret = []byte(os.Getenv("PAGE_TOKEN_SECRET"))
+		if len(tokenSecret) == 0 {
+			// tokens only survive the process lifetime without a configured secret
+			tokenSecret = make([]byte, 32)
+			rand.Read(tokenSecret)
+		}
+	})
+
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -3,6 +3,7 @@ package paginationutil_test
 import (
 	"testing"
 
+	errorsutil "go-clean-grpc/utils/errors"
 	paginationutil "go-clean-grpc/utils/pagination"
 
 	"github.com/stretchr/testify/assert"
@@ -36,3 +37,24 @@ func TestOffset(t *testing.T) {
 	value = paginationutil.Offset(-1, 10)
 	assert.Equal(t, value, 0)
 }
+
+func TestCursor(t *testing.T) {
+	cursor := &paginationutil.Cursor{
+		Sort:   "-priority,due_at",
+		Values: []interface{}{float64(3), nil},
+		ID:     "6361e19d7db4662d84babd76",
+	}
+
+	token, err := paginationutil.EncodeCursor(cursor)
+	assert.NoError(t, err)
+
+	value, err := paginationutil.DecodeCursor(token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, value)
+
+	_, err = paginationutil.DecodeCursor(token + "x")
+	assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+
+	_, err = paginationutil.DecodeCursor("bm90IGEgdG9rZW4")
+	assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+}
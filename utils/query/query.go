@@ -0,0 +1,302 @@
+package queryutil
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	errorsutil "go-clean-grpc/utils/errors"
+)
+
+// Type - value type of field
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeTime
+)
+
+// Filter operator
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpIn       = "in"
+	OpContains = "contains"
+)
+
+// Field - field that can be filtered and sorted
+type Field struct {
+	Name string // public name used in the spec, e.g. due_at
+	Key  string // document key, e.g. dueAt
+	Type Type
+}
+
+// Schema - allowed fields of a resource
+type Schema []Field
+
+// Condition - filter condition, values are typed based on the field type
+type Condition struct {
+	Field    Field
+	Operator string
+	Values   []interface{}
+}
+
+// SortField - sort by field
+type SortField struct {
+	Field Field
+	Desc  bool
+}
+
+// Lookup - find field by public name or document key
+func (s Schema) Lookup(name string) (Field, bool) {
+	for _, field := range s {
+		if field.Name == name || field.Key == name {
+			return field, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// ParseFilter - parse filter spec, conditions are separated by "," and in values by "|"
+// e.g. status:in:pending|in_progress,priority:gte:2,title:contains:report
+func ParseFilter(schema Schema, spec string) ([]Condition, error) {
+	conditions := []Condition{}
+	if strings.TrimSpace(spec) == "" {
+		return conditions, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) != 3 {
+			return nil, invalid("filter %q must be field:operator:value", item)
+		}
+
+		field, ok := schema.Lookup(strings.TrimSpace(parts[0]))
+		if !ok {
+			return nil, invalid("filter field %q is not supported", parts[0])
+		}
+
+		operator := strings.TrimSpace(parts[1])
+		if !allowed(field.Type, operator) {
+			return nil, invalid("filter operator %q is not supported for %s", operator, field.Name)
+		}
+
+		rawValues := []string{parts[2]}
+		if operator == OpIn {
+			rawValues = strings.Split(parts[2], "|")
+		}
+
+		values := make([]interface{}, 0, len(rawValues))
+		for _, raw := range rawValues {
+			value, err := parseValue(field, raw)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		conditions = append(conditions, Condition{
+			Field:    field,
+			Operator: operator,
+			Values:   values,
+		})
+	}
+
+	return conditions, nil
+}
+
+// ParseSort - parse sort spec, fields are separated by "," and "-" prefix is descending
+// e.g. -priority,due_at
+func ParseSort(schema Schema, spec string) ([]SortField, error) {
+	results := []SortField{}
+	if strings.TrimSpace(spec) == "" {
+		return results, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(item, "-"), "+")
+
+		field, ok := schema.Lookup(name)
+		if !ok {
+			return nil, invalid("sort field %q is not supported", name)
+		}
+
+		results = append(results, SortField{
+			Field: field,
+			Desc:  desc,
+		})
+	}
+
+	return results, nil
+}
+
+// FormatSort - format sort fields back into sort spec using public names
+func FormatSort(sort []SortField) string {
+	items := make([]string, 0, len(sort))
+	for _, item := range sort {
+		name := item.Field.Name
+		if item.Desc {
+			name = "-" + name
+		}
+
+		items = append(items, name)
+	}
+
+	return strings.Join(items, ",")
+}
+
+// Coerce - convert decoded JSON value back into the field type, nil is kept as is
+func Coerce(field Field, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case TypeInt:
+		number, ok := value.(float64)
+		if !ok {
+			return nil, invalid("value of %s must be a number", field.Name)
+		}
+
+		return int(number), nil
+	case TypeTime:
+		raw, ok := value.(string)
+		if !ok {
+			return nil, invalid("value of %s must be a date time", field.Name)
+		}
+
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, invalid("value of %s must be a date time", field.Name)
+		}
+
+		return parsed, nil
+	}
+
+	raw, ok := value.(string)
+	if !ok {
+		return nil, invalid("value of %s must be a string", field.Name)
+	}
+
+	return raw, nil
+}
+
+// Text - text searched with its weight
+type Text struct {
+	Value  string
+	Weight int
+}
+
+// TextScore - weighted count of search terms in the texts, 0 when nothing matches or a "-" negated term matches
+// terms match whole words case-insensitively, there is no stemming
+func TextScore(search string, texts ...Text) float64 {
+	score := 0
+	for _, term := range SearchTerms(search) {
+		count := 0
+		for _, text := range texts {
+			for _, word := range Words(text.Value) {
+				if word == term.Word {
+					count += text.Weight
+				}
+			}
+		}
+
+		if term.Negated && count > 0 {
+			return 0
+		}
+		if !term.Negated {
+			score += count
+		}
+	}
+
+	return float64(score)
+}
+
+// SearchTerm - word of search, negated words exclude the match
+type SearchTerm struct {
+	Word    string
+	Negated bool
+}
+
+// SearchTerms - split search into lowercase words, quotes are ignored and "-" prefix negates the word
+func SearchTerms(search string) []SearchTerm {
+	results := []SearchTerm{}
+	for _, item := range strings.Fields(strings.ReplaceAll(search, `"`, " ")) {
+		negated := strings.HasPrefix(item, "-")
+		for _, word := range Words(strings.TrimPrefix(item, "-")) {
+			results = append(results, SearchTerm{Word: word, Negated: negated})
+		}
+	}
+
+	return results
+}
+
+// Words - split text into lowercase words of letters and digits
+func Words(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// EscapeRegex - escape user input to be matched literally inside a regex
+func EscapeRegex(value string) string {
+	return regexp.QuoteMeta(value)
+}
+
+func allowed(fieldType Type, operator string) bool {
+	switch operator {
+	case OpEq, OpNe, OpIn, OpGt, OpGte, OpLt, OpLte:
+		return true
+	case OpContains:
+		return fieldType == TypeString
+	}
+
+	return false
+}
+
+func parseValue(field Field, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch field.Type {
+	case TypeInt:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, invalid("filter value of %s must be a number", field.Name)
+		}
+
+		return value, nil
+	case TypeTime:
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, invalid("filter value of %s must be a RFC3339 date time", field.Name)
+		}
+
+		return value, nil
+	}
+
+	return raw, nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return errorsutil.New(errorsutil.KindInvalidArgument, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,111 @@
+package queryutil_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	errorsutil "go-clean-grpc/utils/errors"
+	queryutil "go-clean-grpc/utils/query"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var schema = queryutil.Schema{
+	{Name: "title", Key: "title", Type: queryutil.TypeString},
+	{Name: "priority", Key: "priority", Type: queryutil.TypeInt},
+	{Name: "due_at", Key: "dueAt", Type: queryutil.TypeTime},
+}
+
+func TestParseFilter(t *testing.T) {
+	conditions, err := queryutil.ParseFilter(schema, "priority:gte:2,title:contains:report,due_at:lt:2022-11-30T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Len(t, conditions, 3)
+
+	assert.Equal(t, "priority", conditions[0].Field.Key)
+	assert.Equal(t, queryutil.OpGte, conditions[0].Operator)
+	assert.Equal(t, []interface{}{2}, conditions[0].Values)
+
+	assert.Equal(t, queryutil.OpContains, conditions[1].Operator)
+	assert.Equal(t, []interface{}{"report"}, conditions[1].Values)
+
+	assert.Equal(t, "dueAt", conditions[2].Field.Key)
+	assert.Equal(t, []interface{}{time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC)}, conditions[2].Values)
+
+	conditions, err = queryutil.ParseFilter(schema, "priority:in:1|3")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 3}, conditions[0].Values)
+
+	conditions, err = queryutil.ParseFilter(schema, "")
+	assert.NoError(t, err)
+	assert.Empty(t, conditions)
+}
+
+func TestParseFilterError(t *testing.T) {
+	specs := []string{
+		"priority",
+		"owner:eq:me",
+		"priority:like:2",
+		"priority:contains:2",
+		"priority:eq:high",
+		"due_at:gt:tomorrow",
+	}
+
+	for _, spec := range specs {
+		_, err := queryutil.ParseFilter(schema, spec)
+		assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument, spec)
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	sort, err := queryutil.ParseSort(schema, "-priority,dueAt")
+	assert.NoError(t, err)
+	assert.Equal(t, []queryutil.SortField{
+		{Field: schema[1], Desc: true},
+		{Field: schema[2], Desc: false},
+	}, sort)
+
+	_, err = queryutil.ParseSort(schema, "-owner")
+	assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+}
+
+func TestFormatSort(t *testing.T) {
+	sort, err := queryutil.ParseSort(schema, "-priority,dueAt")
+	assert.NoError(t, err)
+	assert.Equal(t, "-priority,due_at", queryutil.FormatSort(sort))
+}
+
+func TestCoerce(t *testing.T) {
+	value, err := queryutil.Coerce(schema[1], float64(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	value, err = queryutil.Coerce(schema[2], "2022-11-30T00:00:00.5Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 11, 30, 0, 0, 0, 500000000, time.UTC), value)
+
+	value, err = queryutil.Coerce(schema[2], nil)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = queryutil.Coerce(schema[0], float64(2))
+	assert.ErrorIs(t, err, errorsutil.ErrInvalidArgument)
+}
+
+func TestTextScore(t *testing.T) {
+	title := queryutil.Text{Value: "Write the Report", Weight: 3}
+	description := queryutil.Text{Value: "quarterly report, numbers", Weight: 1}
+
+	assert.Equal(t, float64(4), queryutil.TextScore("report", title, description))
+	assert.Equal(t, float64(5), queryutil.TextScore(`"REPORT" numbers`, title, description))
+	assert.Equal(t, float64(0), queryutil.TextScore("reports", title, description))
+	assert.Equal(t, float64(0), queryutil.TextScore("report -numbers", title, description))
+}
+
+func TestEscapeRegex(t *testing.T) {
+	value := queryutil.EscapeRegex("(.*")
+	assert.Equal(t, `\(\.\*`, value)
+
+	_, err := regexp.Compile(value)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+package ratelimitutil
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - how often the memory store forgets the full buckets
+const sweepInterval = time.Minute
+
+// MemoryStore - store of the token buckets of a single server
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// NewMemoryStore - empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Take - take a token from the bucket of key, a new bucket is full
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Count), updatedAt: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens < 1 {
+		return time.Duration(math.Ceil((1 - b.tokens) / limit.rate() * float64(time.Second))), nil
+	}
+	b.tokens--
+
+	return 0, nil
+}
+
+// refill - add the tokens refilled since the last update, up to the limit count
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens = math.Min(float64(b.limit.Count), b.tokens+elapsed*b.limit.rate())
+	b.updatedAt = now
+}
+
+// sweep - forget the buckets that are full again, a full bucket is the same as no bucket
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Count) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package ratelimitutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ratelimitutil "go-clean-grpc/utils/ratelimit"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := ratelimitutil.NewMemoryStore()
+	limit := ratelimitutil.Limit{Count: 2, Per: time.Second}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	take := func(key string, now time.Time) time.Duration {
+		wait, err := store.Take(context.Background(), key, limit, now)
+		require.NoError(t, err)
+		return wait
+	}
+
+	// the bucket starts full
+	assert.Zero(t, take("alice", now))
+	assert.Zero(t, take("alice", now))
+	assert.Equal(t, 500*time.Millisecond, take("alice", now))
+
+	// another key has its own bucket
+	assert.Zero(t, take("bob", now))
+
+	// a token is refilled every 500ms
+	assert.Equal(t, 250*time.Millisecond, take("alice", now.Add(250*time.Millisecond)))
+	assert.Zero(t, take("alice", now.Add(500*time.Millisecond)))
+	assert.Greater(t, take("alice", now.Add(500*time.Millisecond)), time.Duration(0))
+
+	// the bucket is never fuller than the count, even once forgotten
+	later := now.Add(time.Hour)
+	assert.Zero(t, take("alice", later))
+	assert.Zero(t, take("alice", later))
+	assert.Greater(t, take("alice", later), time.Duration(0))
+}
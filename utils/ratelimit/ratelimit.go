@@ -0,0 +1,354 @@
+package ratelimitutil
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	"go-clean-grpc/pkg/logger"
+	errorsutil "go-clean-grpc/utils/errors"
+	responseutil "go-clean-grpc/utils/response"
+	timeutil "go-clean-grpc/utils/time"
+)
+
+// RetryAfterHeader - HTTP header and gRPC metadata key of the seconds to wait before retrying a limited request
+const RetryAfterHeader = "Retry-After"
+
+// ipRoute - route of the buckets of the IP limiters, every request of an IP takes a token of its single bucket
+const ipRoute = "ip"
+
+// Limit - Count requests every Per, unlimited when Count is 0
+// the bucket holds Count tokens and is refilled evenly over Per
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+// Unlimited - whether requests are not limited
+func (l Limit) Unlimited() bool {
+	return l.Count <= 0 || l.Per <= 0
+}
+
+// rate - tokens refilled every second
+func (l Limit) rate() float64 {
+	return float64(l.Count) / l.Per.Seconds()
+}
+
+// String - limit as parsed by ParseLimit
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+
+	return fmt.Sprintf("%d/%s", l.Count, l.Per)
+}
+
+// ParseLimit - parse a limit as count/period, e.g. 100/m, 5/s or 20/10s, 0 is unlimited
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	count, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q, expected count/period e.g. 100/m", value)
+	}
+
+	result := Limit{}
+	result.Count, _ = strconv.Atoi(strings.TrimSpace(count))
+
+	period = strings.TrimSpace(period)
+	if period != "" && !strings.ContainsAny(period[:1], "0123456789") {
+		period = "1" + period
+	}
+	result.Per, _ = time.ParseDuration(period)
+
+	if result.Count <= 0 || result.Per <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q, expected count/period e.g. 100/m", value)
+	}
+
+	return result, nil
+}
+
+// Store - store of the token buckets, share one store between servers to limit the clients across them
+type Store interface {
+	// Take - take a token from the bucket of key refilled at limit, the wait before the bucket has a token when it is empty
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (time.Duration, error)
+}
+
+// Limiter - token bucket limiter of the requests of each client, by route
+type Limiter struct {
+	store  Store
+	limit  Limit
+	routes map[string]Limit
+}
+
+// NewLimiter - limiter of the buckets of store, routes are limited by their limit of routes, by limit otherwise
+// routes are the HTTP method and chi pattern, e.g. GET /todo/{id}, and the gRPC full methods, e.g. /Todo/GetAll
+func NewLimiter(store Store, limit Limit, routes map[string]Limit) *Limiter {
+	if routes == nil {
+		routes = map[string]Limit{}
+	}
+
+	return &Limiter{
+		store:  store,
+		limit:  limit,
+		routes: routes,
+	}
+}
+
+// NewLimiterFromEnv - limiter of every route to RATE_LIMIT and of the routes of the comma separated RATE_LIMIT_ROUTES,
+// e.g. GET /todo=20/m,/Todo/GetAll=20/m, requests are not limited when both are empty
+func NewLimiterFromEnv(store Store) (*Limiter, error) {
+	limit, err := ParseLimit(os.Getenv("RATE_LIMIT"))
+	if err != nil {
+		return nil, err
+	}
+
+	routes := map[string]Limit{}
+	for _, value := range strings.Split(os.Getenv("RATE_LIMIT_ROUTES"), ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		route, routeLimit, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(route) == "" {
+			return nil, fmt.Errorf("ratelimit: invalid route limit %q, expected route=count/period", value)
+		}
+
+		routes[strings.TrimSpace(route)], err = ParseLimit(routeLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewLimiter(store, limit, routes), nil
+}
+
+// NewIPLimiterFromEnv - limiter of the requests of each IP to RATE_LIMIT_IP, whatever the route and the user,
+// requests are not limited when it is empty
+func NewIPLimiterFromEnv(store Store) (*Limiter, error) {
+	limit, err := ParseLimit(os.Getenv("RATE_LIMIT_IP"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLimiter(store, limit, nil), nil
+}
+
+// Enabled - whether a route is limited
+func (l *Limiter) Enabled() bool {
+	if !l.limit.Unlimited() {
+		return true
+	}
+
+	for _, limit := range l.routes {
+		if !limit.Unlimited() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Limit - limit of the route
+func (l *Limiter) Limit(route string) Limit {
+	if limit, ok := l.routes[route]; ok {
+		return limit
+	}
+
+	return l.limit
+}
+
+// Allow - take a token of the bucket of client for route, the wait before retrying when the request is limited
+// requests are allowed when the store fails, so an unavailable shared store does not take the servers down
+func (l *Limiter) Allow(ctx context.Context, route string, client string) time.Duration {
+	limit := l.Limit(route)
+	if limit.Unlimited() {
+		return 0
+	}
+
+	wait, err := l.store.Take(ctx, route+" "+client, limit, timeutil.GetTimeNow())
+	if err != nil {
+		logger.Error(err)
+		return 0
+	}
+
+	return wait
+}
+
+// Client - identity of the caller, the authenticated user, the IP of addr otherwise
+func Client(ctx context.Context, addr string) string {
+	if claims, ok := pkgauth.FromContext(ctx); ok {
+		if claims.Tenant != "" {
+			return "user:" + claims.Tenant + "/" + claims.Subject
+		}
+
+		return "user:" + claims.Subject
+	}
+
+	return ipClient(addr)
+}
+
+// ipClient - identity of the caller of addr, its IP
+func ipClient(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	return "ip:" + host
+}
+
+// Middleware - limit the HTTP requests of each client by route of routes, limited requests get 429 with a Retry-After header
+// the client IP is the remote address, set it from a trusted proxy header before this middleware when behind one
+func Middleware(limiter *Limiter, routes chi.Routes) func(http.Handler) http.Handler {
+	return middleware(limiter, func(r *http.Request) (string, string) {
+		return route(routes, r), Client(r.Context(), r.RemoteAddr)
+	})
+}
+
+// IPMiddleware - limit the HTTP requests of each IP to the limit of limiter, limited requests get 429 like with Middleware
+// use it before the authentication, so the requests it rejects are limited too
+func IPMiddleware(limiter *Limiter) func(http.Handler) http.Handler {
+	return middleware(limiter, func(r *http.Request) (string, string) {
+		return ipRoute, ipClient(r.RemoteAddr)
+	})
+}
+
+// middleware - limit the HTTP requests by the route and the client of bucket
+func middleware(limiter *Limiter, bucket func(r *http.Request) (string, string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limiter.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, client := bucket(r)
+			wait := limiter.Allow(r.Context(), route, client)
+			if wait > 0 {
+				w.Header().Set(RetryAfterHeader, retryAfter(wait))
+				responseutil.ResponseError(w, r, limitError(wait))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerInterceptor - limit the gRPC calls of each client by full method, limited calls get ResourceExhausted
+// with a retry-after header and the RetryInfo detail
+func UnaryServerInterceptor(limiter *Limiter) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(limiter, func(ctx context.Context, fullMethod string) (string, string) {
+		return fullMethod, Client(ctx, peerAddr(ctx))
+	})
+}
+
+// IPUnaryServerInterceptor - limit the gRPC calls of each IP to the limit of limiter like IPMiddleware, before the authentication
+func IPUnaryServerInterceptor(limiter *Limiter) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(limiter, func(ctx context.Context, fullMethod string) (string, string) {
+		return ipRoute, ipClient(peerAddr(ctx))
+	})
+}
+
+// unaryServerInterceptor - limit the gRPC calls by the route and the client of bucket
+func unaryServerInterceptor(limiter *Limiter, bucket func(ctx context.Context, fullMethod string) (string, string)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		route, client := bucket(ctx, info.FullMethod)
+		wait := limiter.Allow(ctx, route, client)
+		if wait > 0 {
+			grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, retryAfter(wait)))
+			return nil, statusError(wait)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor - limit the gRPC streams of each client like UnaryServerInterceptor, when they are opened
+func StreamServerInterceptor(limiter *Limiter) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(limiter, func(ctx context.Context, fullMethod string) (string, string) {
+		return fullMethod, Client(ctx, peerAddr(ctx))
+	})
+}
+
+// IPStreamServerInterceptor - limit the gRPC streams of each IP like IPUnaryServerInterceptor, when they are opened
+func IPStreamServerInterceptor(limiter *Limiter) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(limiter, func(ctx context.Context, fullMethod string) (string, string) {
+		return ipRoute, ipClient(peerAddr(ctx))
+	})
+}
+
+// streamServerInterceptor - limit the gRPC streams by the route and the client of bucket
+func streamServerInterceptor(limiter *Limiter, bucket func(ctx context.Context, fullMethod string) (string, string)) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		route, client := bucket(ctx, info.FullMethod)
+		wait := limiter.Allow(ctx, route, client)
+		if wait > 0 {
+			stream.SetHeader(metadata.Pairs(RetryAfterHeader, retryAfter(wait)))
+			return statusError(wait)
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// route - method and chi pattern of the route of r, unknown paths share the * pattern
+func route(routes chi.Routes, r *http.Request) string {
+	rctx := chi.NewRouteContext()
+	if routes == nil || !routes.Match(rctx, r.Method, r.URL.Path) {
+		return r.Method + " *"
+	}
+
+	return r.Method + " " + rctx.RoutePattern()
+}
+
+// peerAddr - address of the gRPC client
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	return p.Addr.String()
+}
+
+// retryAfter - seconds of wait rounded up
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
+
+func limitError(wait time.Duration) error {
+	return errorsutil.New(errorsutil.KindResourceExhausted, fmt.Sprintf("too many requests, retry in %s seconds", retryAfter(wait)))
+}
+
+// statusError - ResourceExhausted status with the RetryInfo detail
+func statusError(wait time.Duration) error {
+	st := status.New(codes.ResourceExhausted, errorsutil.Message(limitError(wait)))
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
@@ -0,0 +1,248 @@
+package ratelimitutil_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	ratelimitutil "go-clean-grpc/utils/ratelimit"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]ratelimitutil.Limit{
+		"100/m":  {Count: 100, Per: time.Minute},
+		"5/s":    {Count: 5, Per: time.Second},
+		"20/10s": {Count: 20, Per: 10 * time.Second},
+		" 1/h ":  {Count: 1, Per: time.Hour},
+		"0":      {},
+		"":       {},
+	}
+	for value, expected := range tests {
+		result, err := ratelimitutil.ParseLimit(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, result, value)
+	}
+
+	for _, value := range []string{"100", "a/m", "-1/m", "10/x", "10/0s"} {
+		_, err := ratelimitutil.ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestNewLimiterFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "")
+	t.Setenv("RATE_LIMIT_ROUTES", "")
+	limiter, err := ratelimitutil.NewLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	require.NoError(t, err)
+	assert.False(t, limiter.Enabled())
+
+	t.Setenv("RATE_LIMIT", "100/m")
+	t.Setenv("RATE_LIMIT_ROUTES", "GET /todo=20/m, /Todo/GetAll=20/m,GET /=0")
+	limiter, err = ratelimitutil.NewLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	require.NoError(t, err)
+	assert.True(t, limiter.Enabled())
+	assert.Equal(t, ratelimitutil.Limit{Count: 20, Per: time.Minute}, limiter.Limit("GET /todo"))
+	assert.Equal(t, ratelimitutil.Limit{Count: 20, Per: time.Minute}, limiter.Limit("/Todo/GetAll"))
+	assert.True(t, limiter.Limit("GET /").Unlimited())
+	assert.Equal(t, ratelimitutil.Limit{Count: 100, Per: time.Minute}, limiter.Limit("POST /todo"))
+
+	t.Setenv("RATE_LIMIT_ROUTES", "GET /todo")
+	_, err = ratelimitutil.NewLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	assert.Error(t, err)
+}
+
+func TestNewIPLimiterFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_IP", "")
+	limiter, err := ratelimitutil.NewIPLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	require.NoError(t, err)
+	assert.False(t, limiter.Enabled())
+
+	t.Setenv("RATE_LIMIT_IP", "600/m")
+	limiter, err = ratelimitutil.NewIPLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	require.NoError(t, err)
+	assert.Equal(t, ratelimitutil.Limit{Count: 600, Per: time.Minute}, limiter.Limit("GET /todo"))
+
+	t.Setenv("RATE_LIMIT_IP", "600")
+	_, err = ratelimitutil.NewIPLimiterFromEnv(ratelimitutil.NewMemoryStore())
+	assert.Error(t, err)
+}
+
+func TestClient(t *testing.T) {
+	assert.Equal(t, "ip:10.0.0.1", ratelimitutil.Client(context.Background(), "10.0.0.1:4242"))
+	assert.Equal(t, "ip:10.0.0.1", ratelimitutil.Client(context.Background(), "10.0.0.1"))
+
+	ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+	assert.Equal(t, "user:alice", ratelimitutil.Client(ctx, "10.0.0.1:4242"))
+
+	ctx = pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice", Tenant: "acme"})
+	assert.Equal(t, "user:acme/alice", ratelimitutil.Client(ctx, "10.0.0.1:4242"))
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{Count: 2, Per: time.Hour}, map[string]ratelimitutil.Limit{
+		"GET /todo/{id}": {Count: 1, Per: time.Hour},
+		"GET /":          {},
+	})
+
+	router := chi.NewRouter()
+	router.Use(ratelimitutil.Middleware(limiter, router))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.Get("/", ok)
+	router.Get("/todo", ok)
+	router.Get("/todo/{id}", ok)
+
+	serve := func(path string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/todo", "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, serve("/todo", "10.0.0.1:2").Code)
+	rr := serve("/todo", "10.0.0.1:3")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1800", rr.Header().Get(ratelimitutil.RetryAfterHeader))
+
+	// another client has its own bucket
+	assert.Equal(t, http.StatusOK, serve("/todo", "10.0.0.2:1").Code)
+
+	// the todo share the bucket of their route pattern
+	assert.Equal(t, http.StatusOK, serve("/todo/a", "10.0.0.1:1").Code)
+	rr = serve("/todo/b", "10.0.0.1:1")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "3600", rr.Header().Get(ratelimitutil.RetryAfterHeader))
+
+	// unlimited route
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve("/", "10.0.0.1:1").Code)
+	}
+}
+
+func TestIPMiddleware(t *testing.T) {
+	store := ratelimitutil.NewMemoryStore()
+	limiter := ratelimitutil.NewLimiter(store, ratelimitutil.Limit{Count: 2, Per: time.Hour}, nil)
+
+	router := chi.NewRouter()
+	router.Use(ratelimitutil.IPMiddleware(limiter))
+	router.Use(ratelimitutil.Middleware(limiter, router))
+	router.Get("/todo", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/todo/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// the IP bucket is shared by every route and kept apart from the route buckets of the same store
+	assert.Equal(t, http.StatusOK, serve("/todo", "10.0.0.1:1"))
+	assert.Equal(t, http.StatusOK, serve("/todo/a", "10.0.0.1:2"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/todo/b", "10.0.0.1:3"))
+
+	assert.Equal(t, http.StatusOK, serve("/todo", "10.0.0.2:1"))
+}
+
+func TestIPUnaryServerInterceptor(t *testing.T) {
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{Count: 1, Per: time.Minute}, nil)
+	interceptor := ratelimitutil.IPUnaryServerInterceptor(limiter)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242}})
+
+	// the calls rejected after the limiter take a token too
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Todo/GetAll"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Todo/Get"}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{}, map[string]ratelimitutil.Limit{
+		"/Todo/GetAll": {Count: 1, Per: time.Minute},
+	})
+	interceptor := ratelimitutil.UnaryServerInterceptor(limiter)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242}})
+
+	result, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Todo/GetAll"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Todo/GetAll"}, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, time.Minute.Seconds(), retryInfo.RetryDelay.AsDuration().Seconds(), 1)
+
+	// other methods are not limited
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Todo/Get"}, handler)
+	assert.NoError(t, err)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	limiter := ratelimitutil.NewLimiter(ratelimitutil.NewMemoryStore(), ratelimitutil.Limit{Count: 1, Per: time.Minute}, nil)
+	interceptor := ratelimitutil.StreamServerInterceptor(limiter)
+	stream := &serverStream{ctx: pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	assert.NoError(t, interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/Todo/Watch"}, handler))
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/Todo/Watch"}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, stream.header.Get(ratelimitutil.RetryAfterHeader))
+}
+
+func TestStoreError(t *testing.T) {
+	limiter := ratelimitutil.NewLimiter(failingStore{}, ratelimitutil.Limit{Count: 1, Per: time.Minute}, nil)
+
+	// requests are allowed when the store fails
+	assert.Zero(t, limiter.Allow(context.Background(), "GET /todo", "ip:10.0.0.1"))
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = md
+	return nil
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimitutil.Limit, now time.Time) (time.Duration, error) {
+	return 0, errors.New("store is down")
+}
@@ -3,6 +3,7 @@ package response
 import (
 	"go-clean-grpc/pkg/logger"
 	pkgvalidator "go-clean-grpc/pkg/validator"
+	errorsutil "go-clean-grpc/utils/errors"
 	"net/http"
 
 	"github.com/go-chi/render"
@@ -17,10 +18,11 @@ type ResponseSuccessList struct {
 }
 
 type Meta struct {
-	PerPage     int `json:"per_page"`
-	CurrentPage int `json:"page"`
-	TotalPage   int `json:"page_count"`
-	TotalData   int `json:"total_count"`
+	PerPage       int    `json:"per_page"`
+	CurrentPage   int    `json:"page"`
+	TotalPage     int    `json:"page_count"`
+	TotalData     int    `json:"total_count"`
+	NextPageToken string `json:"next_page_token,omitempty"`
 }
 
 type ResponseSuccess struct {
@@ -47,16 +49,49 @@ func ResponseBodyError(w http.ResponseWriter, r *http.Request, err error) {
 	})
 }
 
-// ResponseError - send response error (500)
+// statusCodes - translation table from domain error kind to HTTP status
+var statusCodes = map[errorsutil.Kind]int{
+	errorsutil.KindInternal:           http.StatusInternalServerError,
+	errorsutil.KindInvalidArgument:    http.StatusBadRequest,
+	errorsutil.KindNotFound:           http.StatusNotFound,
+	errorsutil.KindConflict:           http.StatusConflict,
+	errorsutil.KindFailedPrecondition: http.StatusConflict,
+	errorsutil.KindUnauthenticated:    http.StatusUnauthorized,
+	errorsutil.KindPermissionDenied:   http.StatusForbidden,
+	errorsutil.KindResourceExhausted:  http.StatusTooManyRequests,
+	errorsutil.KindUnavailable:        http.StatusServiceUnavailable,
+	errorsutil.KindDeadlineExceeded:   http.StatusGatewayTimeout,
+	errorsutil.KindCanceled:           499, // client closed request
+	errorsutil.KindAborted:            http.StatusPreconditionFailed,
+}
+
+// ResponseError - send response error, status code based on the error kind (default 500)
 func ResponseError(w http.ResponseWriter, r *http.Request, err error) {
-	logger.Error(err)
+	body := ErrorBody(err)
 
-	render.Status(r, http.StatusInternalServerError)
-	render.JSON(w, r, H{
+	render.Status(r, body["code"].(int))
+	render.JSON(w, r, body)
+}
+
+// ErrorBody - body of response error, internal errors are logged and their message is hidden
+func ErrorBody(err error) H {
+	kind := errorsutil.KindOf(err)
+	code, ok := statusCodes[kind]
+	if !ok || kind == errorsutil.KindInternal {
+		logger.Error(err)
+
+		return H{
+			"success": false,
+			"code":    http.StatusInternalServerError,
+			"message": "There is something error",
+		}
+	}
+
+	return H{
 		"success": false,
-		"code":    http.StatusInternalServerError,
-		"message": "There is something error",
-	})
+		"code":    code,
+		"message": errorsutil.Message(err),
+	}
 }
 
 // ResponseNotFound - send response not found (404)
@@ -69,6 +104,26 @@ func ResponseNotFound(w http.ResponseWriter, r *http.Request, message string) {
 	})
 }
 
+// ResponseNotAcceptable - send response not acceptable (406), none of the accepted media types can be sent
+func ResponseNotAcceptable(w http.ResponseWriter, r *http.Request, message string) {
+	render.Status(r, http.StatusNotAcceptable)
+	render.JSON(w, r, H{
+		"success": false,
+		"code":    http.StatusNotAcceptable,
+		"message": message,
+	})
+}
+
+// ResponseUnsupportedMediaType - send response unsupported media type (415)
+func ResponseUnsupportedMediaType(w http.ResponseWriter, r *http.Request, message string) {
+	render.Status(r, http.StatusUnsupportedMediaType)
+	render.JSON(w, r, H{
+		"success": false,
+		"code":    http.StatusUnsupportedMediaType,
+		"message": message,
+	})
+}
+
 func ResponseCreated(w http.ResponseWriter, r *http.Request, data *ResponseSuccess) {
 	render.Status(r, http.StatusCreated)
 
@@ -0,0 +1,281 @@
+package tenantutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	"go-clean-grpc/pkg/config"
+	errorsutil "go-clean-grpc/utils/errors"
+	responseutil "go-clean-grpc/utils/response"
+)
+
+// Header - HTTP header and gRPC metadata key naming the tenant
+const Header = "X-Tenant-ID"
+
+// Tenant - tenant of a request, the default tenant has an empty ID and is used when tenancy is disabled
+type Tenant struct {
+	ID       string
+	MaxTodos int // quota of todo, unlimited when 0
+}
+
+// validID - tenant ids are used as database, schema and file names
+var validID = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+var (
+	ErrMissingTenant  = errorsutil.New(errorsutil.KindInvalidArgument, "missing tenant, set the "+Header+" header")
+	ErrUnknownTenant  = errorsutil.New(errorsutil.KindInvalidArgument, "unknown tenant")
+	ErrTenantMismatch = errorsutil.New(errorsutil.KindPermissionDenied, "tenant is not allowed for the token")
+)
+
+type contextKey struct{}
+
+// NewContext - context carrying the tenant
+func NewContext(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext - tenant of context, the default tenant without quota when none was set
+func FromContext(ctx context.Context) Tenant {
+	tenant, _ := ctx.Value(contextKey{}).(Tenant)
+
+	return tenant
+}
+
+// ID - id of the tenant of context, empty for the default tenant
+func ID(ctx context.Context) string {
+	return FromContext(ctx).ID
+}
+
+// Name - name of the database, schema or file of the tenant id, base for the default tenant
+func Name(base string, id string) string {
+	if id == "" {
+		return base
+	}
+
+	return base + "_" + id
+}
+
+// Resolver - resolve the tenant of requests among the configured tenants
+type Resolver struct {
+	tenants         map[string]Tenant
+	maxTodos        int
+	crossTenantRole string
+}
+
+// NewResolver - resolver of the tenants ids, tenancy is disabled when there is none
+// maxTodos is the quota of every tenant unless quotas has one, users with crossTenantRole can pick any tenant
+func NewResolver(ids []string, maxTodos int, quotas map[string]int, crossTenantRole string) (*Resolver, error) {
+	resolver := &Resolver{
+		tenants:         map[string]Tenant{},
+		maxTodos:        maxTodos,
+		crossTenantRole: crossTenantRole,
+	}
+
+	for _, id := range ids {
+		if !validID.MatchString(id) {
+			return nil, fmt.Errorf("tenant: invalid tenant id %q, use lowercase letters, digits and _", id)
+		}
+		resolver.tenants[id] = Tenant{ID: id, MaxTodos: maxTodos}
+	}
+
+	for id, quota := range quotas {
+		tenant, ok := resolver.tenants[id]
+		if !ok {
+			return nil, fmt.Errorf("tenant: quota of unknown tenant %q", id)
+		}
+		tenant.MaxTodos = quota
+		resolver.tenants[id] = tenant
+	}
+
+	return resolver, nil
+}
+
+// NewResolverFromEnv - resolver of the comma separated TENANTS, with the quota TENANT_MAX_TODOS (0 = unlimited)
+// and the quotas by tenant of TENANT_QUOTAS, e.g. acme=1000,globex=50
+func NewResolverFromEnv(crossTenantRole string) (*Resolver, error) {
+	ids := splitList(os.Getenv("TENANTS"))
+
+	quotas := map[string]int{}
+	for _, value := range splitList(os.Getenv("TENANT_QUOTAS")) {
+		id, quota, ok := strings.Cut(value, "=")
+		maxTodos, err := strconv.Atoi(strings.TrimSpace(quota))
+		if !ok || err != nil || maxTodos < 0 {
+			return nil, fmt.Errorf("tenant: invalid quota %q, expected tenant=max", value)
+		}
+		quotas[strings.TrimSpace(id)] = maxTodos
+	}
+
+	return NewResolver(ids, config.GetInt("TENANT_MAX_TODOS", 0), quotas, crossTenantRole)
+}
+
+// Enabled - whether requests must name a tenant
+func (r *Resolver) Enabled() bool {
+	return len(r.tenants) > 0
+}
+
+// Tenants - the configured tenants by id, the default tenant when tenancy is disabled
+func (r *Resolver) Tenants() []Tenant {
+	if !r.Enabled() {
+		return []Tenant{{MaxTodos: r.maxTodos}}
+	}
+
+	results := make([]Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		results = append(results, tenant)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	return results
+}
+
+// Resolve - tenant of a request naming the tenant value, the tenant claim of the token wins over value
+// the authenticated user without a tenant claim can only name a tenant with the cross tenant role
+func (r *Resolver) Resolve(ctx context.Context, value string) (Tenant, error) {
+	if !r.Enabled() {
+		return Tenant{MaxTodos: r.maxTodos}, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if claims, ok := pkgauth.FromContext(ctx); ok {
+		switch {
+		case claims.Tenant != "":
+			if value != "" && value != claims.Tenant {
+				return Tenant{}, ErrTenantMismatch
+			}
+			value = claims.Tenant
+		case value != "" && !claims.HasRole(r.crossTenantRole):
+			return Tenant{}, ErrTenantMismatch
+		}
+	}
+
+	if value == "" {
+		return Tenant{}, ErrMissingTenant
+	}
+
+	tenant, ok := r.tenants[value]
+	if !ok {
+		return Tenant{}, ErrUnknownTenant
+	}
+
+	return tenant, nil
+}
+
+// Middleware - set the tenant of HTTP requests from the token or the X-Tenant-ID header
+// requests to the public paths are served without a tenant
+func Middleware(resolver *Resolver, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contains(public, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant, err := resolver.Resolve(r.Context(), r.Header.Get(Header))
+			if err != nil {
+				responseutil.ResponseError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), tenant)))
+		})
+	}
+}
+
+// UnaryServerInterceptor - set the tenant of gRPC calls from the token or the x-tenant-id metadata
+// calls to the public full methods are served without a tenant
+func UnaryServerInterceptor(resolver *Resolver, public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if contains(public, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := resolver.callContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor - set the tenant of gRPC streams like UnaryServerInterceptor
+func StreamServerInterceptor(resolver *Resolver, public ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if contains(public, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx, err := resolver.callContext(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// callContext - context of a gRPC call carrying its tenant, errors are status errors
+func (r *Resolver) callContext(ctx context.Context) (context.Context, error) {
+	value := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(Header); len(values) > 0 {
+			value = values[0]
+		}
+	}
+
+	tenant, err := r.Resolve(ctx, value)
+	if err != nil {
+		code := codes.InvalidArgument
+		if errorsutil.KindOf(err) == errorsutil.KindPermissionDenied {
+			code = codes.PermissionDenied
+		}
+
+		return nil, status.Error(code, errorsutil.Message(err))
+	}
+
+	return NewContext(ctx, tenant), nil
+}
+
+// serverStream - server stream with the context carrying the tenant
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// splitList - trimmed non empty values of a comma separated list
+func splitList(value string) []string {
+	results := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			results = append(results, item)
+		}
+	}
+
+	return results
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
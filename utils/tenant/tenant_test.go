@@ -0,0 +1,188 @@
+package tenantutil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pkgauth "go-clean-grpc/pkg/auth"
+	tenantutil "go-clean-grpc/utils/tenant"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, tenantutil.Tenant{}, tenantutil.FromContext(context.Background()))
+
+	ctx := tenantutil.NewContext(context.Background(), tenantutil.Tenant{ID: "acme", MaxTodos: 10})
+	assert.Equal(t, tenantutil.Tenant{ID: "acme", MaxTodos: 10}, tenantutil.FromContext(ctx))
+	assert.Equal(t, "acme", tenantutil.ID(ctx))
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "todo", tenantutil.Name("todo", ""))
+	assert.Equal(t, "todo_acme", tenantutil.Name("todo", "acme"))
+}
+
+func TestNewResolver(t *testing.T) {
+	_, err := tenantutil.NewResolver([]string{"Acme"}, 0, nil, "admin")
+	assert.Error(t, err)
+
+	_, err = tenantutil.NewResolver([]string{"acme"}, 0, map[string]int{"globex": 1}, "admin")
+	assert.Error(t, err)
+
+	t.Setenv("TENANTS", "globex, acme")
+	t.Setenv("TENANT_MAX_TODOS", "100")
+	t.Setenv("TENANT_QUOTAS", "acme=5")
+	resolver, err := tenantutil.NewResolverFromEnv("admin")
+	require.NoError(t, err)
+	assert.True(t, resolver.Enabled())
+	assert.Equal(t, []tenantutil.Tenant{{ID: "acme", MaxTodos: 5}, {ID: "globex", MaxTodos: 100}}, resolver.Tenants())
+
+	t.Setenv("TENANT_QUOTAS", "acme")
+	_, err = tenantutil.NewResolverFromEnv("admin")
+	assert.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	resolver, err := tenantutil.NewResolver([]string{"acme", "globex"}, 10, nil, "admin")
+	require.NoError(t, err)
+
+	t.Run("success when header", func(t *testing.T) {
+		tenant, err := resolver.Resolve(context.Background(), " acme ")
+		require.NoError(t, err)
+		assert.Equal(t, tenantutil.Tenant{ID: "acme", MaxTodos: 10}, tenant)
+	})
+
+	t.Run("success when tenant claim", func(t *testing.T) {
+		ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice", Tenant: "globex"})
+
+		tenant, err := resolver.Resolve(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, "globex", tenant.ID)
+
+		tenant, err = resolver.Resolve(ctx, "globex")
+		require.NoError(t, err)
+		assert.Equal(t, "globex", tenant.ID)
+	})
+
+	t.Run("error permission denied when header is another tenant than the claim", func(t *testing.T) {
+		ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice", Tenant: "globex", Roles: []string{"admin"}})
+
+		_, err := resolver.Resolve(ctx, "acme")
+		assert.Equal(t, tenantutil.ErrTenantMismatch, err)
+	})
+
+	t.Run("only the cross tenant role picks a tenant without tenant claim", func(t *testing.T) {
+		ctx := pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "alice"})
+		_, err := resolver.Resolve(ctx, "acme")
+		assert.Equal(t, tenantutil.ErrTenantMismatch, err)
+
+		ctx = pkgauth.NewContext(context.Background(), &pkgauth.Claims{Subject: "root", Roles: []string{"admin"}})
+		tenant, err := resolver.Resolve(ctx, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "acme", tenant.ID)
+	})
+
+	t.Run("error invalid argument when missing or unknown tenant", func(t *testing.T) {
+		_, err := resolver.Resolve(context.Background(), "")
+		assert.Equal(t, tenantutil.ErrMissingTenant, err)
+
+		_, err = resolver.Resolve(context.Background(), "initech")
+		assert.Equal(t, tenantutil.ErrUnknownTenant, err)
+	})
+
+	t.Run("default tenant when tenancy is disabled", func(t *testing.T) {
+		resolver, err := tenantutil.NewResolver(nil, 3, nil, "admin")
+		require.NoError(t, err)
+		assert.False(t, resolver.Enabled())
+		assert.Equal(t, []tenantutil.Tenant{{MaxTodos: 3}}, resolver.Tenants())
+
+		tenant, err := resolver.Resolve(context.Background(), "acme")
+		require.NoError(t, err)
+		assert.Equal(t, tenantutil.Tenant{MaxTodos: 3}, tenant)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver, err := tenantutil.NewResolver([]string{"acme"}, 0, nil, "admin")
+	require.NoError(t, err)
+
+	var tenant tenantutil.Tenant
+	handler := tenantutil.Middleware(resolver, "/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = tenantutil.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(tenantutil.Header, "acme")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "acme", tenant.ID)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/todo", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set(tenantutil.Header, "acme")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(pkgauth.NewContext(req.Context(), &pkgauth.Claims{Subject: "alice", Tenant: "globex"})))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// public paths do not need a tenant
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	resolver, err := tenantutil.NewResolver([]string{"acme"}, 0, nil, "admin")
+	require.NoError(t, err)
+	interceptor := tenantutil.UnaryServerInterceptor(resolver)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return tenantutil.ID(ctx), nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+	result, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", result)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx = pkgauth.NewContext(ctx, &pkgauth.Claims{Subject: "alice"})
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.Todo/GetAll"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	resolver, err := tenantutil.NewResolver([]string{"acme"}, 0, nil, "admin")
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+
+	id := ""
+	err = tenantutil.StreamServerInterceptor(resolver)(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/todo.Todo/Watch"}, func(srv interface{}, stream grpc.ServerStream) error {
+		id = tenantutil.ID(stream.Context())
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", id)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -10,3 +10,26 @@ func GetTimeNow() time.Time {
 	// Set timezone,
 	return time.Now().In(loc)
 }
+
+// ParseTime - parse RFC3339 date time, empty value is nil
+func ParseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// FormatTime - format date time as RFC3339, nil is empty
+func FormatTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.Format(time.RFC3339)
+}
@@ -2,6 +2,7 @@ package timeutil_test
 
 import (
 	"testing"
+	"time"
 
 	timeutil "go-clean-grpc/utils/time"
 
@@ -12,3 +13,23 @@ func TestGetTimeNow(t *testing.T) {
 	value := timeutil.GetTimeNow()
 	assert.Equal(t, value, value)
 }
+
+func TestParseTime(t *testing.T) {
+	value, err := timeutil.ParseTime("")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = timeutil.ParseTime("2022-11-02T10:00:00+07:00")
+	assert.NoError(t, err)
+	assert.True(t, value.Equal(time.Date(2022, 11, 2, 3, 0, 0, 0, time.UTC)))
+
+	_, err = timeutil.ParseTime("02-11-2022")
+	assert.Error(t, err)
+}
+
+func TestFormatTime(t *testing.T) {
+	assert.Equal(t, "", timeutil.FormatTime(nil))
+
+	value := time.Date(2022, 11, 2, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2022-11-02T03:00:00Z", timeutil.FormatTime(&value))
+}